@@ -0,0 +1,92 @@
+package wut
+
+import "io"
+
+// ansiState is the state of an ANSIStripper between writes.
+type ansiState int
+
+const (
+	ansiText      ansiState = iota // plain text
+	ansiEscape                     // after ESC
+	ansiCSI                        // within a control sequence, after ESC [
+	ansiString                     // within a string sequence such as OSC, after ESC ]
+	ansiStringEsc                  // after ESC within a string sequence, possibly its terminator
+	ansiCharset                    // after ESC ( or ESC ), preceding a charset designator
+)
+
+// ANSIStripper is an io.Writer removing ANSI escape sequences, such as colors
+// and cursor movement, from the output written through it. Sequences may be
+// split across writes. It is not safe for concurrent use.
+type ANSIStripper struct {
+	w     io.Writer
+	state ansiState
+}
+
+// NewANSIStripper returns an ANSIStripper writing to w.
+func NewANSIStripper(w io.Writer) *ANSIStripper {
+	return &ANSIStripper{w: w}
+}
+
+// Write writes p to the underlying writer with any escape sequences removed.
+func (s *ANSIStripper) Write(p []byte) (int, error) {
+	buf := make([]byte, 0, len(p))
+	buf, s.state = stripANSI(buf, p, s.state)
+	if len(buf) > 0 {
+		if _, err := s.w.Write(buf); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// StripANSI returns b with any ANSI escape sequences removed.
+func StripANSI(b []byte) []byte {
+	out, _ := stripANSI(make([]byte, 0, len(b)), b, ansiText)
+	return out
+}
+
+// stripANSI appends the text of p outside of escape sequences to dst, starting
+// in the given state, and returns the result along with the final state.
+func stripANSI(dst, p []byte, state ansiState) ([]byte, ansiState) {
+	for _, c := range p {
+		switch state {
+		case ansiText:
+			if c == 0x1b {
+				state = ansiEscape
+			} else {
+				dst = append(dst, c)
+			}
+		case ansiEscape:
+			switch c {
+			case '[':
+				state = ansiCSI
+			case ']', 'P', 'X', '^', '_':
+				state = ansiString
+			case '(', ')':
+				state = ansiCharset
+			default:
+				state = ansiText // a two byte sequence, such as ESC c
+			}
+		case ansiCSI:
+			if c >= 0x40 && c <= 0x7e {
+				state = ansiText
+			}
+		case ansiString:
+			switch c {
+			case 0x07:
+				state = ansiText
+			case 0x1b:
+				state = ansiStringEsc
+			}
+		case ansiStringEsc:
+			if c == '\\' {
+				state = ansiText
+			} else {
+				state = ansiString
+			}
+		case ansiCharset:
+			state = ansiText
+		}
+	}
+	return dst, state
+}
@@ -0,0 +1,36 @@
+package wut
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripANSI(t *testing.T) {
+	tests := map[string]string{
+		"plain text\n":                              "plain text\n",
+		"\x1b[1;31merror\x1b[0m: failed":            "error: failed",
+		"\x1b]0;window title\x07prompt$ ":           "prompt$ ",
+		"\x1b]8;;http://x\x1b\\link\x1b]8;;\x1b\\!": "link!",
+		"\x1b(Bascii\x1bc reset":                    "ascii reset",
+		"progress\r\x1b[2Kdone\n":                   "progress\rdone\n",
+	}
+	for in, want := range tests {
+		if got := string(StripANSI([]byte(in))); got != want {
+			t.Errorf("StripANSI(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestANSIStripper(t *testing.T) {
+	var buf strings.Builder
+	s := NewANSIStripper(&buf)
+	// sequences split across writes are still removed
+	for _, p := range []string{"\x1b", "[3", "2mok\x1b[", "0m\n"} {
+		if n, err := s.Write([]byte(p)); n != len(p) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", p, n, err)
+		}
+	}
+	if got, want := buf.String(), "ok\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
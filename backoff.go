@@ -0,0 +1,104 @@
+package wut
+
+import (
+	"math"
+	"time"
+)
+
+// Backoff is a strategy for growing the delay between retries over
+// consecutive failed attempts, starting from [Runner.RetryDelay].
+type Backoff int
+
+const (
+	BackoffFixed        Backoff = iota // retry after RetryDelay every time
+	BackoffExponential                 // multiply the delay by BackoffFactor (default 2) after each failure
+	BackoffFibonacci                   // grow the delay as RetryDelay times the Fibonacci sequence 1, 1, 2, 3, 5...
+	BackoffDecorrelated                // pick a random delay between RetryDelay and BackoffFactor (default 3) times the previous delay
+)
+
+func (b Backoff) String() string {
+	switch b {
+	case BackoffFixed:
+		return "fixed"
+	case BackoffExponential:
+		return "exponential"
+	case BackoffFibonacci:
+		return "fibonacci"
+	case BackoffDecorrelated:
+		return "decorrelated"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements [encoding.TextMarshaler], encoding the strategy as
+// its String, such as for a [Config].
+func (b Backoff) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler], decoding a strategy
+// encoded by MarshalText.
+func (b *Backoff) UnmarshalText(text []byte) error {
+	return unmarshalEnum(b, text, BackoffDecorrelated)
+}
+
+// backoffDelay returns the delay before retrying after the given number of
+// consecutive failed attempts, according to the Backoff of r and capped by its
+// MaxDelay, prior to any Jitter. Following a success, failures is zero and the
+// delay is RetryDelay.
+func (r *Runner) backoffDelay(failures uint) time.Duration {
+	delay := r.growDelay(failures)
+	if r.MaxDelay > 0 {
+		delay = min(delay, r.MaxDelay)
+	}
+	return delay
+}
+
+// growDelay returns the delay after failures according to Delays or Backoff,
+// uncapped.
+func (r *Runner) growDelay(failures uint) time.Duration {
+	if n := uint(len(r.Delays)); n > 0 {
+		return r.Delays[min(max(failures, 1), n)-1]
+	}
+	base := r.RetryDelay
+	if failures == 0 || base <= 0 {
+		return base
+	}
+	var delay float64
+	switch r.Backoff {
+	case BackoffExponential:
+		delay = float64(base) * math.Pow(r.backoffFactor(2), float64(failures-1))
+	case BackoffFibonacci:
+		a, b := 1.0, 1.0
+		for range failures - 1 {
+			a, b = b, a+b
+			if a > math.MaxInt64 {
+				break
+			}
+		}
+		delay = float64(base) * a
+	case BackoffDecorrelated:
+		prev := max(r.prevDelay, base)
+		upper := float64(prev) * r.backoffFactor(3)
+		if upper > float64(base) && upper < math.MaxInt64 {
+			delay = float64(base + r.randDuration(time.Duration(upper)-base))
+		} else {
+			delay = max(upper, float64(base))
+		}
+	default:
+		return base
+	}
+	if delay >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(delay)
+}
+
+// backoffFactor returns BackoffFactor, or def if it is not greater than 1.
+func (r *Runner) backoffFactor(def float64) float64 {
+	if r.BackoffFactor > 1 {
+		return r.BackoffFactor
+	}
+	return def
+}
@@ -0,0 +1,115 @@
+package wut
+
+import (
+	"io"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestRunner_backoffDelay(t *testing.T) {
+	tests := []struct {
+		backoff Backoff
+		factor  float64
+		want    []time.Duration // for 0, 1, 2... consecutive failures
+	}{
+		{BackoffFixed, 0, []time.Duration{1, 1, 1, 1, 1}},
+		{BackoffExponential, 0, []time.Duration{1, 1, 2, 4, 8}},
+		{BackoffExponential, 3, []time.Duration{1, 1, 3, 9, 27}},
+		{BackoffFibonacci, 0, []time.Duration{1, 1, 1, 2, 3, 5, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.backoff.String(), func(t *testing.T) {
+			r := NewRunner(t.Context(), "cmd")
+			r.RetryDelay = time.Second
+			r.Backoff, r.BackoffFactor = tt.backoff, tt.factor
+			var got []time.Duration
+			for n := range uint(len(tt.want)) {
+				got = append(got, r.backoffDelay(n)/time.Second)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("decorrelated", func(t *testing.T) {
+		r := NewRunner(t.Context(), "cmd")
+		r.RetryDelay = time.Second
+		r.Backoff = BackoffDecorrelated
+		r.SetRandSource(rand.NewPCG(1, 2))
+		for n := range uint(20) {
+			prev := max(r.prevDelay, r.RetryDelay)
+			d := r.backoffDelay(n)
+			if n > 0 && (d < r.RetryDelay || d >= 3*prev) {
+				t.Errorf("failures %d: got %v, want in [%v, %v)", n, d, r.RetryDelay, 3*prev)
+			}
+			r.prevDelay = d
+		}
+	})
+
+	t.Run("delays", func(t *testing.T) {
+		r := NewRunner(t.Context(), "cmd")
+		r.RetryDelay = time.Hour // ignored
+		r.Backoff = BackoffExponential
+		r.Delays = []time.Duration{1, 5, 30}
+		var got []time.Duration
+		for n := range uint(6) {
+			got = append(got, r.backoffDelay(n))
+		}
+		if want := []time.Duration{1, 1, 5, 30, 30, 30}; !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("max delay", func(t *testing.T) {
+		r := NewRunner(t.Context(), "cmd")
+		r.RetryDelay = time.Second
+		r.MaxDelay = 5 * time.Second
+		r.SetRandSource(rand.NewPCG(1, 2))
+		for _, b := range []Backoff{BackoffExponential, BackoffFibonacci, BackoffDecorrelated} {
+			r.Backoff, r.prevDelay = b, time.Hour
+			if d := r.backoffDelay(100); d != r.MaxDelay {
+				t.Errorf("%v: got %v, want %v", b, d, r.MaxDelay)
+			}
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		r := NewRunner(t.Context(), "cmd")
+		r.RetryDelay = time.Hour
+		for _, b := range []Backoff{BackoffExponential, BackoffFibonacci, BackoffDecorrelated} {
+			r.Backoff, r.prevDelay = b, time.Duration(1<<62)
+			if d := r.backoffDelay(1000); d < time.Hour {
+				t.Errorf("%v: got %v, want a saturated delay", b, d)
+			}
+		}
+	})
+}
+
+func TestRunner_Backoff(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "flaky")
+		r.SetExecutor(&scriptedExecutor{outputs: make([]string, 6), exitcode: []int{1, 1, 1, 0, 1, 0}})
+		r.CommandOptions.Stdout = io.Discard
+		r.RetryDelay = time.Second
+		r.Backoff = BackoffExponential
+		r.ContinueOnSuccess = true
+		r.MaxRuns = 6
+		var delays []time.Duration
+		r.Observe(func(e Event) {
+			if e.Kind == EventDelay {
+				delays = append(delays, e.Delay)
+			}
+		})
+		r.Run()
+
+		// the delay grows over consecutive failures, and resets on success
+		want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, time.Second, time.Second, time.Second}
+		if !slices.Equal(delays, want) {
+			t.Errorf("got %v, want %v", delays, want)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package wut
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// BenchmarkResult summarizes the measured runs of a benchmark.
+type BenchmarkResult struct {
+	Durations []time.Duration // durations of the successful measured runs, in order
+	Failures  uint            // number of measured runs that failed
+}
+
+// Benchmark executes the command warmup times, ignoring the results, followed
+// by runs measured times, returning a summary of the measured durations.
+//
+// All runs are executed regardless of their outcome, with the configured
+// RetryDelay between each, and so Benchmark overrides MaxRuns and
+// ContinueOnSuccess, and ignores UntilFailure, FailOnOutput, AbortCodes,
+// RetryCodes, and RetryTimeoutsOnly, for the duration of the call only. As
+// with [Runner.Run], the Runner can be stopped early via its context, in
+// which case the results so far are returned along with the reason for
+// stopping. Failed runs are counted but excluded from the duration statistics.
+func (r *Runner) Benchmark(warmup, runs uint) (BenchmarkResult, error) {
+	var (
+		result BenchmarkResult
+		seen   uint
+	)
+	err := r.runAll(warmup+runs, 0, func(a Attempt) {
+		if seen++; seen <= warmup {
+			return
+		}
+		if a.Err != nil {
+			result.Failures++
+		} else {
+			result.Durations = append(result.Durations, a.Duration)
+		}
+	})
+	return result, err
+}
+
+func (b BenchmarkResult) sorted() []time.Duration {
+	return slices.Sorted(slices.Values(b.Durations))
+}
+
+// Min returns the shortest measured duration.
+func (b BenchmarkResult) Min() time.Duration {
+	if len(b.Durations) == 0 {
+		return 0
+	}
+	return slices.Min(b.Durations)
+}
+
+// Max returns the longest measured duration.
+func (b BenchmarkResult) Max() time.Duration {
+	if len(b.Durations) == 0 {
+		return 0
+	}
+	return slices.Max(b.Durations)
+}
+
+// Mean returns the arithmetic mean of the measured durations.
+func (b BenchmarkResult) Mean() time.Duration {
+	if len(b.Durations) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range b.Durations {
+		total += d
+	}
+	return total / time.Duration(len(b.Durations))
+}
+
+// StdDev returns the population standard deviation of the measured durations.
+func (b BenchmarkResult) StdDev() time.Duration {
+	if len(b.Durations) == 0 {
+		return 0
+	}
+	mean := float64(b.Mean())
+	var sum float64
+	for _, d := range b.Durations {
+		sum += (float64(d) - mean) * (float64(d) - mean)
+	}
+	return time.Duration(math.Sqrt(sum / float64(len(b.Durations))))
+}
+
+// Percentile returns the p-th percentile (0-100) of the measured durations,
+// using the nearest-rank method.
+func (b BenchmarkResult) Percentile(p float64) time.Duration {
+	sorted := b.sorted()
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+// HistogramBucket is a bucket of a duration histogram, counting the measured
+// durations in the half-open interval [Low, High).
+type HistogramBucket struct {
+	Low, High time.Duration
+	Count     int
+}
+
+// Histogram divides the range of measured durations into n equally sized
+// buckets, returning the number of durations in each. The final bucket also
+// includes the maximum duration.
+func (b BenchmarkResult) Histogram(n int) []HistogramBucket {
+	if len(b.Durations) == 0 || n < 1 {
+		return nil
+	}
+	lo, hi := b.Min(), b.Max()
+	width := (hi - lo) / time.Duration(n)
+	if width == 0 {
+		return []HistogramBucket{{Low: lo, High: hi, Count: len(b.Durations)}}
+	}
+
+	buckets := make([]HistogramBucket, n)
+	for i := range buckets {
+		buckets[i].Low = lo + time.Duration(i)*width
+		buckets[i].High = buckets[i].Low + width
+	}
+	buckets[n-1].High = hi
+	for _, d := range b.Durations {
+		i := min(int((d-lo)/width), n-1)
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// String returns a human readable summary of the results, including a
+// histogram of the measured durations.
+func (b BenchmarkResult) String() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "runs: %d (%d failed)\n", len(b.Durations)+int(b.Failures), b.Failures)
+	if len(b.Durations) == 0 {
+		return s.String()
+	}
+	fmt.Fprintf(&s, "min %v, mean %v ± %v, median %v, p95 %v, max %v\n",
+		b.Min(), b.Mean(), b.StdDev(), b.Percentile(50), b.Percentile(95), b.Max())
+
+	buckets := b.Histogram(10)
+	largest := 0
+	for _, bucket := range buckets {
+		largest = max(largest, bucket.Count)
+	}
+	for _, bucket := range buckets {
+		bar := strings.Repeat("#", (bucket.Count*40+largest-1)/largest)
+		fmt.Fprintf(&s, "%12v - %-12v %-40s %d\n", bucket.Low, bucket.High, bar, bucket.Count)
+	}
+	return s.String()
+}
@@ -0,0 +1,100 @@
+package wut
+
+import (
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestRunner_Benchmark(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{sleep: 10 * time.Millisecond})
+
+		got, err := r.Benchmark(2, 5)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if r.RunsCompleted() != 7 {
+			t.Errorf("runs completed: got %d, want 7", r.RunsCompleted())
+		}
+		if len(got.Durations) != 5 || got.Failures != 0 {
+			t.Errorf("got %d durations and %d failures, want 5 and 0", len(got.Durations), got.Failures)
+		}
+		if got.Mean() != 10*time.Millisecond {
+			t.Errorf("mean: got %v, want %v", got.Mean(), 10*time.Millisecond)
+		}
+	})
+
+	t.Run("reused", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			r := NewRunnerWithExecutor(t.Context(), mockExecutor{sleep: 10 * time.Millisecond})
+			for range 2 {
+				got, err := r.Benchmark(2, 3)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(got.Durations) != 3 {
+					t.Errorf("got %d durations, want 3", len(got.Durations))
+				}
+			}
+			if r.MaxRuns != 0 || r.ContinueOnSuccess {
+				t.Errorf("got MaxRuns %d, ContinueOnSuccess %v, want unchanged", r.MaxRuns, r.ContinueOnSuccess)
+			}
+		})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			r := NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1})
+			r.UntilFailure = true
+			r.AbortCodes = []int{1}
+			got, err := r.Benchmark(1, 3)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got.Durations) != 0 || got.Failures != 3 {
+				t.Errorf("got %d durations and %d failures, want 0 and 3", len(got.Durations), got.Failures)
+			}
+			if !r.UntilFailure || !slices.Equal(r.AbortCodes, []int{1}) {
+				t.Errorf("got UntilFailure %v, AbortCodes %v, want unchanged", r.UntilFailure, r.AbortCodes)
+			}
+		})
+	})
+}
+
+func TestBenchmarkResult(t *testing.T) {
+	ms := time.Millisecond
+	b := BenchmarkResult{Durations: []time.Duration{4 * ms, 1 * ms, 3 * ms, 2 * ms, 10 * ms}}
+
+	if got := b.Min(); got != 1*ms {
+		t.Errorf("min: got %v", got)
+	}
+	if got := b.Max(); got != 10*ms {
+		t.Errorf("max: got %v", got)
+	}
+	if got := b.Mean(); got != 4*ms {
+		t.Errorf("mean: got %v", got)
+	}
+	if got := b.Percentile(50); got != 3*ms {
+		t.Errorf("median: got %v", got)
+	}
+	if got := b.Percentile(100); got != 10*ms {
+		t.Errorf("p100: got %v", got)
+	}
+	if got := b.StdDev().Round(time.Microsecond); got != 3162*time.Microsecond {
+		t.Errorf("stddev: got %v", got)
+	}
+
+	var counts []int
+	for _, bucket := range b.Histogram(3) {
+		counts = append(counts, bucket.Count)
+	}
+	if want := []int{3, 1, 1}; !slices.Equal(counts, want) {
+		t.Errorf("histogram counts: got %v, want %v", counts, want)
+	}
+
+	if got := (BenchmarkResult{}).String(); got != "runs: 0 (0 failed)\n" {
+		t.Errorf("empty summary: got %q", got)
+	}
+}
@@ -0,0 +1,109 @@
+package wut
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultCgroupParent = "/sys/fs/cgroup"
+	cgroupCPUPeriod     = 100000 // microseconds, the kernel default for cpu.max
+)
+
+// setCgroup creates a transient cgroup with the limits of res, and configures
+// cmd to start within it. The returned finish function must be called with
+// the result of the command once it has completed, and removes the cgroup,
+// returning the result with ErrOOMKilled joined if the command was killed for
+// exceeding its memory limit.
+func setCgroup(cmd *exec.Cmd, res Resources) (finish func(error) error, err error) {
+	parent := res.CgroupParent
+	if parent == "" {
+		parent = defaultCgroupParent
+	}
+	dir := filepath.Join(parent, "wut-"+strings.ToLower(rand.Text()[:12]))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("wut: creating cgroup: %w", err)
+	}
+
+	cleanup := func() {
+		// kill any lingering descendants so that the cgroup can be removed
+		os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0)
+		for range 50 {
+			if err := os.Remove(dir); err == nil || !errors.Is(err, syscall.EBUSY) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if err := writeCgroupLimits(dir, res); err != nil {
+		cleanup()
+		return nil, err
+	}
+	fd, err := syscall.Open(dir, syscall.O_DIRECTORY|syscall.O_RDONLY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("wut: opening cgroup: %w", err)
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = fd
+
+	finish = func(err error) error {
+		syscall.Close(fd)
+		if err != nil && cgroupOOMKilled(dir) {
+			err = errors.Join(err, ErrOOMKilled)
+		}
+		cleanup()
+		return err
+	}
+	return finish, nil
+}
+
+func writeCgroupLimits(dir string, res Resources) error {
+	if res.MemoryMax > 0 {
+		limit := strconv.FormatInt(res.MemoryMax, 10)
+		if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(limit), 0); err != nil {
+			return fmt.Errorf("wut: setting cgroup memory limit: %w", err)
+		}
+		// disable swap so that the limit is enforced by the OOM killer
+		os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0)
+	}
+	if res.CPUMax > 0 {
+		quota := max(int64(res.CPUMax*cgroupCPUPeriod), 1000)
+		limit := fmt.Sprintf("%d %d", quota, cgroupCPUPeriod)
+		if err := os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(limit), 0); err != nil {
+			return fmt.Errorf("wut: setting cgroup CPU limit: %w", err)
+		}
+	}
+	return nil
+}
+
+// cgroupOOMKilled reports whether any process in the cgroup has been killed by
+// the OOM killer.
+func cgroupOOMKilled(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "memory.events"))
+	if err != nil {
+		return false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if n, ok := strings.CutPrefix(scanner.Text(), "oom_kill "); ok {
+			return n != "0"
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package wut
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cgroupParentForTest returns a cgroup v2 directory in which the test may
+// create transient cgroups, skipping the test if there is none.
+func cgroupParentForTest(t *testing.T) string {
+	t.Helper()
+	parent := os.Getenv("WUT_TEST_CGROUP_PARENT")
+	if parent == "" {
+		parent = defaultCgroupParent
+	}
+	controllers, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		t.Skipf("cgroup v2 not available at %s: %v", parent, err)
+	}
+	for _, c := range []string{"memory", "cpu"} {
+		if !strings.Contains(" "+string(controllers)+" ", " "+c+" ") {
+			t.Skipf("%s controller not enabled in %s", c, parent)
+		}
+	}
+	probe := filepath.Join(parent, "wut-test-probe")
+	if err := os.Mkdir(probe, 0o755); err != nil {
+		t.Skipf("cgroup %s not writable: %v", parent, err)
+	}
+	os.Remove(probe)
+	return parent
+}
+
+func TestCmdExecutor_Cgroup(t *testing.T) {
+	parent := cgroupParentForTest(t)
+
+	t.Run("limits", func(t *testing.T) {
+		var stdout bytes.Buffer
+		opts := CommandOpts{
+			Stdout: &stdout,
+			Resources: Resources{
+				MemoryMax:    64 << 20,
+				CPUMax:       0.5,
+				CgroupParent: parent,
+			},
+		}
+		script := `d=/sys/fs/cgroup$(cut -d: -f3 /proc/self/cgroup); echo $(cat $d/memory.max) $(cat $d/cpu.max)`
+		if err := (CmdExecutor{}).Run(t.Context(), opts, "sh", "-c", script); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, want := strings.TrimSpace(stdout.String()), "67108864 50000 100000"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		assertNoTransientCgroups(t, parent)
+	})
+
+	t.Run("oom", func(t *testing.T) {
+		opts := CommandOpts{
+			Resources: Resources{MemoryMax: 8 << 20, CgroupParent: parent},
+		}
+		// grow a shell variable without bound until the OOM killer steps in
+		script := `x=a; while true; do x="$x$x"; done`
+		err := CmdExecutor{}.Run(t.Context(), opts, "sh", "-c", script)
+		if !errors.Is(err, ErrOOMKilled) {
+			t.Errorf("got error %v, want %v", err, ErrOOMKilled)
+		}
+		assertNoTransientCgroups(t, parent)
+	})
+}
+
+func assertNoTransientCgroups(t *testing.T, parent string) {
+	t.Helper()
+	matches, _ := filepath.Glob(filepath.Join(parent, "wut-*"))
+	if len(matches) > 0 {
+		t.Errorf("transient cgroups not removed: %v", matches)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package wut
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// setCgroup is not supported on this platform.
+func setCgroup(cmd *exec.Cmd, res Resources) (finish func(error) error, err error) {
+	return nil, errors.New("wut: cgroup confinement is only supported on Linux")
+}
@@ -0,0 +1,92 @@
+// Package chaos provides a [wut.Executor] decorator that injects failures,
+// delays, and partial output around a real Executor.
+//
+// It is intended for testing how retry policies and the code embedding a
+// Runner behave under controlled adverse conditions.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// ErrInjected is returned for runs failed by fault injection.
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Executor wraps an Executor, injecting faults into its runs.
+//
+// Each run is first delayed by Delay plus a random duration up to DelayJitter.
+// Then, with probability FailureRate, the run fails with [ErrInjected] after
+// writing PartialOutput to stdout, without running the wrapped Executor.
+// Otherwise, the run is passed through to the wrapped Executor.
+//
+// An Executor must not be copied after first use.
+type Executor struct {
+	Executor wut.Executor // wrapped executor, defaults to wut.CmdExecutor
+
+	FailureRate   float64       // probability in [0, 1] that a run fails
+	PartialOutput string        // output written to stdout prior to an injected failure
+	Delay         time.Duration // latency added before each run
+	DelayJitter   time.Duration // maximum random latency added in addition to Delay
+
+	// Rand is the source of randomness for injected faults. If nil, a randomly
+	// seeded source is used. Provide a seeded source for reproducible faults.
+	Rand *rand.Rand
+
+	mu sync.Mutex // guards Rand, which is not safe for concurrent use
+}
+
+// verify Executor implements the wut.Executor interface
+var _ wut.Executor = (*Executor)(nil)
+
+func (e *Executor) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	delay, fail := e.roll()
+
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	if fail {
+		if e.PartialOutput != "" && opts.Stdout != nil {
+			if _, err := io.WriteString(opts.Stdout, e.PartialOutput); err != nil {
+				return errors.Join(ErrInjected, err)
+			}
+		}
+		return ErrInjected
+	}
+
+	executor := e.Executor
+	if executor == nil {
+		executor = wut.CmdExecutor{}
+	}
+	return executor.Run(ctx, opts, name, args...)
+}
+
+// roll determines the delay and failure outcome for a run.
+func (e *Executor) roll() (delay time.Duration, fail bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rnd := e.Rand
+	if rnd == nil {
+		rnd = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+		e.Rand = rnd
+	}
+
+	delay = e.Delay
+	if e.DelayJitter > 0 {
+		delay += time.Duration(rnd.Int64N(int64(e.DelayJitter)))
+	}
+	fail = e.FailureRate > 0 && rnd.Float64() < e.FailureRate
+	return delay, fail
+}
@@ -0,0 +1,89 @@
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// countingExecutor is a wut.Executor that always succeeds, counting its runs.
+type countingExecutor struct{ runs int }
+
+func (ce *countingExecutor) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	ce.runs++
+	return nil
+}
+
+func TestExecutor_Run(t *testing.T) {
+	t.Run("passthrough", func(t *testing.T) {
+		inner := &countingExecutor{}
+		e := &Executor{Executor: inner}
+		for range 10 {
+			if err := e.Run(t.Context(), wut.CommandOpts{}, "cmd"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if inner.runs != 10 {
+			t.Errorf("inner runs: got %d, want 10", inner.runs)
+		}
+	})
+
+	t.Run("injected failure with partial output", func(t *testing.T) {
+		inner := &countingExecutor{}
+		e := &Executor{Executor: inner, FailureRate: 1, PartialOutput: "half a li"}
+
+		var stdout bytes.Buffer
+		err := e.Run(t.Context(), wut.CommandOpts{Stdout: &stdout}, "cmd")
+		if !errors.Is(err, ErrInjected) {
+			t.Errorf("got %v, want %v", err, ErrInjected)
+		}
+		if got := stdout.String(); got != "half a li" {
+			t.Errorf("stdout: got %q, want %q", got, "half a li")
+		}
+		if inner.runs != 0 {
+			t.Errorf("inner runs: got %d, want 0", inner.runs)
+		}
+	})
+
+	t.Run("seeded failures are reproducible", func(t *testing.T) {
+		outcomes := func() (fails []bool) {
+			e := &Executor{Executor: &countingExecutor{}, FailureRate: 0.5, Rand: rand.New(rand.NewPCG(1, 2))}
+			for range 20 {
+				fails = append(fails, e.Run(t.Context(), wut.CommandOpts{}, "cmd") != nil)
+			}
+			return fails
+		}
+		a, b := outcomes(), outcomes()
+		for i := range a {
+			if a[i] != b[i] {
+				t.Fatalf("outcome %d differs between identically seeded executors", i)
+			}
+		}
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			e := &Executor{Executor: &countingExecutor{}, Delay: time.Second, DelayJitter: time.Second}
+
+			start := time.Now()
+			if err := e.Run(t.Context(), wut.CommandOpts{}, "cmd"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < time.Second || elapsed >= 2*time.Second {
+				t.Errorf("elapsed: got %v, want in [1s, 2s)", elapsed)
+			}
+
+			ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+			defer cancel()
+			if err := e.Run(ctx, wut.CommandOpts{}, "cmd"); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+			}
+		})
+	})
+}
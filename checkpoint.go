@@ -0,0 +1,81 @@
+package wut
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checkpoint is the state of a Runner persisted to its StateFile.
+type checkpoint struct {
+	Attempts    uint      `json:"attempts"`     // attempts completed
+	LastAttempt time.Time `json:"last_attempt"` // time the last attempt ended
+}
+
+// loadCheckpoint resumes from the checkpoint in the StateFile of r, if any.
+// A checkpoint which cannot be read is logged and ignored.
+func (r *Runner) loadCheckpoint() {
+	data, err := os.ReadFile(r.StateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	var cp checkpoint
+	if err == nil {
+		err = json.Unmarshal(data, &cp)
+	}
+	if err != nil {
+		r.log(slog.LevelWarn, "Ignoring unreadable state file", "path", r.StateFile, "error", err)
+		return
+	}
+
+	r.shared.runsCompleted.Store(uint64(cp.Attempts))
+	if !r.ContinueOnSuccess && !r.UntilFailure && r.ConsecutiveSuccesses <= 1 {
+		r.failures = cp.Attempts // the state file is removed on success, so each attempt failed
+	}
+	r.resumedAfter = cp.LastAttempt
+	r.log(slog.LevelInfo, "Resuming from state file", "path", r.StateFile, "attempts", cp.Attempts)
+}
+
+// saveCheckpoint atomically replaces the StateFile of r with its current
+// state, following an attempt which ended at end.
+func (r *Runner) saveCheckpoint(end time.Time) {
+	data, err := json.Marshal(checkpoint{Attempts: r.RunsCompleted(), LastAttempt: end})
+	if err == nil {
+		err = writeFileAtomic(r.StateFile, data)
+	}
+	if err != nil {
+		r.log(slog.LevelWarn, "Cannot write state file", "path", r.StateFile, "error", err)
+	}
+}
+
+// clearCheckpoint removes the StateFile of r, once there is nothing left to
+// resume.
+func (r *Runner) clearCheckpoint() {
+	if err := os.Remove(r.StateFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		r.log(slog.LevelWarn, "Cannot remove state file", "path", r.StateFile, "error", err)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file alongside path, and renames
+// it into place, so that readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err = errors.Join(err, f.Close()); err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+package wut
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestRunner_StateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	synctest.Test(t, func(t *testing.T) {
+		// attempts at 0s and 10s fail, before the Runner is stopped at 15s
+		ctx, cancel := context.WithTimeout(t.Context(), 15*time.Second)
+		defer cancel()
+		r := NewRunnerWithExecutor(ctx, mockExecutor{exitcode: 1})
+		r.RetryDelay = 10 * time.Second
+		r.StateFile = path
+		r.Run()
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected state file to be kept: %v", err)
+		}
+
+		// a restarted Runner continues from attempt 3, after the remainder of
+		// the retry delay
+		start := time.Now()
+		r = NewRunnerWithExecutor(t.Context(), mockExecutor{})
+		r.RetryDelay = 10 * time.Second
+		r.StateFile = path
+		var attempts []Attempt
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptStart {
+				attempts = append(attempts, e.Attempt)
+			}
+		})
+		if err := r.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(attempts) != 1 || attempts[0].Num != 3 {
+			t.Fatalf("got attempts %+v, want only attempt 3", attempts)
+		}
+		if got, want := attempts[0].Start.Sub(start), 5*time.Second; got != want {
+			t.Errorf("resumed after %v, want %v", got, want)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected state file to be removed on success, got %v", err)
+		}
+	})
+}
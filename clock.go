@@ -0,0 +1,58 @@
+package wut
+
+import "time"
+
+// Clock provides the current time and timers to the Runner.
+//
+// The Runner uses its Clock to schedule retry delays, along with its
+// DrainTimeout and IdleTimeout, permitting consumers to drive it with
+// simulated time when testing/synctest is not an option. Note that process timeouts and context deadlines are
+// always governed by the real clock.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	// AfterFunc calls f in its own goroutine once d has elapsed, mirroring
+	// [time.AfterFunc]. The C method of the returned Timer is not used.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer created by a Clock, mirroring the behavior of [time.Timer].
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// realClock is the default implementation of the Clock interface, using the
+// time package.
+type realClock struct{}
+
+// verify realClock implements the Clock interface
+var _ Clock = realClock{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+// realTimer adapts a *time.Timer to the Timer interface.
+type realTimer struct{ t *time.Timer }
+
+func (rt realTimer) C() <-chan time.Time        { return rt.t.C }
+func (rt realTimer) Stop() bool                 { return rt.t.Stop() }
+func (rt realTimer) Reset(d time.Duration) bool { return rt.t.Reset(d) }
+
+// stopTimer stops t, draining its channel if it had already fired, so that it
+// may be safely Reset by Clock implementations without the synchronous timer
+// channels of Go 1.23 and later.
+func stopTimer(t Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C():
+		default:
+		}
+	}
+}
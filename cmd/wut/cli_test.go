@@ -4,7 +4,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/rogpeppe/go-internal/testscript"
 )
@@ -15,15 +17,36 @@ func TestCLI(t *testing.T) {
 	}
 	testscript.Run(t, testscript.Params{
 		Dir: "testdata",
+		Setup: func(env *testscript.Env) error {
+			// a binary built with -race otherwise sleeps for a second on
+			// exit, upsetting the timing of scripts running wut repeatedly
+			env.Setenv("GORACE", "atexit_sleep_ms=0")
+			return nil
+		},
+		Cmds: map[string]func(ts *testscript.TestScript, neg bool, args []string){
+			"sleep": sleep,
+		},
 	})
 }
 
+// sleep pauses the script for the given duration, such as to let a command
+// run in the background reach a given state.
+func sleep(ts *testscript.TestScript, neg bool, args []string) {
+	if neg || len(args) != 1 {
+		ts.Fatalf("usage: sleep duration")
+	}
+	d, err := time.ParseDuration(args[0])
+	ts.Check(err)
+	time.Sleep(d)
+}
+
 func TestMain(m *testing.M) {
 	testscript.Main(m, map[string]func(){
 		"wut":           main,
 		"bintrue":       binTrue,
 		"binfalse":      binFalse,
 		"succeed-after": succeedAfterAttempts,
+		"output":        writeOutput,
 	})
 }
 
@@ -39,6 +62,8 @@ func succeedAfterAttempts() {
 	var (
 		filename = flag.String("file", "attempts.dat", "data file to track attempts")
 		fails    = flag.Int("fails", 5, "number of times to fail before succeeding")
+		verbose  = flag.Bool("verbose", false, "print the attempt number to stdout")
+		hang     = flag.Bool("hang", false, "hang until killed rather than failing")
 	)
 	flag.Parse()
 
@@ -48,7 +73,27 @@ func succeedAfterAttempts() {
 	}
 	val++
 	os.WriteFile(*filename, fmt.Append(nil, val), 0644)
+	if *verbose {
+		fmt.Println("attempt", val)
+	}
 	if val <= *fails {
+		if *hang {
+			select {}
+		}
 		os.Exit(val)
 	}
 }
+
+func writeOutput() {
+	var (
+		stdout = flag.String("stdout", "", `text to write to stdout, with \n for newlines and \e for escape`)
+		stderr = flag.String("stderr", "", `text to write to stderr, with \n for newlines and \e for escape`)
+		code   = flag.Int("exit", 0, "exit code")
+	)
+	flag.Parse()
+
+	unescape := strings.NewReplacer(`\n`, "\n", `\e`, "\x1b")
+	fmt.Fprint(os.Stdout, unescape.Replace(*stdout))
+	fmt.Fprint(os.Stderr, unescape.Replace(*stderr))
+	os.Exit(*code)
+}
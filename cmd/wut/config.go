@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// applyFlags sets the fields of cfg corresponding to command line flags. If all
+// is false, only flags explicitly set are applied, so that they override the
+// settings read from a -config file while leaving the others in place.
+func applyFlags(cfg *wut.Config, all bool) error {
+	set := func(name string) bool { return all || isFlagSet(name) }
+
+	if set("retry-delay") {
+		cfg.RetryDelay = wut.Duration(*retryDelay)
+	}
+	if set("backoff") {
+		cfg.Backoff = backoff
+	}
+	if set("backoff-factor") {
+		cfg.BackoffFactor = *backoffFactor
+	}
+	if set("max-delay") {
+		cfg.MaxDelay = wut.Duration(*maxDelay)
+	}
+	if set("jitter") {
+		cfg.Jitter, cfg.JitterFraction = wut.Duration(jitter.d), jitter.fraction
+	}
+	if set("delays") {
+		cfg.Delays = nil
+		for _, d := range delays {
+			cfg.Delays = append(cfg.Delays, wut.Duration(d))
+		}
+	}
+	if set("process-timeout") {
+		cfg.ProcessTimeout = wut.Duration(*processTimeout)
+	}
+	if set("idle-timeout") {
+		cfg.IdleTimeout = wut.Duration(*idleTimeout)
+	}
+	if set("hedge") {
+		cfg.HedgeDelay = wut.Duration(*hedge)
+	}
+	if set("interval") {
+		cfg.Interval = wut.Duration(*interval)
+	}
+	if set("at") {
+		cfg.StartAt = startAt.t
+	}
+	if set("splay") {
+		cfg.Splay = wut.Duration(*splay)
+	}
+	if set("drain") {
+		cfg.DrainTimeout = wut.Duration(*drain)
+	}
+	if set("max-runs") {
+		cfg.MaxRuns = *maxRuns
+	}
+	if set("continue") {
+		cfg.ContinueOnSuccess = *continueOnSuccess
+	}
+	if set("consecutive-successes") {
+		cfg.ConsecutiveSuccesses = *consecutive
+	}
+	if set("restart") {
+		switch *restart {
+		case "always":
+			cfg.ContinueOnSuccess = true
+		case "on-failure":
+		case "never":
+			cfg.MaxRuns = 1
+		default:
+			return fmt.Errorf("invalid value %q for flag -restart, must be one of always, on-failure, or never", *restart)
+		}
+	}
+	if set("min-uptime") {
+		cfg.MinUptime = wut.Duration(*minUptime)
+	}
+	if set("crash-loop") {
+		cfg.CrashLoopLimit = *crashLoop
+	}
+	if set("until-failure") {
+		cfg.UntilFailure = *untilFailure
+	}
+	if set("until-output") {
+		if _, err := regexp.Compile(*untilOutput); err != nil {
+			return fmt.Errorf("invalid value %q for flag -until-output: %v", *untilOutput, err)
+		}
+		cfg.UntilOutput = *untilOutput
+	}
+	if set("until-cmd") {
+		cfg.Verify = *untilCmd
+	}
+	if set("fail-on-output") {
+		if _, err := regexp.Compile(*failOnOutput); err != nil {
+			return fmt.Errorf("invalid value %q for flag -fail-on-output: %v", *failOnOutput, err)
+		}
+		cfg.FailOnOutput = *failOnOutput
+	}
+	if set("success-codes") {
+		cfg.SuccessCodes = successCodes
+	}
+	if set("abort-on-codes") {
+		cfg.AbortCodes = abortCodes
+	}
+	if set("retry-on-codes") {
+		cfg.RetryCodes = retryCodes
+	}
+	if set("retry-on-timeout-only") {
+		cfg.RetryTimeoutsOnly = *retryTimeoutOnly
+	}
+	if set("forever") && *forever {
+		if isFlagSet("max-runs") || isFlagSet("until-failure") {
+			return errors.New("flag -forever cannot be combined with -max-runs or -until-failure")
+		}
+		cfg.ContinueOnSuccess, cfg.MaxRuns, cfg.UntilFailure = true, 0, false
+		if cfg.Backoff == wut.BackoffFixed && len(cfg.Delays) == 0 && !isFlagSet("backoff") {
+			cfg.Backoff = wut.BackoffExponential
+		}
+		if cfg.MaxDelay == 0 && !isFlagSet("max-delay") {
+			cfg.MaxDelay = wut.Duration(time.Minute)
+		}
+	}
+	if set("state-file") {
+		cfg.StateFile = *stateFile
+	}
+	if set("lock") {
+		cfg.LockFile = *lockFile
+	}
+	if set("lock-wait") {
+		cfg.LockWait = *lockWait
+	}
+	if set("skip-if-succeeded") {
+		cfg.SuccessMarker = *skipIfFresh
+	}
+	if set("success-ttl") {
+		cfg.SuccessTTL = wut.Duration(*successTTL)
+	}
+
+	if set("process-group") {
+		cfg.ProcessGroup = *processGroup
+	}
+	if set("grace-period") {
+		cfg.GracePeriod = wut.Duration(*gracePeriod)
+	}
+	if set("wait-delay") {
+		cfg.WaitDelay = wut.Duration(*waitDelay)
+	}
+	if set("user") {
+		cfg.User = *runAsUser
+	}
+	if set("group") {
+		cfg.Group = *runAsGroup
+	}
+	if set("kill-on-exit") {
+		cfg.KillOnParentExit = *killOnExit
+	}
+	if set("orphans") {
+		if err := cfg.Orphans.UnmarshalText([]byte(*orphans)); err != nil {
+			return fmt.Errorf("invalid value %q for flag -orphans, must be one of ignore, report, or kill", *orphans)
+		}
+		// orphans are looked for in the process group of the command
+		if cfg.Orphans != wut.OrphansIgnore && !isFlagSet("process-group") {
+			cfg.ProcessGroup = true
+		}
+	}
+	if set("nice") {
+		cfg.Nice = *nice
+	}
+	if set("ionice") {
+		cfg.IOClass, cfg.IOLevel = ionice.class, ionice.level
+	}
+	if set("memory-max") {
+		cfg.MemoryMax = memoryMax.bytes
+	}
+	if set("cpus") {
+		cfg.CPUMax = *cpus
+	}
+	if set("cgroup-parent") {
+		cfg.CgroupParent = *cgroupParent
+	}
+	if set("max-rss") {
+		cfg.MaxRSS = maxRSS.bytes
+	}
+
+	if set("redact") {
+		for _, p := range redact {
+			if _, err := regexp.Compile(p); err != nil {
+				return fmt.Errorf("invalid value %q for flag -redact: %v", p, err)
+			}
+		}
+		cfg.Redact = redact
+	}
+	if set("redact-env") {
+		cfg.RedactEnv = redactEnv
+	}
+
+	if set("fail-tail") || set("chronic") {
+		cfg.OutputPolicy = wut.OutputPassthrough
+		if *failTail > 0 || *chronic {
+			cfg.OutputPolicy = wut.OutputOnFailure
+		}
+		if *chronic {
+			cfg.OutputPolicy = wut.OutputOnFinalFailure
+		}
+		cfg.OutputTailLines = *failTail
+	}
+	if set("log-output") {
+		cfg.LogStdout, cfg.LogStderr = nil, nil
+		if *logOutput {
+			info, warn := slog.LevelInfo, slog.LevelWarn
+			cfg.LogStdout, cfg.LogStderr = &info, &warn
+		}
+	}
+	if set("log-json") {
+		cfg.LogJSONOutput = *logJSON
+	}
+	if set("strip-ansi") {
+		cfg.OutputStripANSI = *stripANSI
+	}
+	return nil
+}
+
+// applySuperviseDefaults sets the defaults of the subcommand form "supervise"
+// in cfg, for each of the corresponding flags not given explicitly. They are
+// applied before any -config file is read, so that it overrides them too.
+func applySuperviseDefaults(cfg *wut.Config) {
+	if !isFlagSet("backoff") {
+		cfg.Backoff = wut.BackoffExponential
+	}
+	if !isFlagSet("max-delay") {
+		cfg.MaxDelay = wut.Duration(time.Minute)
+	}
+	if !isFlagSet("min-uptime") {
+		cfg.MinUptime = wut.Duration(10 * time.Second)
+	}
+	if !isFlagSet("crash-loop") {
+		cfg.CrashLoopLimit = 5
+	}
+	if !isFlagSet("grace-period") {
+		cfg.GracePeriod = wut.Duration(10 * time.Second)
+	}
+	if !isFlagSet("process-group") {
+		cfg.ProcessGroup = true
+	}
+}
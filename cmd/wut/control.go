@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mroth/wut"
+)
+
+// sendControl sends cmd to the control socket at path, and prints the status
+// in its reply to w as JSON.
+func sendControl(w io.Writer, path, cmd string) error {
+	if path == "" {
+		return errors.New("flag -control-send requires -control")
+	}
+	reply, err := wut.ControlClient{Path: path}.Send(cmd)
+	if err != nil {
+		return err
+	}
+	if reply.Status != nil {
+		data, err := json.Marshal(reply.Status)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", data)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// detachedEnv is set in the environment of the copy of wut started in the
+// background by -detach to the pid of the wut which started it, so that it
+// runs rather than detaching again.
+const detachedEnv = "WUT_DETACHED"
+
+// detached reports whether wut was started in the background by -detach, and
+// if so the pid of the wut which started it, removing detachedEnv from the
+// environment so it is not passed on to the command.
+func detached() (parent int, ok bool) {
+	v, ok := os.LookupEnv(detachedEnv)
+	os.Unsetenv(detachedEnv)
+	parent, _ = strconv.Atoi(v)
+	return parent, ok
+}
+
+// detach starts a copy of wut in the background with the same arguments, in a
+// new session detached from the terminal, and returns its pid. Its standard
+// input is /dev/null, and its standard output and error are appended to
+// logFile, or also /dev/null if empty.
+func detach(logFile string) (int, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	null, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer null.Close()
+	out := null
+	if logFile != "" {
+		if out, err = os.OpenFile(logFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644); err != nil {
+			return 0, err
+		}
+		defer out.Close()
+	}
+
+	cmd := &exec.Cmd{
+		Path:        exe,
+		Args:        os.Args,
+		Env:         append(os.Environ(), detachedEnv+"="+strconv.Itoa(os.Getpid())),
+		Stdin:       null,
+		Stdout:      out,
+		Stderr:      out,
+		SysProcAttr: detachAttr(),
+	}
+	if cmd.SysProcAttr == nil {
+		return 0, errors.New("flag -detach is not supported on this platform")
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+	return pid, cmd.Process.Release()
+}
+
+// claimPidfile writes pid to the pidfile at path, unless it records another
+// process still running other than prev, such as the wut which detached the
+// process pid, and returns a function removing the pidfile if it still
+// records pid. A pidfile recording a process which is no longer running is
+// stale, and replaced.
+func claimPidfile(path string, pid, prev int) (release func(), err error) {
+	if other, ok := runningPid(path); ok && other != pid && other != prev {
+		return nil, errAlreadyRunning(path, other)
+	}
+	// written via a temporary file, so that the pidfile is never seen partially written
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintf(tmp, "%d\n", pid)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return func() {
+		if recorded, ok := readPidfile(path); ok && recorded == pid {
+			os.Remove(path)
+		}
+	}, nil
+}
+
+// runningPid returns the pid recorded by the pidfile at path, if it is of a
+// process still running.
+func runningPid(path string) (int, bool) {
+	pid, ok := readPidfile(path)
+	return pid, ok && processAlive(pid)
+}
+
+// errAlreadyRunning returns the error reporting that the pidfile at path
+// records the running process pid.
+func errAlreadyRunning(path string, pid int) error {
+	return fmt.Errorf("already running as pid %d, according to %s", pid, path)
+}
+
+// readPidfile returns the pid recorded by the pidfile at path, if any.
+func readPidfile(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid, err == nil && pid > 0
+}
+
+// atExit, if set, is called by exit before wut exits.
+var atExit func()
+
+// exit calls atExit, if set, then exits with the given code.
+func exit(code int) {
+	if atExit != nil {
+		atExit()
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// detachAttr returns nil, as -detach is not supported on this platform.
+func detachAttr() *syscall.SysProcAttr { return nil }
+
+// processAlive reports whether a process with the given pid is running.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
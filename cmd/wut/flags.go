@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stringList is a flag.Value collecting the values of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// jitterValue is a flag.Value parsing a jitter given either as a duration,
+// e.g. "5s", or as a percentage of each delay, e.g. "10%".
+type jitterValue struct {
+	d        time.Duration
+	fraction float64
+	raw      string
+}
+
+func (v *jitterValue) String() string { return v.raw }
+
+func (v *jitterValue) Set(s string) error {
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		f, err := strconv.ParseFloat(pct, 64)
+		if err != nil || f < 0 {
+			return fmt.Errorf("invalid percentage %q", s)
+		}
+		v.d, v.fraction, v.raw = 0, f/100, s
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return fmt.Errorf("invalid jitter %q, must be a duration or a percentage", s)
+	}
+	v.d, v.fraction, v.raw = d, 0, s
+	return nil
+}
+
+// timeValue is a flag.Value parsing a time, either as a time of day such as
+// "02:30", meaning its next occurrence, or as a date and time such as
+// "2024-07-01T02:30:00", in local time unless a zone offset is given.
+type timeValue struct {
+	t   time.Time
+	raw string
+}
+
+func (v *timeValue) String() string { return v.raw }
+
+func (v *timeValue) Set(s string) error {
+	t, err := parseTime(s, time.Now())
+	if err != nil {
+		return err
+	}
+	v.t, v.raw = t, s
+	return nil
+}
+
+// parseTime parses s as described by timeValue, relative to now.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if clock, err := time.Parse(layout, s); err == nil {
+			t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+			if !t.After(now) {
+				t = time.Date(now.Year(), now.Month(), now.Day()+1, clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, must be a time of day such as 02:30 or a date and time such as 2024-07-01T02:30:00", s)
+}
+
+// intList is a flag.Value parsing a comma separated list of integers, such as
+// exit codes.
+type intList []int
+
+func (l *intList) String() string {
+	s := make([]string, len(*l))
+	for i, n := range *l {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+func (l *intList) Set(s string) error {
+	var ns []int
+	for f := range strings.SplitSeq(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", f)
+		}
+		ns = append(ns, n)
+	}
+	*l = ns
+	return nil
+}
+
+// durationList is a flag.Value parsing a comma separated list of durations.
+type durationList []time.Duration
+
+func (l *durationList) String() string {
+	s := make([]string, len(*l))
+	for i, d := range *l {
+		s[i] = d.String()
+	}
+	return strings.Join(s, ",")
+}
+
+func (l *durationList) Set(s string) error {
+	var ds []time.Duration
+	for f := range strings.SplitSeq(s, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(f))
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid duration %q", f)
+		}
+		ds = append(ds, d)
+	}
+	*l = ds
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestJitterValue(t *testing.T) {
+	tests := map[string]jitterValue{
+		"5s":   {d: 5 * time.Second},
+		"10%":  {fraction: 0.1},
+		"2.5%": {fraction: 0.025},
+	}
+	for in, want := range tests {
+		var v jitterValue
+		if err := v.Set(in); err != nil {
+			t.Errorf("%q: unexpected error: %v", in, err)
+		} else if v.d != want.d || v.fraction != want.fraction {
+			t.Errorf("%q: got %v, %v, want %v, %v", in, v.d, v.fraction, want.d, want.fraction)
+		}
+	}
+
+	for _, bad := range []string{"", "%", "-5s", "-1%", "soon"} {
+		var v jitterValue
+		if err := v.Set(bad); err == nil {
+			t.Errorf("%q: expected error, got nil", bad)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, time.June, 30, 12, 0, 0, 0, time.Local)
+	tests := map[string]time.Time{
+		"02:30":                     time.Date(2024, time.July, 1, 2, 30, 0, 0, time.Local),
+		"14:15:30":                  time.Date(2024, time.June, 30, 14, 15, 30, 0, time.Local),
+		"12:00":                     time.Date(2024, time.July, 1, 12, 0, 0, 0, time.Local),
+		"2024-07-01T02:30:00":       time.Date(2024, time.July, 1, 2, 30, 0, 0, time.Local),
+		"2024-07-01 02:30":          time.Date(2024, time.July, 1, 2, 30, 0, 0, time.Local),
+		"2024-07-01T02:30:00Z":      time.Date(2024, time.July, 1, 2, 30, 0, 0, time.UTC),
+		"2024-07-01T02:30:00+02:00": time.Date(2024, time.July, 1, 0, 30, 0, 0, time.UTC),
+	}
+	for in, want := range tests {
+		if got, err := parseTime(in, now); err != nil {
+			t.Errorf("%q: unexpected error: %v", in, err)
+		} else if !got.Equal(want) {
+			t.Errorf("%q: got %v, want %v", in, got, want)
+		}
+	}
+
+	for _, bad := range []string{"", "2:30pm", "25:00", "2024-07-01", "tomorrow"} {
+		if _, err := parseTime(bad, now); err == nil {
+			t.Errorf("%q: expected error, got nil", bad)
+		}
+	}
+}
+
+func TestDurationList(t *testing.T) {
+	var l durationList
+	if err := l.Set("1s, 5s,30s,5m"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := durationList{time.Second, 5 * time.Second, 30 * time.Second, 5 * time.Minute}
+	if !slices.Equal(l, want) {
+		t.Errorf("got %v, want %v", l, want)
+	}
+
+	for _, bad := range []string{"", "1s,", "1s,-5s", "soon"} {
+		var l durationList
+		if err := l.Set(bad); err == nil {
+			t.Errorf("%q: expected error, got nil", bad)
+		}
+	}
+}
+
+func TestIntList(t *testing.T) {
+	var l intList
+	if err := l.Set("0, 2,126"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (intList{0, 2, 126}); !slices.Equal(l, want) {
+		t.Errorf("got %v, want %v", l, want)
+	}
+
+	for _, bad := range []string{"", "1,", "one"} {
+		var l intList
+		if err := l.Set(bad); err == nil {
+			t.Errorf("%q: expected error, got nil", bad)
+		}
+	}
+}
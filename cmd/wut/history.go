@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// showHistory prints the n most recent runs recorded in the history file at
+// path to w, one per line.
+func showHistory(w io.Writer, path string, n int) error {
+	if path == "" {
+		return errors.New("flag -history-show requires -history")
+	}
+	recs, err := wut.OpenHistory(path).Recent(n)
+	if err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		result := "ok"
+		if !rec.Succeeded() {
+			result = fmt.Sprintf("failed (exit code %d): %s", rec.ExitCode, rec.Error)
+		}
+		fmt.Fprintf(w, "%s  %8.3fs  %3d attempts  %s\n",
+			rec.Start.Local().Format(time.DateTime), rec.Duration, rec.Attempts, result)
+	}
+	return nil
+}
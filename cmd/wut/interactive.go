@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+var errStoppedByUser = errors.New("stopped by user")
+
+// isTerminal reports whether f appears to be attached to a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// watchKeys reads line-buffered input from r until EOF, calling kick when an
+// empty line (a bare Enter) or "r" is entered, and stop when "q" is entered.
+//
+// The terminal is left in its default cooked mode so that the child command's
+// output is not disturbed, which means each key must be followed by Enter.
+func watchKeys(r io.Reader, kick func(), stop func(cause error)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "", "r":
+			kick()
+		case "q":
+			stop(errStoppedByUser)
+			return
+		}
+	}
+}
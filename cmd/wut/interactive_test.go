@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWatchKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantKicks int
+		wantStop  bool
+	}{
+		{"enter kicks", "\n\n", 2, false},
+		{"r kicks", "r\nR\n", 2, false},
+		{"q stops", "\nq\n\n", 1, true},
+		{"other input ignored", "hello\n", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				kicks   int
+				stopErr error
+			)
+			watchKeys(strings.NewReader(tt.input),
+				func() { kicks++ },
+				func(cause error) { stopErr = cause },
+			)
+
+			if kicks != tt.wantKicks {
+				t.Errorf("kicks: got %d, want %d", kicks, tt.wantKicks)
+			}
+			if gotStop := errors.Is(stopErr, errStoppedByUser); gotStop != tt.wantStop {
+				t.Errorf("stopped: got %v, want %v", gotStop, tt.wantStop)
+			}
+		})
+	}
+}
@@ -6,18 +6,82 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/mroth/wut"
+	"github.com/mroth/wut/systemdwut"
 )
 
 var (
 	timeout           = flag.Duration("timeout", 0, "maximum time to wait for a successful execution")
+	processTimeout    = flag.Duration("process-timeout", 0, "maximum time for each run of the command, after which it is killed and retried (default no limit)")
+	idleTimeout       = flag.Duration("idle-timeout", 0, "kill and retry the command if it writes no output for this long (default no limit)")
+	hedge             = flag.Duration("hedge", 0, "if a run has not completed after this long, start a duplicate concurrently, with the first to succeed determining the outcome and the other killed; cannot be combined with -idle-timeout or -until-output (default no hedging)")
 	retryDelay        = flag.Duration("retry-delay", time.Second, "delay between retries")
+	interval          = flag.Duration("interval", 0, "start runs at a fixed rate, every `duration` from the start of the first, rather than -retry-delay after each ends, e.g. with -continue; starts missed while a run takes longer are skipped, so runs never overlap")
+	watchDebounce     = flag.Duration("watch-debounce", 200*time.Millisecond, "with -watch, wait for changes to settle for this long before running the command")
+	splay             = flag.Duration("splay", 0, "delay the first run by a random duration of up to this long, after any -at or -cron time, so that the same command scheduled across many hosts does not run in lockstep")
+	cronSpec          = flag.String("cron", "", "run the command on a cron `schedule` such as '*/5 * * * *', in local time, with the other options applying afresh to each scheduled run; runs never overlap, and failures do not stop the schedule")
+	maxDelay          = flag.Duration("max-delay", 0, "cap the delay between retries chosen by the -backoff strategy (default no cap)")
+	backoffFactor     = flag.Float64("backoff-factor", 0, "factor by which the -backoff strategy grows the delay (default 2 for exponential, 3 for decorrelated)")
 	maxRuns           = flag.Uint("max-runs", 0, "maximum number of times to run the command (default unlimited)")
 	continueOnSuccess = flag.Bool("continue", false, "continue running even after successful execution")
+	forever           = flag.Bool("forever", false, "keep running the command indefinitely regardless of outcome, -retry-delay after each run, backing off exponentially up to -max-delay (default 1m) while it fails, unless -backoff or -delays are given")
+	consecutive       = flag.Uint("consecutive-successes", 0, "require `N` successful runs in a row before exiting successfully, starting over after a failure")
+	restart           = flag.String("restart", "on-failure", "`policy` for running the command again once it exits: always, on-failure, or never, e.g. with wut supervise")
+	minUptime         = flag.Duration("min-uptime", 0, "treat a run lasting at least this long before failing as healthy, restarting it without backing off further or counting it towards -crash-loop")
+	crashLoop         = flag.Uint("crash-loop", 0, "stop once `N` runs in a row have failed, within -min-uptime of starting if set, rather than restarting the command indefinitely")
+	untilFailure      = flag.Bool("until-failure", false, "run the command repeatedly until it fails, such as to reproduce a flaky test, then exit with an error and print the output of the failed run")
+	untilOutput       = flag.String("until-output", "", "succeed only once a line of the command's output matches the regular `pattern`, regardless of its exit status")
+	untilCmd          = flag.String("until-cmd", "", "succeed only once the shell `script` also succeeds, run after each successful attempt to verify it")
+	failOnOutput      = flag.String("fail-on-output", "", "stop without retrying if a line of the command's output matches the regular `pattern`, e.g. 'permission denied'")
+	retryTimeoutOnly  = flag.Bool("retry-on-timeout-only", false, "retry only attempts killed by -process-timeout (and any exiting with -retry-on-codes), stopping on other failures")
+	drain             = flag.Duration("drain", 0, "when wut is interrupted or terminated, or its -timeout expires, let a running attempt continue for up to this long to finish before killing it")
+	gracePeriod       = flag.Duration("grace-period", 0, "on timeout, send SIGTERM and wait up to this long for the command to exit before killing it")
+	waitDelay         = flag.Duration("wait-delay", wut.DefaultWaitDelay, "once the command exits or is killed, wait up to this long for it and any descendants holding its output to finish, before forcibly stopping them (negative to wait indefinitely)")
+	killOnExit        = flag.Bool("kill-on-exit", false, "kill the command if wut itself is killed (Linux and FreeBSD only)")
+	runAsUser         = flag.String("user", "", "run the command as this user, by name or uid (Unix only)")
+	runAsGroup        = flag.String("group", "", "run the command as this group, by name or gid (default the user's groups, Unix only)")
+	nice              = flag.Int("nice", 0, "adjust the scheduling priority of the command, from -20 (highest) to 19 (lowest), Unix only")
+	cpus              = flag.Float64("cpus", 0, "limit the CPU usage of each run of the command to this many CPUs, e.g. 0.5 (Linux cgroup v2 only)")
+	cgroupParent      = flag.String("cgroup-parent", "", "create the cgroups used by -memory-max and -cpus under this `directory` (default /sys/fs/cgroup)")
+	orphans           = flag.String("orphans", "ignore", "once the command exits, `policy` for processes it left running in its process group: ignore, report, or kill, implying -process-group (Unix only)")
+	processGroup      = flag.Bool("process-group", false, "run the command in its own process group, killing all its descendants on timeout")
+	benchmark         = flag.Uint("benchmark", 0, "benchmark the command over `N` measured runs regardless of outcome, and print a duration summary")
+	warmup            = flag.Uint("warmup", 0, "number of warmup runs excluded from the -benchmark summary")
+	flakes            = flag.Uint("flakes", 0, "run the command `N` times regardless of outcome, and print a summary of its passes and failures, grouped by failure signature")
+	stress            = flag.Int("stress", 0, "run `N` concurrent copies of the command repeatedly regardless of outcome for -stress-duration, and print a summary")
+	stressDuration    = flag.Duration("stress-duration", 10*time.Second, "duration of a -stress run")
+	failTail          = flag.Int("fail-tail", 0, "print the last `N` lines of the command's output, only for failed attempts")
+	chronic           = flag.Bool("chronic", false, "print the command's output only for the final attempt, if wut exits without success (combine with -fail-tail to limit it)")
+	logOutput         = flag.Bool("log-output", false, "log each line of the command's output, stdout at INFO level and stderr at WARN level")
+	logJSON           = flag.Bool("log-json", false, "with -log-output, log lines of output which are JSON objects as structured records, merging their fields")
+	teeFile           = flag.String("tee", "", "append the command's output to `file`, in addition to any other output options")
+	teeBackups        = flag.Int("tee-backups", 3, "number of rotated -tee files to keep")
+	stripANSI         = flag.Bool("strip-ansi", false, "remove ANSI color and control sequences from the output written by -tee, -events, and -log-output")
+	stateFile         = flag.String("state-file", "", "persist the attempt count to `file` after each attempt, resuming from it if wut is restarted before finishing")
+	lockFile          = flag.String("lock", "", "hold an exclusive lock on `file` while running, exiting if another instance holds it")
+	lockWait          = flag.Bool("lock-wait", false, "wait for the -lock held by another instance to be released, rather than exiting")
+	skipIfFresh       = flag.String("skip-if-succeeded", "", "record each success in the marker `file`, and exit successfully without running the command while it is fresh")
+	successTTL        = flag.Duration("success-ttl", 0, "duration for which a -skip-if-succeeded marker is fresh (default forever)")
+	watchdogFailures  = flag.Int("watchdog-failures", 3, "when run by systemd with WatchdogSec=, stop pinging the watchdog after `N` consecutive failed attempts")
+	httpAddr          = flag.String("http", "", "serve /healthz and /status endpoints reporting the runner's progress over HTTP on `address`, e.g. :8080")
+	controlSocket     = flag.String("control", "", "accept status, pause, resume, kick, and stop commands on a Unix socket at `path`")
+	controlSend       = flag.String("control-send", "", "send `command` to the -control socket of a running wut, print its reply, and exit, without running a command")
+	historyFile       = flag.String("history", "", "append a record of each run, with its timing and outcome, to `file`")
+	historyShow       = flag.Int("history-show", 0, "print the last `N` runs recorded in the -history file and exit, without running a command")
+	eventsFile        = flag.String("events", "", "append a JSON object describing each attempt as a line to `file`, or - for stdout")
+	configFile        = flag.String("config", "", "read settings from the JSON `file`, overridden by any flags given, and run its command if none is given")
+	waitNonEmpty      = flag.Bool("wait-nonempty", false, "with -wait-for-file, also wait for the file to be non-empty")
+	detachMode        = flag.Bool("detach", false, "run in the background, detached from the terminal, with the options given, exiting once started (Unix only)")
+	detachLog         = flag.String("detach-log", "", "with -detach, append the log and output of the background wut to `file` (default discarded)")
+	pidFile           = flag.String("pidfile", "", "write the pid of wut to `file` while it runs, exiting if it records another instance still running")
+	interactive       = flag.Bool("interactive", false, "when attached to a terminal, press Enter to retry immediately or q+Enter to stop")
 )
 
 const (
@@ -25,21 +89,150 @@ const (
 	usageShort = `Usage: wut [OPTIONS] COMMAND [ARGS]...`
 )
 
+var (
+	backoff      wut.Backoff
+	jitter       jitterValue
+	delays       durationList
+	successCodes intList
+	abortCodes   intList
+	retryCodes   intList
+	ionice       ioniceValue
+	ulimit       ulimitValue
+	memoryMax    byteSizeValue
+	maxRSS       byteSizeValue
+	teeMaxSize   byteSizeValue
+	redact       stringList
+	redactEnv    stringList
+	waitFiles    stringList
+	waitPorts    stringList
+	waitURLs     stringList
+	expectStatus intList
+	watchPaths   stringList
+	startAt      timeValue
+)
+
+// shortFlags maps the short aliases of frequently typed flags to their names.
+var shortFlags = map[string]string{
+	"t": "timeout",
+	"d": "retry-delay",
+	"n": "max-runs",
+	"c": "continue",
+}
+
+func init() {
+	for short, name := range shortFlags {
+		flag.Var(flag.Lookup(name).Value, short, "shorthand for -"+name)
+	}
+	flag.TextVar(&backoff, "backoff", wut.BackoffFixed, "`strategy` for growing the delay between retries over consecutive failures: fixed, exponential, fibonacci, or decorrelated")
+	flag.Var(&delays, "delays", "retry after each of these comma separated `durations` in turn, e.g. 1s,5s,30s,5m, repeating the last, instead of -retry-delay and -backoff")
+	flag.Var(&jitter, "jitter", "add a random delay of up to this `duration` or percentage of each retry delay, e.g. 5s or 10%, to avoid retrying in lockstep with other hosts")
+	flag.Var(&successCodes, "success-codes", "treat these comma separated exit `codes` as success, in addition to 0, e.g. 1 for diff")
+	flag.Var(&abortCodes, "abort-on-codes", "stop without retrying if the command exits with any of these comma separated `codes`, e.g. 126,127, exiting with the same code")
+	flag.Var(&retryCodes, "retry-on-codes", "retry only if the command exits with one of these comma separated `codes`, e.g. 75, stopping as for -abort-on-codes otherwise")
+	flag.Var(&ionice, "ionice", "set the I/O scheduling `class[:level]` of the command, with class one of realtime, best-effort, or idle, Linux only")
+	flag.Var(&ulimit, "ulimit", "set resource limits on the command as comma separated `name=soft[:hard]` pairs, e.g. nofile=1024,cpu=60, Linux only")
+	flag.Var(&teeMaxSize, "tee-max-size", "rotate the -tee file once it would exceed this many `bytes`, e.g. 10M (default no rotation)")
+	flag.Var(&redact, "redact", "redact text matching this regular `pattern` from logs and captured output (repeatable)")
+	flag.Var(&redactEnv, "redact-env", "redact the value of the environment variable `name` from logs and captured output (repeatable)")
+	flag.Var(&waitFiles, "wait-for-file", "wait until a file exists at `path` before running the command, or just wait if no command is given (repeatable)")
+	flag.Var(&waitPorts, "port", "wait until a TCP connection can be made to `host:port` before running the command, or just wait if no command is given (repeatable)")
+	flag.Var(&waitURLs, "wait-for-http", "wait until a GET request to `URL` succeeds before running the command, or just wait if no command is given (repeatable)")
+	flag.Var(&startAt, "at", "delay the first run until `time`, either a time of day such as 02:30 for its next occurrence, or a date and time such as 2024-07-01T02:30:00, in local time unless an offset is given")
+	flag.Var(&watchPaths, "watch", "run the command, then run it again each time a file at or within `path` changes, until interrupted (repeatable)")
+	flag.Var(&expectStatus, "expect-status", "with -wait-for-http, the comma separated HTTP status `codes` indicating success (default any 2xx)")
+	flag.Var(&memoryMax, "memory-max", "limit the memory usage of each run of the command to this many `bytes`, e.g. 512M, reporting if it is killed for exceeding it (Linux cgroup v2 only)")
+	flag.Var(&maxRSS, "max-rss", "kill the command if the resident memory of it and its descendants exceeds this many `bytes`, e.g. 512M, as sampled periodically (Unix only)")
+}
+
 func main() {
 	flag.Usage = func() {
 		fmt.Fprintln(os.Stderr, banner)
 		fmt.Fprintln(os.Stderr, usageShort)
+		fmt.Fprintln(os.Stderr, "       wut [OPTIONS] WAIT-OPTIONS... [COMMAND [ARGS]...]")
+		fmt.Fprintln(os.Stderr, "       wut every DURATION [OPTIONS] [--] COMMAND [ARGS]...")
+		fmt.Fprintln(os.Stderr, "       wut supervise [OPTIONS] [--] COMMAND [ARGS]...")
+		fmt.Fprintln(os.Stderr, "\nOptions may also be given GNU style, as --name or --name=value, and -t, -d,")
+		fmt.Fprintln(os.Stderr, "-n, and -c are short for -timeout, -retry-delay, -max-runs, and -continue.")
+		fmt.Fprintln(os.Stderr, "\nThe WAIT-OPTIONS -wait-for-file, -port, and -wait-for-http each wait for a")
+		fmt.Fprintln(os.Stderr, "condition before the command is run, which may then be omitted.")
+		fmt.Fprintln(os.Stderr, "\nwut every DURATION runs the command periodically, regardless of its outcome,")
+		fmt.Fprintln(os.Stderr, "as for -forever -interval=DURATION.")
+		fmt.Fprintln(os.Stderr, "\nwut supervise keeps a long running command such as a daemon running, restarting")
+		fmt.Fprintln(os.Stderr, "it according to -restart and passing its output through. It defaults to")
+		fmt.Fprintln(os.Stderr, "-backoff=exponential -max-delay=1m -min-uptime=10s -crash-loop=5")
+		fmt.Fprintln(os.Stderr, "-grace-period=10s -process-group, and exits successfully once stopped by SIGINT")
+		fmt.Fprintln(os.Stderr, "or SIGTERM, after the command exits.")
 		fmt.Fprintln(os.Stderr, "\nOptions:")
 		flag.PrintDefaults()
 	}
-	flag.Parse()
-	if flag.NArg() < 1 {
+	if err := parseArgs(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(125)
+	}
+	if *controlSend != "" {
+		if err := sendControl(os.Stdout, *controlSocket, *controlSend); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *historyShow > 0 {
+		if err := showHistory(os.Stdout, *historyFile, *historyShow); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(125)
+		}
+		return
+	}
+
+	parentPid, isDetached := detached()
+
+	var cfg wut.Config
+	if err := applyFlags(&cfg, true); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(125)
+	}
+	if supervising {
+		applySuperviseDefaults(&cfg)
+	}
+	if *configFile != "" {
+		if err := cfg.ReadFile(*configFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(125)
+		}
+		if err := applyFlags(&cfg, false); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(125)
+		}
+	}
+	if flag.NArg() > 0 {
+		cfg.Command, cfg.Args, cfg.Shell = flag.Arg(0), flag.Args()[1:], ""
+	}
+	if cfg.UntilFailure && cfg.OutputPolicy == wut.OutputPassthrough {
+		cfg.OutputPolicy = wut.OutputOnFinalFailure
+	}
+	targets := waitTargets()
+	if cfg.Command == "" && cfg.Shell == "" && len(targets) == 0 {
 		flag.Usage()
 		os.Exit(125)
 	}
+	if len(watchPaths) > 0 && *cronSpec != "" {
+		fmt.Fprintln(os.Stderr, "flag -watch cannot be combined with -cron")
+		os.Exit(125)
+	}
+	var sched *wut.Cron
+	if *cronSpec != "" {
+		var err error
+		if sched, err = wut.ParseCron(*cronSpec); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(125)
+		}
+	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
+	signalled := ctx
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
 
 	if *timeout > 0 {
 		tctx, cf := context.WithTimeoutCause(ctx, *timeout, errors.New("timeout exceeded"))
@@ -47,17 +240,251 @@ func main() {
 		defer cf()
 	}
 
-	runner := wut.NewRunner(ctx, flag.Arg(0), flag.Args()[1:]...)
-	runner.ContinueOnSuccess = *continueOnSuccess
-	runner.MaxRuns = *maxRuns
-	runner.RetryDelay = *retryDelay
-
 	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	if *detachMode && !isDetached {
+		// claim the pidfile before starting anything, handing it over to the
+		// background wut once started, which accepts it recording our pid
+		release := func() {}
+		if *pidFile != "" {
+			var err error
+			if release, err = claimPidfile(*pidFile, os.Getpid(), 0); err != nil {
+				logger.Error("Cannot write pidfile", "error", err)
+				os.Exit(125)
+			}
+		}
+		pid, err := detach(*detachLog)
+		if err != nil {
+			release()
+			logger.Error("Cannot detach", "error", err)
+			os.Exit(125)
+		}
+		if recorded, ok := readPidfile(*pidFile); ok && recorded == os.Getpid() {
+			// should this fail, the background wut still records itself
+			if _, err := claimPidfile(*pidFile, pid, os.Getpid()); err != nil {
+				logger.Warn("Cannot write pidfile", "error", err)
+			}
+		}
+		logger.Info("Detached", "pid", pid)
+		return
+	}
+	if *pidFile != "" {
+		release, err := claimPidfile(*pidFile, os.Getpid(), parentPid)
+		if err != nil {
+			logger.Error("Cannot write pidfile", "error", err)
+			os.Exit(125)
+		}
+		atExit = release
+		defer release()
+	}
+	if len(targets) > 0 {
+		if err := waitFor(ctx, logger, cfg, targets); err != nil {
+			logger.Error("Runner encountered an error", "error", err)
+			exit(1)
+		}
+		if cfg.Command == "" && cfg.Shell == "" {
+			return
+		}
+	}
+
+	runner, err := wut.NewRunnerFromConfig(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exit(125)
+	}
+	runner.CommandOptions.Resources.Rlimits = ulimit.rlimits
+	if runner.OutputPolicy != wut.OutputPassthrough || supervising {
+		runner.CommandOptions.Stdout = os.Stdout
+		runner.CommandOptions.Stderr = os.Stderr
+	}
 	runner.SetLogger(logger)
+	if *teeFile != "" {
+		rf, err := wut.OpenRotatingFile(*teeFile, teeMaxSize.bytes, *teeBackups)
+		if err != nil {
+			logger.Error("Cannot open tee file", "error", err)
+			exit(125)
+		}
+		defer rf.Close()
+		runner.OutputTee = rf
+	}
+
+	if *eventsFile != "" {
+		w := os.Stdout
+		if *eventsFile != "-" {
+			f, err := os.OpenFile(*eventsFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+			if err != nil {
+				logger.Error("Cannot open events file", "error", err)
+				exit(125)
+			}
+			defer f.Close()
+			w = f
+		}
+		runner.CaptureLimit = 64 << 10
+		runner.Observe(wut.NewJSONLSink(w).Record)
+	}
+
+	// when run by systemd as a Type=notify service, report progress to it
+	if _, err := systemdwut.Notify(runner); err != nil {
+		logger.Warn("Cannot notify systemd", "error", err)
+	}
+	if _, err := systemdwut.Watchdog(ctx, runner, *watchdogFailures); err != nil {
+		logger.Warn("Cannot ping systemd watchdog", "error", err)
+	}
+
+	if *controlSocket != "" {
+		ln, err := wut.ListenControl(*controlSocket)
+		if err != nil {
+			logger.Error("Cannot listen on control socket", "error", err)
+			exit(125)
+		}
+		defer ln.Close()
+		cs := &wut.ControlServer{
+			Runner: runner,
+			Status: wut.TrackStatus(runner),
+			Stop:   func() { cancel(errors.New("stopped via control socket")) },
+		}
+		go cs.Serve(ln)
+	}
+
+	if *httpAddr != "" {
+		ln, err := net.Listen("tcp", *httpAddr)
+		if err != nil {
+			logger.Error("Cannot listen for HTTP", "error", err)
+			exit(125)
+		}
+		go http.Serve(ln, wut.TrackStatus(runner).Handler())
+	}
+
+	if *historyFile != "" {
+		history := wut.OpenHistory(*historyFile)
+		runner.Observe(func(e wut.Event) {
+			history.Record(e)
+			if err := history.Err(); err != nil && e.Kind == wut.EventRunEnd {
+				logger.Error("Cannot record history", "error", err)
+			}
+		})
+	}
+
+	if *interactive {
+		if isTerminal(os.Stdin) {
+			go watchKeys(os.Stdin, runner.Kick, cancel)
+		} else {
+			logger.Warn("Ignoring -interactive, stdin is not a terminal")
+		}
+	}
+
+	if *benchmark > 0 {
+		if !isFlagSet("retry-delay") {
+			runner.RetryDelay = 0
+		}
+		result, err := runner.Benchmark(*warmup, *benchmark)
+		fmt.Print(result)
+		if err != nil {
+			logger.Error("Runner encountered an error", "error", err)
+			exit(1)
+		}
+		if result.Failures > 0 {
+			exit(1)
+		}
+		return
+	}
 
-	err := runner.Run()
+	if *flakes > 0 {
+		if !isFlagSet("retry-delay") {
+			runner.RetryDelay = 0
+		}
+		report, err := runner.Flakes(*flakes)
+		fmt.Print(report)
+		if err != nil {
+			logger.Error("Runner encountered an error", "error", err)
+			exit(1)
+		}
+		if report.Failures > 0 {
+			exit(1)
+		}
+		return
+	}
+
+	if *stress > 0 {
+		if !isFlagSet("retry-delay") {
+			runner.RetryDelay = 0
+		}
+		report, err := runner.Stress(*stress, *stressDuration)
+		fmt.Print(report)
+		if err != nil {
+			logger.Error("Runner encountered an error", "error", err)
+			exit(1)
+		}
+		if report.Results.Failures > 0 {
+			exit(1)
+		}
+		return
+	}
+
+	if len(watchPaths) > 0 {
+		if err := runner.Watch(watchPaths, *watchDebounce); err != nil {
+			logger.Error("Runner encountered an error", "error", err)
+			exit(1)
+		}
+		return
+	}
+
+	if sched != nil {
+		if err := runner.RunSchedule(sched); err != nil {
+			logger.Error("Runner encountered an error", "error", err)
+			exit(1)
+		}
+		return
+	}
+
+	err = runner.Run()
+	if supervising && signalled.Err() != nil {
+		return // stopped cleanly, once the command exited
+	}
 	if err != nil {
 		logger.Error("Runner encountered an error", "error", err)
-		os.Exit(1)
+		// exit with the code of the command if it failed permanently, so
+		// that it is distinguishable from running out of retries
+		var coder interface{ ExitCode() int }
+		if errors.Is(err, wut.ErrPermanentFailure) && errors.As(err, &coder) && coder.ExitCode() > 0 {
+			exit(coder.ExitCode())
+		}
+		exit(1)
 	}
 }
+
+// supervising is set by parseArgs for the subcommand form "supervise".
+var supervising bool
+
+// parseArgs parses the command line flags from args, first accepting the
+// subcommand forms "every DURATION", equivalent to -forever -interval=DURATION,
+// and "supervise", setting supervising.
+func parseArgs(args []string) error {
+	switch {
+	case len(args) > 0 && args[0] == "every":
+		if len(args) < 2 {
+			return errors.New("usage: wut every DURATION [OPTIONS] [--] COMMAND [ARGS]...")
+		}
+		if d, err := time.ParseDuration(args[1]); err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration %q for wut every", args[1])
+		}
+		flag.Set("interval", args[1])
+		flag.Set("forever", "true")
+		args = args[2:]
+	case len(args) > 0 && args[0] == "supervise":
+		supervising = true
+		args = args[1:]
+	}
+	return flag.CommandLine.Parse(args)
+}
+
+// isFlagSet reports whether the named flag was explicitly set on the command
+// line, either by its name or its short alias.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name || shortFlags[f.Name] == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mroth/wut"
+)
+
+// ioniceValue is a flag.Value parsing an I/O scheduling class and optional
+// level in the form CLASS[:LEVEL], e.g. "idle" or "best-effort:7".
+type ioniceValue struct {
+	class wut.IOClass
+	level int
+}
+
+var ioClasses = map[string]wut.IOClass{
+	"realtime":    wut.IOClassRealtime,
+	"best-effort": wut.IOClassBestEffort,
+	"idle":        wut.IOClassIdle,
+}
+
+func (v *ioniceValue) String() string {
+	for name, class := range ioClasses {
+		if class == v.class {
+			return fmt.Sprintf("%s:%d", name, v.level)
+		}
+	}
+	return ""
+}
+
+func (v *ioniceValue) Set(s string) error {
+	name, level, hasLevel := strings.Cut(s, ":")
+	class, ok := ioClasses[name]
+	if !ok {
+		return fmt.Errorf("unknown I/O class %q, must be one of realtime, best-effort, or idle", name)
+	}
+	v.class, v.level = class, 0
+	if hasLevel {
+		l, err := strconv.Atoi(level)
+		if err != nil || l < 0 || l > 7 {
+			return fmt.Errorf("invalid I/O priority level %q, must be 0-7", level)
+		}
+		v.level = l
+	}
+	return nil
+}
+
+// ulimitValue is a flag.Value parsing a comma separated list of resource
+// limits in the form NAME=SOFT[:HARD], e.g. "nofile=1024,cpu=60:120".
+type ulimitValue struct {
+	rlimits []wut.Rlimit
+	raw     string
+}
+
+func (v *ulimitValue) String() string { return v.raw }
+
+func (v *ulimitValue) Set(s string) error {
+	var rlimits []wut.Rlimit
+	for item := range strings.SplitSeq(s, ",") {
+		name, limits, ok := strings.Cut(item, "=")
+		resource, known := rlimitResources[name]
+		if !ok || !known {
+			return fmt.Errorf("invalid resource limit %q, must be NAME=SOFT[:HARD] with NAME one of %s", item, rlimitNames())
+		}
+		soft, hard, _ := strings.Cut(limits, ":")
+		rl := wut.Rlimit{Resource: resource}
+		var err error
+		if rl.Cur, err = strconv.ParseUint(soft, 10, 64); err != nil {
+			return fmt.Errorf("invalid soft limit in %q", item)
+		}
+		if hard != "" {
+			if rl.Max, err = strconv.ParseUint(hard, 10, 64); err != nil {
+				return fmt.Errorf("invalid hard limit in %q", item)
+			}
+		}
+		rlimits = append(rlimits, rl)
+	}
+	v.rlimits, v.raw = rlimits, s
+	return nil
+}
+
+func rlimitNames() string {
+	var names []string
+	for name := range rlimitResources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// byteSizeValue is a flag.Value parsing a number of bytes with an optional
+// binary unit suffix of K, M, G, or T, e.g. "512M".
+type byteSizeValue struct {
+	bytes int64
+	raw   string
+}
+
+var byteUnits = map[byte]int64{'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30, 'T': 1 << 40}
+
+func (v *byteSizeValue) String() string { return v.raw }
+
+func (v *byteSizeValue) Set(s string) error {
+	num, mult := strings.TrimSuffix(strings.ToUpper(s), "B"), int64(1)
+	if n := len(num); n > 0 {
+		if m, ok := byteUnits[num[n-1]]; ok {
+			num, mult = num[:n-1], m
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n <= 0 || n > math.MaxInt64/mult {
+		return fmt.Errorf("invalid size %q, must be a positive number of bytes with optional K, M, G, or T suffix", s)
+	}
+	v.bytes, v.raw = n*mult, s
+	return nil
+}
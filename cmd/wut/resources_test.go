@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mroth/wut"
+)
+
+func TestIoniceValue(t *testing.T) {
+	tests := []struct {
+		in        string
+		wantClass wut.IOClass
+		wantLevel int
+		wantErr   bool
+	}{
+		{in: "idle", wantClass: wut.IOClassIdle},
+		{in: "best-effort:7", wantClass: wut.IOClassBestEffort, wantLevel: 7},
+		{in: "realtime:8", wantErr: true},
+		{in: "fast", wantErr: true},
+	}
+	for _, tt := range tests {
+		var v ioniceValue
+		err := v.Set(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: got error %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (v.class != tt.wantClass || v.level != tt.wantLevel) {
+			t.Errorf("%q: got %v:%d, want %v:%d", tt.in, v.class, v.level, tt.wantClass, tt.wantLevel)
+		}
+	}
+}
+
+func TestUlimitValue(t *testing.T) {
+	if len(rlimitResources) == 0 {
+		t.Skip("resource limits are not supported on this platform")
+	}
+
+	var v ulimitValue
+	if err := v.Set("nofile=1024,cpu=60:120"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []wut.Rlimit{
+		{Resource: rlimitResources["nofile"], Cur: 1024},
+		{Resource: rlimitResources["cpu"], Cur: 60, Max: 120},
+	}
+	if len(v.rlimits) != len(want) || v.rlimits[0] != want[0] || v.rlimits[1] != want[1] {
+		t.Errorf("got %+v, want %+v", v.rlimits, want)
+	}
+
+	for _, bad := range []string{"nofile", "bogus=1", "nofile=many", "cpu=1:x"} {
+		if err := v.Set(bad); err == nil {
+			t.Errorf("%q: expected error, got nil", bad)
+		}
+	}
+}
+
+func TestByteSizeValue(t *testing.T) {
+	tests := map[string]int64{
+		"1024": 1024,
+		"512M": 512 << 20,
+		"2g":   2 << 30,
+		"1KB":  1 << 10,
+	}
+	for in, want := range tests {
+		var v byteSizeValue
+		if err := v.Set(in); err != nil {
+			t.Errorf("%q: unexpected error: %v", in, err)
+		} else if v.bytes != want {
+			t.Errorf("%q: got %d, want %d", in, v.bytes, want)
+		}
+	}
+
+	for _, bad := range []string{"", "M", "-1", "0", "1X", "9999999T"} {
+		var v byteSizeValue
+		if err := v.Set(bad); err == nil {
+			t.Errorf("%q: expected error, got nil", bad)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package main
+
+// rlimitResources maps the names accepted by -ulimit to their resource.
+// Resource limits are not supported on this platform.
+var rlimitResources = map[string]int{}
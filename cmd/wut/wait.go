@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mroth/wut"
+	"github.com/mroth/wut/probe"
+)
+
+// waitTarget is a condition checked by a probe, which must hold before the
+// command is run.
+type waitTarget struct {
+	name  string // name passed to the probe, such as a path or address
+	probe wut.Executor
+}
+
+// waitTargets returns the conditions given by the -wait-for-file, -port, and
+// -wait-for-http flags.
+func waitTargets() []waitTarget {
+	var targets []waitTarget
+	for _, path := range waitFiles {
+		targets = append(targets, waitTarget{path, probe.File{NonEmpty: *waitNonEmpty}})
+	}
+	for _, addr := range waitPorts {
+		targets = append(targets, waitTarget{addr, probe.TCP{}})
+	}
+	for _, url := range waitURLs {
+		targets = append(targets, waitTarget{url, probe.HTTP{ExpectStatus: expectStatus}})
+	}
+	return targets
+}
+
+// waitFor probes each of targets in turn until it holds, retrying with the
+// delay and per-attempt timeout of cfg, and stopping once ctx is done.
+func waitFor(ctx context.Context, logger *slog.Logger, cfg wut.Config, targets []waitTarget) error {
+	for _, t := range targets {
+		r := wut.NewRunner(ctx, t.name)
+		r.SetExecutor(t.probe)
+		r.SetLogger(logger.With("probe", t.name))
+		r.RetryDelay = time.Duration(cfg.RetryDelay)
+		r.ProcessTimeout = time.Duration(cfg.ProcessTimeout)
+		if err := r.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
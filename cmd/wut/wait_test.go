@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mroth/wut"
+	"github.com/mroth/wut/probe"
+)
+
+func TestWaitFor(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	logger := slog.New(slog.DiscardHandler)
+	cfg := wut.Config{RetryDelay: wut.Duration(10 * time.Millisecond)}
+	if err := waitFor(t.Context(), logger, cfg, []waitTarget{{ln.Addr().String(), probe.TCP{}}}); err != nil {
+		t.Errorf("listening port: unexpected error: %v", err)
+	}
+
+	ln.Close()
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	if err := waitFor(ctx, logger, cfg, []waitTarget{{ln.Addr().String(), probe.TCP{}}}); err == nil {
+		t.Error("closed port: expected error, got nil")
+	}
+}
+
+func TestWaitFor_http(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	logger := slog.New(slog.DiscardHandler)
+	cfg := wut.Config{RetryDelay: wut.Duration(10 * time.Millisecond)}
+	if err := waitFor(t.Context(), logger, cfg, []waitTarget{{srv.URL, probe.HTTP{ExpectStatus: []int{200}}}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests: got %d, want 3", got)
+	}
+}
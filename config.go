@@ -0,0 +1,296 @@
+package wut
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// Duration is a time.Duration encoded as text in the format accepted by
+// time.ParseDuration, such as "1m30s", for use in a [Config].
+type Duration time.Duration
+
+// MarshalText implements [encoding.TextMarshaler].
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Config is a declarative description of a Runner, such as may be decoded
+// from a JSON, YAML, or TOML file, from which a Runner is created by
+// [NewRunnerFromConfig]. Each field corresponds to the Runner, CommandOpts, or
+// Resources field of the same name, unless noted otherwise, and enumerated
+// values are given by their String, such as "on-failure" for OutputOnFailure.
+//
+// Settings which cannot be described declaratively, such as writers and
+// callbacks, are left for the caller to set on the Runner, as are resource
+// limits, whose identifiers vary by platform.
+type Config struct {
+	// Command and Args are the command to run. Alternatively, Shell is a
+	// script to run through the system shell, see [NewShellRunner].
+	Command string   `json:"command,omitempty" yaml:"command,omitempty" toml:"command,omitempty"`
+	Args    []string `json:"args,omitempty" yaml:"args,omitempty" toml:"args,omitempty"`
+	Shell   string   `json:"shell,omitempty" yaml:"shell,omitempty" toml:"shell,omitempty"`
+
+	ProcessTimeout       Duration   `json:"process_timeout,omitzero" yaml:"process_timeout,omitempty" toml:"process_timeout,omitempty"`
+	IdleTimeout          Duration   `json:"idle_timeout,omitzero" yaml:"idle_timeout,omitempty" toml:"idle_timeout,omitempty"`
+	HedgeDelay           Duration   `json:"hedge_delay,omitzero" yaml:"hedge_delay,omitempty" toml:"hedge_delay,omitempty"`
+	RetryDelay           Duration   `json:"retry_delay,omitzero" yaml:"retry_delay,omitempty" toml:"retry_delay,omitempty"`
+	Backoff              Backoff    `json:"backoff,omitzero" yaml:"backoff,omitempty" toml:"backoff,omitempty"`
+	BackoffFactor        float64    `json:"backoff_factor,omitempty" yaml:"backoff_factor,omitempty" toml:"backoff_factor,omitempty"`
+	Delays               []Duration `json:"delays,omitempty" yaml:"delays,omitempty" toml:"delays,omitempty"`
+	MaxDelay             Duration   `json:"max_delay,omitzero" yaml:"max_delay,omitempty" toml:"max_delay,omitempty"`
+	Interval             Duration   `json:"interval,omitzero" yaml:"interval,omitempty" toml:"interval,omitempty"`
+	StartAt              time.Time  `json:"start_at,omitzero" yaml:"start_at,omitempty" toml:"start_at,omitempty"`
+	Splay                Duration   `json:"splay,omitzero" yaml:"splay,omitempty" toml:"splay,omitempty"`
+	DrainTimeout         Duration   `json:"drain_timeout,omitzero" yaml:"drain_timeout,omitempty" toml:"drain_timeout,omitempty"`
+	Jitter               Duration   `json:"jitter,omitzero" yaml:"jitter,omitempty" toml:"jitter,omitempty"`
+	JitterFraction       float64    `json:"jitter_fraction,omitempty" yaml:"jitter_fraction,omitempty" toml:"jitter_fraction,omitempty"`
+	MaxRuns              uint       `json:"max_runs,omitempty" yaml:"max_runs,omitempty" toml:"max_runs,omitempty"`
+	ContinueOnSuccess    bool       `json:"continue_on_success,omitempty" yaml:"continue_on_success,omitempty" toml:"continue_on_success,omitempty"`
+	ConsecutiveSuccesses uint       `json:"consecutive_successes,omitempty" yaml:"consecutive_successes,omitempty" toml:"consecutive_successes,omitempty"`
+	UntilFailure         bool       `json:"until_failure,omitempty" yaml:"until_failure,omitempty" toml:"until_failure,omitempty"`
+	MinUptime            Duration   `json:"min_uptime,omitzero" yaml:"min_uptime,omitempty" toml:"min_uptime,omitempty"`
+	CrashLoopLimit       uint       `json:"crash_loop_limit,omitempty" yaml:"crash_loop_limit,omitempty" toml:"crash_loop_limit,omitempty"`
+	SuccessCodes         []int      `json:"success_codes,omitempty" yaml:"success_codes,omitempty" toml:"success_codes,omitempty"`
+	UntilOutput          string     `json:"until_output,omitempty" yaml:"until_output,omitempty" toml:"until_output,omitempty"`       // regular expression
+	Verify               string     `json:"verify,omitempty" yaml:"verify,omitempty" toml:"verify,omitempty"`                         // script run through the system shell, with Env and Dir
+	FailOnOutput         string     `json:"fail_on_output,omitempty" yaml:"fail_on_output,omitempty" toml:"fail_on_output,omitempty"` // regular expression
+	AbortCodes           []int      `json:"abort_codes,omitempty" yaml:"abort_codes,omitempty" toml:"abort_codes,omitempty"`
+	RetryCodes           []int      `json:"retry_codes,omitempty" yaml:"retry_codes,omitempty" toml:"retry_codes,omitempty"`
+	RetryTimeoutsOnly    bool       `json:"retry_timeouts_only,omitempty" yaml:"retry_timeouts_only,omitempty" toml:"retry_timeouts_only,omitempty"`
+
+	CaptureLimit     int          `json:"capture_limit,omitempty" yaml:"capture_limit,omitempty" toml:"capture_limit,omitempty"`
+	OutputPolicy     OutputPolicy `json:"output_policy,omitzero" yaml:"output_policy,omitempty" toml:"output_policy,omitempty"`
+	OutputTailLines  int          `json:"output_tail_lines,omitempty" yaml:"output_tail_lines,omitempty" toml:"output_tail_lines,omitempty"`
+	OutputPrefix     string       `json:"output_prefix,omitempty" yaml:"output_prefix,omitempty" toml:"output_prefix,omitempty"`
+	OutputMaxBytes   int          `json:"output_max_bytes,omitempty" yaml:"output_max_bytes,omitempty" toml:"output_max_bytes,omitempty"`
+	OutputMaxLines   int          `json:"output_max_lines,omitempty" yaml:"output_max_lines,omitempty" toml:"output_max_lines,omitempty"`
+	OutputTimestamps Timestamps   `json:"output_timestamps,omitzero" yaml:"output_timestamps,omitempty" toml:"output_timestamps,omitempty"`
+	OutputStripANSI  bool         `json:"output_strip_ansi,omitempty" yaml:"output_strip_ansi,omitempty" toml:"output_strip_ansi,omitempty"`
+
+	// LogStdout and LogStderr are the levels at which to log the output of
+	// the command, such as "INFO", or unset to not log it.
+	LogStdout     *slog.Level       `json:"log_stdout,omitempty" yaml:"log_stdout,omitempty" toml:"log_stdout,omitempty"`
+	LogStderr     *slog.Level       `json:"log_stderr,omitempty" yaml:"log_stderr,omitempty" toml:"log_stderr,omitempty"`
+	LogJSONOutput bool              `json:"log_json_output,omitempty" yaml:"log_json_output,omitempty" toml:"log_json_output,omitempty"`
+	LogAttrs      map[string]string `json:"log_attrs,omitempty" yaml:"log_attrs,omitempty" toml:"log_attrs,omitempty"` // string attributes, in order of key
+	LogGroup      string            `json:"log_group,omitempty" yaml:"log_group,omitempty" toml:"log_group,omitempty"`
+	LogKeys       map[string]string `json:"log_keys,omitempty" yaml:"log_keys,omitempty" toml:"log_keys,omitempty"`
+
+	// Redact and RedactEnv configure a Redactor, with regular expressions
+	// matching secrets, and names of environment variables holding them.
+	Redact    []string `json:"redact,omitempty" yaml:"redact,omitempty" toml:"redact,omitempty"`
+	RedactEnv []string `json:"redact_env,omitempty" yaml:"redact_env,omitempty" toml:"redact_env,omitempty"`
+
+	StateFile     string   `json:"state_file,omitempty" yaml:"state_file,omitempty" toml:"state_file,omitempty"`
+	LockFile      string   `json:"lock_file,omitempty" yaml:"lock_file,omitempty" toml:"lock_file,omitempty"`
+	LockWait      bool     `json:"lock_wait,omitempty" yaml:"lock_wait,omitempty" toml:"lock_wait,omitempty"`
+	SuccessMarker string   `json:"success_marker,omitempty" yaml:"success_marker,omitempty" toml:"success_marker,omitempty"`
+	SuccessTTL    Duration `json:"success_ttl,omitzero" yaml:"success_ttl,omitempty" toml:"success_ttl,omitempty"`
+
+	Env          []string     `json:"env,omitempty" yaml:"env,omitempty" toml:"env,omitempty"`
+	Dir          string       `json:"dir,omitempty" yaml:"dir,omitempty" toml:"dir,omitempty"`
+	WaitDelay    Duration     `json:"wait_delay,omitzero" yaml:"wait_delay,omitempty" toml:"wait_delay,omitempty"`
+	ProcessGroup bool         `json:"process_group,omitempty" yaml:"process_group,omitempty" toml:"process_group,omitempty"`
+	GracePeriod  Duration     `json:"grace_period,omitzero" yaml:"grace_period,omitempty" toml:"grace_period,omitempty"`
+	User         string       `json:"user,omitempty" yaml:"user,omitempty" toml:"user,omitempty"`
+	Group        string       `json:"group,omitempty" yaml:"group,omitempty" toml:"group,omitempty"`
+	Orphans      OrphanPolicy `json:"orphans,omitzero" yaml:"orphans,omitempty" toml:"orphans,omitempty"`
+
+	// KillOnParentExit sets the ParentDeathSignal of the command to SIGKILL.
+	KillOnParentExit bool `json:"kill_on_parent_exit,omitempty" yaml:"kill_on_parent_exit,omitempty" toml:"kill_on_parent_exit,omitempty"`
+
+	Nice            int      `json:"nice,omitempty" yaml:"nice,omitempty" toml:"nice,omitempty"`
+	IOClass         IOClass  `json:"io_class,omitzero" yaml:"io_class,omitempty" toml:"io_class,omitempty"`
+	IOLevel         int      `json:"io_level,omitempty" yaml:"io_level,omitempty" toml:"io_level,omitempty"`
+	MemoryMax       int64    `json:"memory_max,omitempty" yaml:"memory_max,omitempty" toml:"memory_max,omitempty"`
+	CPUMax          float64  `json:"cpu_max,omitempty" yaml:"cpu_max,omitempty" toml:"cpu_max,omitempty"`
+	CgroupParent    string   `json:"cgroup_parent,omitempty" yaml:"cgroup_parent,omitempty" toml:"cgroup_parent,omitempty"`
+	MaxRSS          int64    `json:"max_rss,omitempty" yaml:"max_rss,omitempty" toml:"max_rss,omitempty"`
+	RSSPollInterval Duration `json:"rss_poll_interval,omitzero" yaml:"rss_poll_interval,omitempty" toml:"rss_poll_interval,omitempty"`
+}
+
+// LoadConfig reads a Config from the JSON file at path, see [Config.ReadFile].
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	err := cfg.ReadFile(path)
+	return cfg, err
+}
+
+// ReadFile sets the fields of c present in the JSON file at path, leaving the
+// remaining fields, such as defaults set beforehand, unchanged. Unknown fields
+// are rejected, so that misspelled settings are not silently ignored.
+func (c *Config) ReadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// NewRunnerFromConfig creates a new Runner with the provided context, as
+// described by cfg. See [NewRunner] for the role of the context.
+func NewRunnerFromConfig(ctx context.Context, cfg Config) (*Runner, error) {
+	var r *Runner
+	switch {
+	case cfg.Command != "" && cfg.Shell != "":
+		return nil, errors.New("wut: config sets both command and shell")
+	case cfg.Shell != "":
+		r = NewShellRunner(ctx, cfg.Shell)
+	case cfg.Command != "":
+		r = NewRunner(ctx, cfg.Command, cfg.Args...)
+	default:
+		return nil, errors.New("wut: config sets no command")
+	}
+	if cfg.HedgeDelay > 0 && (cfg.IdleTimeout > 0 || cfg.UntilOutput != "") {
+		return nil, errors.New("wut: config sets hedge_delay with idle_timeout or until_output")
+	}
+
+	r.ProcessTimeout = time.Duration(cfg.ProcessTimeout)
+	r.IdleTimeout = time.Duration(cfg.IdleTimeout)
+	r.HedgeDelay = time.Duration(cfg.HedgeDelay)
+	r.RetryDelay = time.Duration(cfg.RetryDelay)
+	r.Backoff = cfg.Backoff
+	r.BackoffFactor = cfg.BackoffFactor
+	for _, d := range cfg.Delays {
+		r.Delays = append(r.Delays, time.Duration(d))
+	}
+	r.MaxDelay = time.Duration(cfg.MaxDelay)
+	r.Interval = time.Duration(cfg.Interval)
+	r.StartAt = cfg.StartAt
+	r.Splay = time.Duration(cfg.Splay)
+	r.DrainTimeout = time.Duration(cfg.DrainTimeout)
+	r.Jitter = time.Duration(cfg.Jitter)
+	r.JitterFraction = cfg.JitterFraction
+	r.MaxRuns = cfg.MaxRuns
+	r.ContinueOnSuccess = cfg.ContinueOnSuccess
+	r.ConsecutiveSuccesses = cfg.ConsecutiveSuccesses
+	r.UntilFailure = cfg.UntilFailure
+	r.MinUptime = time.Duration(cfg.MinUptime)
+	r.CrashLoopLimit = cfg.CrashLoopLimit
+	r.SuccessCodes = cfg.SuccessCodes
+	if cfg.UntilOutput != "" {
+		re, err := regexp.Compile(cfg.UntilOutput)
+		if err != nil {
+			return nil, fmt.Errorf("wut: config until_output pattern: %w", err)
+		}
+		r.UntilOutput = re
+	}
+	if cfg.Verify != "" {
+		r.Verify = func(ctx context.Context) error {
+			return ShellExecutor{}.Run(ctx, CommandOpts{Env: cfg.Env, Dir: cfg.Dir}, cfg.Verify)
+		}
+	}
+	if cfg.FailOnOutput != "" {
+		re, err := regexp.Compile(cfg.FailOnOutput)
+		if err != nil {
+			return nil, fmt.Errorf("wut: config fail_on_output pattern: %w", err)
+		}
+		r.FailOnOutput = re
+	}
+	r.AbortCodes = cfg.AbortCodes
+	r.RetryCodes = cfg.RetryCodes
+	r.RetryTimeoutsOnly = cfg.RetryTimeoutsOnly
+
+	r.CaptureLimit = cfg.CaptureLimit
+	r.OutputPolicy = cfg.OutputPolicy
+	r.OutputTailLines = cfg.OutputTailLines
+	r.OutputPrefix = cfg.OutputPrefix
+	r.OutputMaxBytes = cfg.OutputMaxBytes
+	r.OutputMaxLines = cfg.OutputMaxLines
+	r.OutputTimestamps = cfg.OutputTimestamps
+	r.OutputStripANSI = cfg.OutputStripANSI
+
+	if cfg.LogStdout != nil {
+		r.LogStdout = *cfg.LogStdout
+	}
+	if cfg.LogStderr != nil {
+		r.LogStderr = *cfg.LogStderr
+	}
+	r.LogJSONOutput = cfg.LogJSONOutput
+	for _, k := range slices.Sorted(maps.Keys(cfg.LogAttrs)) {
+		r.LogAttrs = append(r.LogAttrs, slog.String(k, cfg.LogAttrs[k]))
+	}
+	r.LogGroup = cfg.LogGroup
+	r.LogKeys = cfg.LogKeys
+
+	if len(cfg.Redact) > 0 || len(cfg.RedactEnv) > 0 {
+		rd := &Redactor{EnvVars: cfg.RedactEnv}
+		for _, p := range cfg.Redact {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("wut: config redact pattern: %w", err)
+			}
+			rd.Patterns = append(rd.Patterns, re)
+		}
+		r.Redactor = rd
+	}
+
+	r.StateFile = cfg.StateFile
+	r.LockFile = cfg.LockFile
+	r.LockWait = cfg.LockWait
+	r.SuccessMarker = cfg.SuccessMarker
+	r.SuccessTTL = time.Duration(cfg.SuccessTTL)
+
+	opts := &r.CommandOptions
+	opts.Env = cfg.Env
+	opts.Dir = cfg.Dir
+	opts.WaitDelay = time.Duration(cfg.WaitDelay)
+	opts.ProcessGroup = cfg.ProcessGroup
+	opts.GracePeriod = time.Duration(cfg.GracePeriod)
+	opts.User = cfg.User
+	opts.Group = cfg.Group
+	opts.Orphans = cfg.Orphans
+	if cfg.KillOnParentExit {
+		opts.ParentDeathSignal = syscall.SIGKILL
+	}
+	opts.Resources = Resources{
+		Nice:            cfg.Nice,
+		IOClass:         cfg.IOClass,
+		IOLevel:         cfg.IOLevel,
+		MemoryMax:       cfg.MemoryMax,
+		CPUMax:          cfg.CPUMax,
+		CgroupParent:    cfg.CgroupParent,
+		MaxRSS:          cfg.MaxRSS,
+		RSSPollInterval: time.Duration(cfg.RSSPollInterval),
+	}
+	return r, nil
+}
+
+// unmarshalEnum sets dst to the value from 0 to last whose String is text.
+func unmarshalEnum[T interface {
+	~int
+	String() string
+}](dst *T, text []byte, last T) error {
+	for v := T(0); v <= last; v++ {
+		if v.String() == string(text) {
+			*dst = v
+			return nil
+		}
+	}
+	return fmt.Errorf("wut: unknown %T %q", *dst, text)
+}
@@ -0,0 +1,105 @@
+package wut
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wut.json")
+	err := os.WriteFile(path, []byte(`{
+		"command": "curl",
+		"args": ["-f", "http://localhost"],
+		"retry_delay": "1m30s",
+		"max_runs": 3,
+		"output_policy": "on-final-failure",
+		"output_timestamps": "relative",
+		"log_stderr": "WARN",
+		"log_attrs": {"job": "probe", "env": "dev"},
+		"redact": ["token=\\w+"],
+		"process_group": true,
+		"orphans": "kill",
+		"io_class": "idle"
+	}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := NewRunnerFromConfig(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.name != "curl" || !reflect.DeepEqual(r.args, []string{"-f", "http://localhost"}) {
+		t.Errorf("got command %q %q", r.name, r.args)
+	}
+	if r.RetryDelay != 90*time.Second || r.MaxRuns != 3 {
+		t.Errorf("got RetryDelay %v, MaxRuns %v", r.RetryDelay, r.MaxRuns)
+	}
+	if r.OutputPolicy != OutputOnFinalFailure || r.OutputTimestamps != TimestampsRelative {
+		t.Errorf("got OutputPolicy %v, OutputTimestamps %v", r.OutputPolicy, r.OutputTimestamps)
+	}
+	if r.LogStderr != slog.LevelWarn || r.LogStdout != nil {
+		t.Errorf("got LogStdout %v, LogStderr %v", r.LogStdout, r.LogStderr)
+	}
+	if want := []slog.Attr{slog.String("env", "dev"), slog.String("job", "probe")}; !reflect.DeepEqual(r.LogAttrs, want) {
+		t.Errorf("got LogAttrs %v, want %v", r.LogAttrs, want)
+	}
+	if r.Redactor == nil || r.Redactor.Redact("token=abc") == "token=abc" {
+		t.Errorf("got Redactor %v, want token redacted", r.Redactor)
+	}
+	opts := r.CommandOptions
+	if !opts.ProcessGroup || opts.Orphans != OrphansKill || opts.Resources.IOClass != IOClassIdle {
+		t.Errorf("got ProcessGroup %v, Orphans %v, IOClass %v", opts.ProcessGroup, opts.Orphans, opts.Resources.IOClass)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name, json, want string
+	}{
+		{"unknown field", `{"command": "x", "retries": 3}`, "unknown field"},
+		{"bad duration", `{"command": "x", "retry_delay": "soon"}`, "invalid duration"},
+		{"bad enum", `{"command": "x", "output_policy": "sometimes"}`, `unknown wut.OutputPolicy "sometimes"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wut.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRunnerFromConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"no command", Config{}},
+		{"command and shell", Config{Command: "x", Shell: "x"}},
+		{"bad redact pattern", Config{Command: "x", Redact: []string{"("}}},
+		{"hedge with idle timeout", Config{Command: "x", HedgeDelay: Duration(time.Second), IdleTimeout: Duration(time.Second)}},
+		{"hedge with until output", Config{Command: "x", HedgeDelay: Duration(time.Second), UntilOutput: "ready"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRunnerFromConfig(t.Context(), tt.cfg); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package wut
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Control commands accepted by a ControlServer.
+const (
+	ControlStatus = "status" // report the Status of the Runner
+	ControlPause  = "pause"  // pause the Runner, see Runner.Pause
+	ControlResume = "resume" // resume the Runner, see Runner.Resume
+	ControlKick   = "kick"   // skip any retry delay, see Runner.Kick
+	ControlStop   = "stop"   // stop the Runner, if the ControlServer has a Stop function
+)
+
+// ControlServer serves a control socket for a Runner, through which a
+// long-running process can be managed with a [ControlClient]. Each connection
+// carries a single command, sent as a line of text, and its [ControlReply],
+// sent as a line of JSON.
+type ControlServer struct {
+	Runner *Runner
+	Status *StatusTracker // tracker of the Runner reported by ControlStatus
+
+	// Stop, if set, is called for ControlStop, and should cancel the context
+	// of the Runner. If nil, ControlStop is rejected.
+	Stop func()
+}
+
+// ControlReply is the reply of a ControlServer to a command.
+type ControlReply struct {
+	Error  string  `json:"error,omitempty"`
+	Status *Status `json:"status,omitempty"` // current status, if the ControlServer has a StatusTracker
+}
+
+// ListenControl listens on a Unix socket at path, replacing any stale socket
+// left behind by a previous process. The socket is only accessible by the
+// current user.
+func ListenControl(path string) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Serve accepts connections on ln, handling each in its own goroutine, until
+// ln is closed.
+func (cs *ControlServer) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		go cs.handle(conn)
+	}
+}
+
+// handle reads a single command from conn, and replies to it.
+func (cs *ControlServer) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	var reply ControlReply
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+	if err := cs.exec(strings.TrimSpace(line)); err != nil {
+		reply.Error = err.Error()
+	}
+	if cs.Status != nil {
+		s := cs.Status.Status()
+		reply.Status = &s
+	}
+	data, _ := json.Marshal(reply)
+	conn.Write(append(data, '\n'))
+}
+
+// exec executes a command.
+func (cs *ControlServer) exec(cmd string) error {
+	switch cmd {
+	case ControlStatus:
+	case ControlPause:
+		cs.Runner.Pause()
+	case ControlResume:
+		cs.Runner.Resume()
+	case ControlKick:
+		cs.Runner.Kick()
+	case ControlStop:
+		if cs.Stop == nil {
+			return errors.New("stop is not supported")
+		}
+		cs.Stop()
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	return nil
+}
+
+// ControlClient sends commands to the control socket of a ControlServer.
+type ControlClient struct {
+	Path string // path of the control socket
+}
+
+// Send sends a command, such as ControlPause, returning the reply. Commands
+// rejected by the server are returned as errors.
+func (c ControlClient) Send(cmd string) (ControlReply, error) {
+	var reply ControlReply
+	conn, err := net.DialTimeout("unix", c.Path, 10*time.Second)
+	if err != nil {
+		return reply, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if _, err := fmt.Fprintln(conn, cmd); err != nil {
+		return reply, err
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return reply, err
+	}
+	if err := json.Unmarshal(line, &reply); err != nil {
+		return reply, err
+	}
+	if reply.Error != "" {
+		return reply, errors.New(reply.Error)
+	}
+	return reply, nil
+}
+
+// Status returns the status of the Runner.
+func (c ControlClient) Status() (Status, error) {
+	reply, err := c.Send(ControlStatus)
+	if err == nil && reply.Status == nil {
+		err = errors.New("wut: status is not available")
+	}
+	if err != nil {
+		return Status{}, err
+	}
+	return *reply.Status, nil
+}
+
+// Pause pauses the Runner.
+func (c ControlClient) Pause() error { return c.sendOnly(ControlPause) }
+
+// Resume resumes the Runner.
+func (c ControlClient) Resume() error { return c.sendOnly(ControlResume) }
+
+// Kick causes the Runner to skip any retry delay.
+func (c ControlClient) Kick() error { return c.sendOnly(ControlKick) }
+
+// Stop stops the Runner.
+func (c ControlClient) Stop() error { return c.sendOnly(ControlStop) }
+
+func (c ControlClient) sendOnly(cmd string) error {
+	_, err := c.Send(cmd)
+	return err
+}
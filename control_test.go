@@ -0,0 +1,85 @@
+package wut
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestControlServer(t *testing.T) {
+	// socket paths are limited in length, so avoid the long t.TempDir
+	dir, err := os.MkdirTemp("", "wut")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "control.sock")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	r := NewRunnerWithExecutor(ctx, mockExecutor{})
+	r.ContinueOnSuccess = true
+	r.RetryDelay = time.Hour
+
+	ln, err := ListenControl(path)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+	cs := &ControlServer{Runner: r, Status: TrackStatus(r), Stop: cancel}
+	go cs.Serve(ln)
+
+	done := make(chan error)
+	go func() { done <- r.Run() }()
+
+	client := ControlClient{Path: path}
+	waitForRuns := func(n uint) Status {
+		t.Helper()
+		for range 200 {
+			s, err := client.Status()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.RunsCompleted >= n {
+				return s
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %d runs", n)
+		return Status{}
+	}
+	waitForRuns(1)
+
+	// a kick while paused does not start an attempt
+	if err := client.Pause(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.Kick(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if s, _ := client.Status(); s.RunsCompleted != 1 || !s.Paused {
+		t.Errorf("got status %+v, want 1 run while paused", s)
+	}
+
+	if err := client.Resume(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := waitForRuns(2); s.Paused {
+		t.Errorf("got status %+v, want resumed", s)
+	}
+
+	if _, err := client.Send("bogus"); err == nil {
+		t.Errorf("expected error for unknown command")
+	}
+
+	if err := client.Stop(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
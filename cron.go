@@ -0,0 +1,204 @@
+package wut
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a Schedule given by a standard five field cron expression, such as
+// "*/5 * * * *", see [ParseCron].
+type Cron struct {
+	spec    string
+	minute  uint64 // bit i set for each matching value i
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	anyDay  bool // neither dom nor dow is restricted
+	eachDay bool // dom and dow are both restricted, so either matches
+}
+
+// verify Cron implements the Schedule interface
+var _ Schedule = (*Cron)(nil)
+
+// cronField describes the range of values of a field of a cron expression,
+// and any names for them.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names of the values from min, if any
+}
+
+var cronFields = [...]cronField{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{"day of week", 0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a standard cron expression of five space separated fields:
+// minute, hour, day of month, month, and day of week. Each field is either *,
+// or a comma separated list of values or ranges such as 1-5, optionally with
+// a step such as */15 or 0-30/10. Months and days of the week may be given by
+// their three letter English names, and Sunday as either 0 or 7. As in
+// cron(8), if both the day of month and day of week are restricted, that is,
+// neither starts with *, a time matching either matches. The macros @hourly, @daily, @midnight, @weekly,
+// @monthly, @yearly, and @annually are also accepted.
+//
+// Times are matched in the location of the time passed to Next.
+func ParseCron(spec string) (*Cron, error) {
+	expr := spec
+	if m, ok := cronMacros[strings.TrimSpace(spec)]; ok {
+		expr = m
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("wut: cron expression %q: want %d fields, got %d", spec, len(cronFields), len(fields))
+	}
+
+	var sets [len(cronFields)]uint64
+	for i, f := range fields {
+		set, err := cronFields[i].parse(f)
+		if err != nil {
+			return nil, fmt.Errorf("wut: cron expression %q: %v", spec, err)
+		}
+		sets[i] = set
+	}
+	c := &Cron{
+		spec:   spec,
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // Sunday may be either 0 or 7
+	}
+	// a day field starting with "*", such as "*/2", counts as unrestricted in
+	// deciding whether either day field may match
+	c.anyDay = fields[2] == "*" && fields[4] == "*"
+	c.eachDay = !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// parse returns the set of values matched by s.
+func (f cronField) parse(s string) (uint64, error) {
+	var set uint64
+	for part := range strings.SplitSeq(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepStr, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loStr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiStr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max // as in 5/15, stepping from a value to the end
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q in %s field", rng, f.name)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// value parses a single value of the field, by number or name.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, must be from %d to %d", s, f.name, f.min, f.max)
+	}
+	return n, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (c *Cron) String() string { return c.spec }
+
+// Next implements [Schedule], returning the first time after t, to the
+// minute, matching the expression, or the zero time if there is none within
+// the next five years, such as for "0 0 30 2 *".
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = nextHour(t)
+		case c.minute&(1<<t.Minute()) == 0:
+			// skip straight to the next matching minute within the hour
+			next := c.minute >> t.Minute()
+			if next == 0 {
+				t = nextHour(t)
+			} else {
+				t = t.Add(time.Duration(bits.TrailingZeros64(next)) * time.Minute)
+			}
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// nextHour returns the start of the hour following t, in its location. Unlike
+// Truncate, this respects locations offset from UTC by part of an hour.
+func nextHour(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+}
+
+// dayMatches reports whether the day of t matches the day of month and day
+// of week fields.
+func (c *Cron) dayMatches(t time.Time) bool {
+	if c.anyDay {
+		return true
+	}
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.eachDay {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,102 @@
+package wut
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron_Next(t *testing.T) {
+	// Saturday
+	from := time.Date(2025, time.March, 15, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, time.March, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2025, time.March, 15, 10, 10, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2025, time.March, 15, 10, 25, 0, 0, time.UTC)},
+		{"0,30 9-17 * * *", time.Date(2025, time.March, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2025, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2025, time.March, 16, 2, 30, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2025, time.March, 17, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * mon", time.Date(2025, time.March, 17, 0, 0, 0, 0, time.UTC)}, // either day field matches
+		{"@hourly", time.Date(2025, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2025, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.spec, err)
+			continue
+		}
+		if got := c.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestCron_Next_dayStep(t *testing.T) {
+	// a stepped "*" day field restricts the days along with the other one,
+	// rather than either matching: Mondays on odd days of the month
+	c, err := ParseCron("0 0 */2 * 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2025, time.March, 17, 10, 0, 0, 0, time.UTC) // Monday
+	if got, want := c.Next(from), time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCron_NextLocation(t *testing.T) {
+	c, err := ParseCron("0 0 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// midnight is determined in the location of the time given
+	from := time.Date(2025, time.March, 15, 23, 0, 0, 0, time.UTC)
+	if got, want := c.Next(from), time.Date(2025, time.March, 16, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("UTC: got %v, want %v", got, want)
+	}
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	if got, want := c.Next(from.In(loc)), time.Date(2025, time.March, 17, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("UTC+2: got %v, want %v", got, want)
+	}
+	loc = time.FixedZone("UTC+5:30", 5*60*60+30*60)
+	if got, want := c.Next(from.In(loc)), time.Date(2025, time.March, 17, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("UTC+5:30: got %v, want %v", got, want)
+	}
+	c, _ = ParseCron("15 * * * *")
+	if got, want := c.Next(from.In(loc)), time.Date(2025, time.March, 16, 5, 15, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("UTC+5:30: got %v, want %v", got, want)
+	}
+}
+
+func TestParseCron_errors(t *testing.T) {
+	for _, bad := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@reboot",
+	} {
+		if _, err := ParseCron(bad); err == nil {
+			t.Errorf("%q: expected error, got nil", bad)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package wut
+
+import (
+	"bytes"
+	"strings"
+)
+
+// maxDiffCells bounds the size of the table used to compute a line diff.
+// Beyond it, the differing region is reported as wholly replaced.
+const maxDiffCells = 1 << 20
+
+// lineDiff returns a line-oriented diff between a and b, listing lines only
+// present in a prefixed by "-", and lines only present in b prefixed by "+".
+// Unchanged lines are omitted. It returns an empty string if a and b are equal.
+func lineDiff(a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	al, bl := splitLines(a), splitLines(b)
+
+	// trim the common prefix and suffix, which is typically most of the output
+	// of a command polled repeatedly
+	for len(al) > 0 && len(bl) > 0 && al[0] == bl[0] {
+		al, bl = al[1:], bl[1:]
+	}
+	for len(al) > 0 && len(bl) > 0 && al[len(al)-1] == bl[len(bl)-1] {
+		al, bl = al[:len(al)-1], bl[:len(bl)-1]
+	}
+
+	var s strings.Builder
+	if (len(al)+1)*(len(bl)+1) > maxDiffCells {
+		for _, l := range al {
+			s.WriteString("-" + l + "\n")
+		}
+		for _, l := range bl {
+			s.WriteString("+" + l + "\n")
+		}
+		return s.String()
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of al[i:] and bl[j:]
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			i, j = i+1, j+1
+		case j == len(bl) || (i < len(al) && lcs[i+1][j] >= lcs[i][j+1]):
+			s.WriteString("-" + al[i] + "\n")
+			i++
+		default:
+			s.WriteString("+" + bl[j] + "\n")
+			j++
+		}
+	}
+	return s.String()
+}
+
+// splitLines splits b into lines, without their line terminators.
+func splitLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
@@ -0,0 +1,89 @@
+package wut
+
+import (
+	"crypto/sha256"
+	"slices"
+	"testing"
+	"testing/synctest"
+)
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"from empty", "", "a\n", "+a\n"},
+		{"to empty", "a\n", "", "-a\n"},
+		{"changed line", "status: pending\nready: 1/3\n", "status: pending\nready: 2/3\n", "-ready: 1/3\n+ready: 2/3\n"},
+		{"inserted line", "a\nc\n", "a\nb\nc\n", "+b\n"},
+		{"interleaved", "a\nb\nc\nd\n", "a\nx\nc\ny\n", "-b\n+x\n-d\n+y\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lineDiff([]byte(tt.a), []byte(tt.b)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunner_OutputDiff(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "status")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"pods: 1/3\n", "pods: 1/3\n", "pods: 3/3\n"},
+			exitcode: []int{1, 1, 0},
+		})
+		r.CaptureLimit = 1024
+
+		var diffs []string
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptEnd {
+				diffs = append(diffs, e.Attempt.OutputDiff)
+			}
+		})
+		if err := r.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := []string{"", "", "-pods: 1/3\n+pods: 3/3\n"}
+		if !slices.Equal(diffs, want) {
+			t.Errorf("diffs: got %q, want %q", diffs, want)
+		}
+	})
+}
+
+func TestRunner_OutputSum(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "status")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"a: done\n", "a: done\n", "b: done\n"},
+			exitcode: []int{1, 1, 1},
+		})
+		r.MaxRuns = 3
+		r.CaptureLimit = 5 // only "done\n" is retained
+
+		var sums [][sha256.Size]byte
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptEnd {
+				sums = append(sums, e.Attempt.OutputSum)
+			}
+		})
+		r.Run()
+
+		if len(sums) != 3 {
+			t.Fatalf("got %d attempts, want 3", len(sums))
+		}
+		if want := sha256.Sum256([]byte("a: done\n")); sums[0] != want {
+			t.Errorf("got sum %x, want %x", sums[0], want)
+		}
+		if sums[1] != sums[0] {
+			t.Errorf("got different sums for identical output")
+		}
+		if sums[2] == sums[1] {
+			t.Errorf("got identical sums for output differing beyond the capture limit")
+		}
+	})
+}
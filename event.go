@@ -0,0 +1,135 @@
+package wut
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EventKind identifies the kind of an Event.
+type EventKind int
+
+const (
+	EventRunStart     EventKind = iota // Runner has started.
+	EventDelay                         // Runner has begun waiting out a retry delay.
+	EventDelaySkipped                  // Runner has skipped the remainder of a retry delay due to a Kick.
+	EventAttemptStart                  // Runner has started an attempt at executing the command.
+	EventAttemptEnd                    // Runner has completed an attempt at executing the command.
+	EventRunEnd                        // Runner has stopped, either due to success or another stop condition.
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventRunStart:
+		return "run start"
+	case EventDelay:
+		return "delay"
+	case EventDelaySkipped:
+		return "delay skipped"
+	case EventAttemptStart:
+		return "attempt start"
+	case EventAttemptEnd:
+		return "attempt end"
+	case EventRunEnd:
+		return "run end"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+}
+
+// Attempt describes a single execution of the command by a Runner.
+type Attempt struct {
+	Num      uint          // attempt number, starting from 1
+	Start    time.Time     // time the attempt started
+	Duration time.Duration // duration of the attempt, once completed
+	Err      error         // result of the attempt, once completed
+	Output   []byte        // captured output of the attempt, if enabled via Runner.CaptureLimit
+
+	// OutputDiff is a line-oriented diff of Output against the Output of the
+	// previous attempt, with removed lines prefixed by "-" and added lines
+	// prefixed by "+". It is empty if output capture is disabled, the output
+	// is unchanged, or for the first attempt.
+	OutputDiff string
+
+	// OutputSum is the SHA-256 hash of the complete output of the attempt as
+	// captured, prior to redaction and including any output beyond
+	// Runner.CaptureLimit, allowing cheap comparisons of the output of
+	// attempts. It is zero if output capture is disabled.
+	OutputSum [sha256.Size]byte
+
+	// OOMKilled reports whether the command was killed for exceeding its
+	// memory limit, see [Resources.MemoryMax] and [Resources.MaxRSS].
+	OOMKilled bool
+
+	// ForceKilled reports whether the command, or a descendant holding its
+	// output open, had to be forcibly stopped after failing to exit, see
+	// [ErrForceKilled].
+	ForceKilled bool
+
+	// Interrupted reports whether the attempt was cancelled because the
+	// context of the Runner was done, see [Runner.DrainTimeout], rather than
+	// running to completion or exceeding Runner.ProcessTimeout.
+	Interrupted bool
+
+	// Orphans are the pids of any processes the command left running once it
+	// exited, if detection is enabled via [CommandOpts.Orphans].
+	Orphans []int
+}
+
+// ExitCode returns the exit code of a completed attempt.
+//
+// It returns 0 if the attempt succeeded, the exit code reported by its error if
+// the error provides one via an ExitCode() int method (as [os/exec.ExitError]
+// does), or -1 otherwise, such as when the command could not be started.
+func (a Attempt) ExitCode() int {
+	if a.Err == nil {
+		return 0
+	}
+	var coder interface{ ExitCode() int }
+	if errors.As(a.Err, &coder) {
+		return coder.ExitCode()
+	}
+	return -1
+}
+
+// Event describes something that happened during the execution of a Runner.
+//
+// Events are emitted to observers registered with [Runner.Observe].
+type Event struct {
+	Time time.Time // time the event occurred, according to the Runner's Clock
+	Kind EventKind
+
+	// Attempt describes the attempt an EventAttemptStart or EventAttemptEnd
+	// relates to. Its Duration and Err are only set for EventAttemptEnd.
+	Attempt Attempt
+
+	// Delay is the duration of the retry delay for an EventDelay.
+	Delay time.Duration
+
+	// Err is the final result of the Runner for an EventRunEnd, which is nil
+	// on success or otherwise explains why the Runner stopped.
+	Err error
+}
+
+// String returns a short human readable description of the event.
+func (e Event) String() string {
+	switch e.Kind {
+	case EventDelay:
+		return fmt.Sprintf("delay %v", e.Delay)
+	case EventAttemptStart:
+		return fmt.Sprintf("attempt %d start", e.Attempt.Num)
+	case EventAttemptEnd:
+		if e.Attempt.Err != nil {
+			return fmt.Sprintf("attempt %d failed after %v: %v", e.Attempt.Num, e.Attempt.Duration, e.Attempt.Err)
+		}
+		return fmt.Sprintf("attempt %d succeeded after %v", e.Attempt.Num, e.Attempt.Duration)
+	case EventRunEnd:
+		if e.Err != nil {
+			return fmt.Sprintf("run end: %v", e.Err)
+		}
+		return "run end: success"
+	default:
+		return e.Kind.String()
+	}
+}
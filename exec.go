@@ -2,22 +2,46 @@ package wut
 
 import (
 	"context"
+	"errors"
 	"os/exec"
+	"runtime"
+	"strings"
+	"time"
 )
 
-// executor is used to abstract command execution for testing purposes.
-type executor interface {
+// Executor abstracts how the Runner executes a command.
+//
+// Implementations must run the named command with the given arguments to
+// completion, honoring the provided CommandOpts where applicable, and return
+// nil only if the command succeeded. Run must return promptly once ctx is
+// done, which is how the Runner enforces process timeouts and cancellation.
+//
+// Custom implementations can be used to supply alternate execution backends,
+// such as remote execution, emulation, or test doubles.
+type Executor interface {
 	Run(ctx context.Context, opts CommandOpts, name string, args ...string) error
 }
 
-// cmdExecutor is the default implementation of the executor interface.
-// It uses the os/exec package to run commands in a subprocess.
-type cmdExecutor struct{}
+// DefaultWaitDelay is the WaitDelay used by [CmdExecutor] if
+// [CommandOpts.WaitDelay] is zero, so that a command which does not exit once
+// cancelled, or leaves a descendant holding its output open, cannot stall the
+// Runner indefinitely. A negative WaitDelay disables it.
+const DefaultWaitDelay = 10 * time.Second
 
-// verify cmdExecutor implements the executor interface
-var _ executor = cmdExecutor{}
+// ErrForceKilled is matched by the error of a run whose command, or a
+// descendant holding its output open, failed to exit once cancelled or once
+// the command exited, and so was forcibly killed or had its output closed,
+// after [CommandOpts.WaitDelay] or [CommandOpts.GracePeriod].
+var ErrForceKilled = errors.New("wut: command forcibly stopped after failing to exit")
 
-func (ce cmdExecutor) Run(ctx context.Context, opts CommandOpts, name string, args ...string) error {
+// CmdExecutor is the default implementation of the Executor interface.
+// It uses the os/exec package to run commands in a local subprocess.
+type CmdExecutor struct{}
+
+// verify CmdExecutor implements the Executor interface
+var _ Executor = CmdExecutor{}
+
+func (ce CmdExecutor) Run(ctx context.Context, opts CommandOpts, name string, args ...string) error {
 	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Env = opts.Env
 	cmd.Dir = opts.Dir
@@ -25,8 +49,148 @@ func (ce cmdExecutor) Run(ctx context.Context, opts CommandOpts, name string, ar
 	cmd.Stdout = opts.Stdout
 	cmd.Stderr = opts.Stderr
 	cmd.WaitDelay = opts.WaitDelay
+	if cmd.WaitDelay == 0 {
+		cmd.WaitDelay = DefaultWaitDelay
+	} else if cmd.WaitDelay < 0 {
+		cmd.WaitDelay = 0 // wait indefinitely
+	}
+
+	attach, release := func() error { return nil }, func() {}
+	if opts.ProcessGroup {
+		var err error
+		if attach, release, err = setProcessGroup(cmd); err != nil {
+			return err
+		}
+		defer release()
+	}
+	escalated := func() bool { return false }
+	if opts.GracePeriod > 0 {
+		stop := setGracefulStop(cmd, opts.StopSignal, opts.GracePeriod, opts.ProcessGroup)
+		defer stop()
+		escalated = stop
+	}
 	if opts.Cancel != nil {
 		cmd.Cancel = opts.Cancel // not safe to set to nil
 	}
-	return cmd.Run()
+	if opts.User != "" || opts.Group != "" {
+		if err := setCredential(cmd, opts.User, opts.Group); err != nil {
+			return err
+		}
+	}
+	if opts.Orphans != OrphansIgnore {
+		if err := checkOrphansSupported(opts); err != nil {
+			return err
+		}
+	}
+	finish := func(err error) error { return err }
+	if opts.Resources.usesCgroup() {
+		var err error
+		if finish, err = setCgroup(cmd, opts.Resources); err != nil {
+			return err
+		}
+	}
+	if opts.ParentDeathSignal != nil {
+		if err := setParentDeathSignal(cmd, opts.ParentDeathSignal); err != nil {
+			return err
+		}
+	}
+
+	if err := cmd.Start(); err != nil {
+		return finish(err)
+	}
+	err := attach()
+	if err == nil && !opts.Resources.isZero() {
+		err = applyResources(cmd.Process.Pid, opts.Resources)
+	}
+	var stopWatch func() bool
+	if err == nil && opts.Resources.MaxRSS > 0 {
+		stopWatch, err = watchMemory(cmd.Process.Pid, opts.Resources.MaxRSS, opts.Resources.RSSPollInterval)
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return finish(err)
+	}
+	// note when ctx is done, to tell whether os/exec then gave up waiting
+	canceledAt := make(chan time.Time, 1)
+	stopCancelWatch := context.AfterFunc(ctx, func() { canceledAt <- time.Now() })
+	err = cmd.Wait()
+	stopCancelWatch()
+	if stopWatch != nil && stopWatch() {
+		err = errors.Join(err, ErrOOMKilled)
+	}
+	if escalated() || waitDelayExpired(err, canceledAt, cmd.WaitDelay) {
+		err = errors.Join(err, ErrForceKilled)
+	}
+	if opts.Orphans != OrphansIgnore {
+		pids, oerr := handleOrphans(cmd.Process.Pid, opts.Orphans)
+		if oerr != nil {
+			err = errors.Join(err, oerr)
+		} else if len(pids) > 0 && opts.OnOrphans != nil {
+			opts.OnOrphans(pids)
+		}
+	}
+	return finish(err)
+}
+
+// waitDelayExpired reports whether os/exec gave up waiting for a command after
+// its WaitDelay, either for its output to be closed once it exited, or for it
+// to exit once cancelled, given the time it was cancelled, if any.
+func waitDelayExpired(err error, canceledAt <-chan time.Time, waitDelay time.Duration) bool {
+	if errors.Is(err, exec.ErrWaitDelay) {
+		return true
+	}
+	select {
+	case t := <-canceledAt:
+		return waitDelay > 0 && time.Since(t) >= waitDelay
+	default:
+		return false
+	}
+}
+
+// ShellExecutor is an Executor that runs commands through a shell, so that
+// shell features such as pipelines and redirects may be used.
+//
+// The command name and arguments are joined with spaces to form the script
+// passed to the shell, and are otherwise interpreted by the shell verbatim.
+// The script is executed via a local subprocess in the same manner as
+// [CmdExecutor].
+//
+// On Windows, the script is passed to cmd.exe quoted following the
+// conventions of the Microsoft C runtime, which cmd.exe does not itself
+// follow, so scripts containing double quotes may not be interpreted as
+// written. Such scripts are better run through another Shell, such as
+// PowerShell, or via CmdExecutor.
+type ShellExecutor struct {
+	// Shell is the shell binary used to run scripts. If empty, "sh" is used,
+	// or "cmd" on Windows. Scripts are passed to cmd using its /C flag, and to
+	// any other shell using -c.
+	Shell string
+}
+
+// verify ShellExecutor implements the Executor interface
+var _ Executor = ShellExecutor{}
+
+func (se ShellExecutor) Run(ctx context.Context, opts CommandOpts, name string, args ...string) error {
+	shell, flag := shellCommand(runtime.GOOS, se.Shell)
+	script := strings.Join(append([]string{name}, args...), " ")
+	return CmdExecutor{}.Run(ctx, opts, shell, flag, script)
+}
+
+// shellCommand returns the shell to run scripts with on goos, defaulting to
+// the platform shell if empty, along with the flag it takes a script with.
+func shellCommand(goos, shell string) (string, string) {
+	if shell == "" {
+		if goos != "windows" {
+			return "sh", "-c"
+		}
+		shell = "cmd"
+	}
+	if goos == "windows" {
+		base := shell[strings.LastIndexAny(shell, `/\`)+1:]
+		if strings.EqualFold(base, "cmd") || strings.EqualFold(base, "cmd.exe") {
+			return shell, "/C"
+		}
+	}
+	return shell, "-c"
 }
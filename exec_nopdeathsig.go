@@ -0,0 +1,14 @@
+//go:build !(linux || freebsd)
+
+package wut
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// setParentDeathSignal is not supported on this platform.
+func setParentDeathSignal(cmd *exec.Cmd, sig os.Signal) error {
+	return errors.New("wut: parent death signal is not supported on this platform")
+}
@@ -0,0 +1,26 @@
+//go:build !unix && !windows
+
+package wut
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// setProcessGroup is a no-op on platforms without process groups.
+func setProcessGroup(cmd *exec.Cmd) (attach func() error, release func(), err error) {
+	return func() error { return nil }, func() {}, nil
+}
+
+// setGracefulStop is a no-op on platforms without signals, where commands are
+// always killed immediately when cancelled.
+func setGracefulStop(cmd *exec.Cmd, sig os.Signal, grace time.Duration, group bool) (stop func() (escalated bool)) {
+	return func() bool { return false }
+}
+
+// setCredential is not supported on this platform.
+func setCredential(cmd *exec.Cmd, username, group string) error {
+	return errors.New("wut: running commands as another user is not supported on this platform")
+}
@@ -0,0 +1,24 @@
+//go:build linux || freebsd
+
+package wut
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setParentDeathSignal configures cmd to be sent sig if the process that
+// started it exits.
+func setParentDeathSignal(cmd *exec.Cmd, sig os.Signal) error {
+	ssig, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("wut: unsupported parent death signal %v", sig)
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Pdeathsig = ssig
+	return nil
+}
@@ -0,0 +1,41 @@
+//go:build linux || freebsd
+
+package wut
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCmdExecutor_ParentDeathSignal(t *testing.T) {
+	// When re-executed as a helper, start a long running command with a parent
+	// death signal, report its pid, and exit abruptly while it is running.
+	if os.Getenv("WUT_TEST_PDEATHSIG_HELPER") == "1" {
+		opts := CommandOpts{Stdout: os.Stdout, ParentDeathSignal: syscall.SIGKILL}
+		go CmdExecutor{}.Run(context.Background(), opts, "sh", "-c", "echo $$; exec sleep 30")
+		time.Sleep(200 * time.Millisecond)
+		os.Exit(0)
+	}
+
+	helper := exec.Command(os.Args[0], "-test.run=^TestCmdExecutor_ParentDeathSignal$")
+	helper.Env = append(os.Environ(), "WUT_TEST_PDEATHSIG_HELPER=1")
+	helper.WaitDelay = time.Second
+	out, _ := helper.Output()
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatalf("reading command pid from %q: %v", out, err)
+	}
+	t.Cleanup(func() { syscall.Kill(pid, syscall.SIGKILL) })
+
+	time.Sleep(100 * time.Millisecond)
+	if processAlive(pid) {
+		t.Error("command still running after its parent exited")
+	}
+}
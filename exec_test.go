@@ -1,20 +1,25 @@
 package wut
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os/exec"
+	"runtime"
+	"testing"
 	"time"
 )
 
-// A mockExecutor is a mock implementation of the executor interface for testing purposes.
+// A mockExecutor is a mock implementation of the Executor interface for testing purposes.
 type mockExecutor struct {
 	sleep    time.Duration // 1. First, we sleep for the specified duration (simulating processing time)
 	output   string        // 2. Then, we write output to the standard output stream
 	exitcode int           // 3. Finally, we exit with the specified exit code
 }
 
-// verify mockExecutor implements the executor interface
-var _ executor = mockExecutor{}
+// verify mockExecutor implements the Executor interface
+var _ Executor = mockExecutor{}
 
 func (me mockExecutor) Run(ctx context.Context, opts CommandOpts, name string, args ...string) error {
 	select {
@@ -34,3 +39,47 @@ func (me mockExecutor) Run(ctx context.Context, opts CommandOpts, name string, a
 		return nil
 	}
 }
+
+func TestShellExecutor_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test script requires a POSIX shell")
+	}
+
+	var stdout bytes.Buffer
+	opts := CommandOpts{Stdout: &stdout}
+	err := ShellExecutor{}.Run(t.Context(), opts, "echo hello |", "tr a-z A-Z")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := stdout.String(), "HELLO\n"; got != want {
+		t.Errorf("stdout: got %q, want %q", got, want)
+	}
+
+	err = ShellExecutor{}.Run(t.Context(), CommandOpts{}, "exit 3")
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("got %v, want exit code 3", err)
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	tests := []struct {
+		goos, shell     string
+		wantShell, flag string
+	}{
+		{"linux", "", "sh", "-c"},
+		{"linux", "bash", "bash", "-c"},
+		{"windows", "", "cmd", "/C"},
+		{"windows", "CMD.EXE", "CMD.EXE", "/C"},
+		{"windows", `C:\Windows\System32\cmd.exe`, `C:\Windows\System32\cmd.exe`, "/C"},
+		{"windows", "pwsh", "pwsh", "-c"},
+		{"windows", `C:\Program Files\Git\bin\bash.exe`, `C:\Program Files\Git\bin\bash.exe`, "-c"},
+	}
+	for _, tt := range tests {
+		shell, flag := shellCommand(tt.goos, tt.shell)
+		if shell != tt.wantShell || flag != tt.flag {
+			t.Errorf("shellCommand(%q, %q) = %q, %q; want %q, %q",
+				tt.goos, tt.shell, shell, flag, tt.wantShell, tt.flag)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+//go:build unix
+
+package wut
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup configures cmd to run in its own process group, and to kill
+// the entire group when cancelled. The returned attach function must be called
+// once the command has started, and release once it has completed.
+func setProcessGroup(cmd *exec.Cmd) (attach func() error, release func(), err error) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		// a negative pid signals every process in the group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return func() error { return nil }, func() {}, nil
+}
+
+// setGracefulStop configures cmd to be sent sig when cancelled, escalating to
+// SIGKILL if it has not exited after grace. If group is set, the signals are
+// sent to the command's entire process group. The returned stop function must
+// be called once the command has completed, to prevent a pending escalation,
+// and reports whether the command was escalated to SIGKILL.
+func setGracefulStop(cmd *exec.Cmd, sig os.Signal, grace time.Duration, group bool) (stop func() (escalated bool)) {
+	ssig, ok := sig.(syscall.Signal)
+	if !ok {
+		ssig = syscall.SIGTERM
+	}
+
+	var escalation *time.Timer
+	var killed atomic.Bool
+	cmd.Cancel = func() error {
+		pid := cmd.Process.Pid
+		if group {
+			pid = -pid
+		}
+		escalation = time.AfterFunc(grace, func() {
+			killed.Store(true)
+			syscall.Kill(pid, syscall.SIGKILL)
+		})
+		return syscall.Kill(pid, ssig)
+	}
+	// ensure os/exec does not give up waiting on the command prior to escalation
+	cmd.WaitDelay = max(cmd.WaitDelay, grace)
+
+	return func() bool {
+		if escalation != nil {
+			escalation.Stop()
+		}
+		return killed.Load()
+	}
+}
+
+// setCredential configures cmd to run as the given user and group, each of
+// which may be a name or numeric id. If group is empty, the primary group of
+// the user is used, along with the user's supplementary groups. If username
+// is empty, the current user is retained.
+func setCredential(cmd *exec.Cmd, username, group string) error {
+	if username == "" {
+		username = strconv.Itoa(os.Getuid())
+	}
+	u, err := lookupUser(username)
+	if err != nil {
+		return err
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return fmt.Errorf("wut: invalid uid %q for user %s", u.Uid, username)
+	}
+
+	cred := &syscall.Credential{Uid: uint32(uid)}
+	if group != "" {
+		gid, err := lookupGroupID(group)
+		if err != nil {
+			return err
+		}
+		cred.Gid = gid
+	} else {
+		gid, err := strconv.ParseUint(u.Gid, 10, 32)
+		if err != nil {
+			return fmt.Errorf("wut: invalid gid %q for user %s", u.Gid, username)
+		}
+		cred.Gid = uint32(gid)
+		// supplementary groups are best effort, not all platforms support them
+		if gids, err := u.GroupIds(); err == nil {
+			for _, g := range gids {
+				if id, err := strconv.ParseUint(g, 10, 32); err == nil {
+					cred.Groups = append(cred.Groups, uint32(id))
+				}
+			}
+		}
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = cred
+	return nil
+}
+
+func lookupUser(name string) (*user.User, error) {
+	if _, err := strconv.ParseUint(name, 10, 32); err == nil {
+		if u, err := user.LookupId(name); err == nil {
+			return u, nil
+		}
+		// a numeric uid need not exist in the user database
+		return &user.User{Uid: name, Gid: name}, nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("wut: %w", err)
+	}
+	return u, nil
+}
+
+func lookupGroupID(name string) (uint32, error) {
+	if gid, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(gid), nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, fmt.Errorf("wut: %w", err)
+	}
+	gid, err := strconv.ParseUint(g.Gid, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("wut: invalid gid %q for group %s", g.Gid, name)
+	}
+	return uint32(gid), nil
+}
@@ -0,0 +1,242 @@
+//go:build unix
+
+package wut
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCmdExecutor_ProcessGroup(t *testing.T) {
+	for _, pgroup := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ProcessGroup=%v", pgroup), func(t *testing.T) {
+			// The shell spawns a background grandchild, reports its pid, and
+			// waits on it, until the run is cancelled.
+			var stdout bytes.Buffer
+			opts := CommandOpts{Stdout: &stdout, ProcessGroup: pgroup, WaitDelay: 100 * time.Millisecond}
+			ctx, cancel := context.WithTimeout(t.Context(), 500*time.Millisecond)
+			defer cancel()
+			CmdExecutor{}.Run(ctx, opts, "sh", "-c", "sleep 30 & echo $!; wait")
+
+			pid, err := strconv.Atoi(strings.TrimSpace(stdout.String()))
+			if err != nil {
+				t.Fatalf("reading grandchild pid: %v", err)
+			}
+			t.Cleanup(func() { syscall.Kill(pid, syscall.SIGKILL) })
+
+			// allow the kill signal to be delivered
+			time.Sleep(100 * time.Millisecond)
+			if alive := processAlive(pid); alive == pgroup {
+				t.Errorf("grandchild alive: got %v, want %v", alive, !pgroup)
+			}
+		})
+	}
+}
+
+// processAlive reports whether the process with the given pid is running,
+// treating zombie processes as not running.
+func processAlive(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	if runtime.GOOS == "linux" {
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			return false
+		}
+		// the state follows the parenthesized command name
+		if i := bytes.LastIndexByte(stat, ')'); i >= 0 && i+2 < len(stat) {
+			return stat[i+2] != 'Z'
+		}
+	}
+	return true
+}
+
+func TestCmdExecutor_GracePeriod(t *testing.T) {
+	t.Run("graceful exit", func(t *testing.T) {
+		var stdout bytes.Buffer
+		opts := CommandOpts{Stdout: &stdout, ProcessGroup: true, GracePeriod: 5 * time.Second}
+		ctx, cancel := context.WithTimeout(t.Context(), 300*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		CmdExecutor{}.Run(ctx, opts, "sh", "-c", `trap "echo terminated; exit 0" TERM; sleep 30 & wait`)
+		if got := stdout.String(); got != "terminated\n" {
+			t.Errorf("stdout: got %q, want %q", got, "terminated\n")
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("elapsed: got %v, want well under the grace period", elapsed)
+		}
+	})
+
+	t.Run("escalation", func(t *testing.T) {
+		opts := CommandOpts{GracePeriod: 200 * time.Millisecond, StopSignal: syscall.SIGUSR1}
+		ctx, cancel := context.WithTimeout(t.Context(), 300*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := CmdExecutor{}.Run(ctx, opts, "sh", "-c", `trap "" USR1; sleep 2; sleep 2; sleep 2`)
+		if !errors.Is(err, ErrForceKilled) {
+			t.Errorf("got %v, want %v", err, ErrForceKilled)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("elapsed: got %v, want command killed after grace period", elapsed)
+		}
+	})
+}
+
+func TestCmdExecutor_WaitDelay(t *testing.T) {
+	// the command exits, but leaves a child holding its output open
+	var stdout bytes.Buffer
+	opts := CommandOpts{Stdout: &stdout, WaitDelay: 200 * time.Millisecond}
+
+	start := time.Now()
+	err := CmdExecutor{}.Run(t.Context(), opts, "sh", "-c", "sleep 5 & echo started")
+	if !errors.Is(err, ErrForceKilled) || !errors.Is(err, exec.ErrWaitDelay) {
+		t.Errorf("got %v, want %v", err, ErrForceKilled)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("elapsed: got %v, want output closed after WaitDelay", elapsed)
+	}
+	if got := stdout.String(); got != "started\n" {
+		t.Errorf("stdout: got %q, want %q", got, "started\n")
+	}
+}
+
+func TestCmdExecutor_User(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing user requires root privileges")
+	}
+
+	tests := []struct {
+		user, group string
+		want        string
+	}{
+		{user: "65534", want: "65534:65534"},
+		{user: "65534", group: "12345", want: "65534:12345"},
+		{group: "12345", want: "0:12345"},
+	}
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+		opts := CommandOpts{Stdout: &stdout, User: tt.user, Group: tt.group}
+		if err := (CmdExecutor{}).Run(t.Context(), opts, "sh", "-c", "echo $(id -u):$(id -g)"); err != nil {
+			t.Fatalf("user=%q group=%q: unexpected error: %v", tt.user, tt.group, err)
+		}
+		if got := strings.TrimSpace(stdout.String()); got != tt.want {
+			t.Errorf("user=%q group=%q: got %s, want %s", tt.user, tt.group, got, tt.want)
+		}
+	}
+
+	err := CmdExecutor{}.Run(t.Context(), CommandOpts{User: "no-such-user-wut"}, "true")
+	if err == nil {
+		t.Error("unknown user: expected error, got nil")
+	}
+}
+
+func TestCmdExecutor_MaxRSS(t *testing.T) {
+	opts := CommandOpts{
+		Resources: Resources{MaxRSS: 16 << 20, RSSPollInterval: 20 * time.Millisecond},
+	}
+
+	// the memory is allocated by a grandchild, which must be accounted for
+	script := `sh -c 'x=a; i=0; while [ $i -lt 26 ]; do x="$x$x"; i=$((i+1)); done; sleep 10'`
+	start := time.Now()
+	err := CmdExecutor{}.Run(t.Context(), opts, "sh", "-c", script)
+	if !errors.Is(err, ErrOOMKilled) {
+		t.Errorf("got error %v, want %v", err, ErrOOMKilled)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("command not killed promptly, took %v", elapsed)
+	}
+
+	if err := (CmdExecutor{}).Run(t.Context(), opts, "true"); err != nil {
+		t.Errorf("within limit: unexpected error: %v", err)
+	}
+}
+
+func TestCmdExecutor_Orphans(t *testing.T) {
+	for _, policy := range []OrphanPolicy{OrphansReport, OrphansKill} {
+		t.Run(policy.String(), func(t *testing.T) {
+			var orphans []int
+			opts := CommandOpts{
+				ProcessGroup: true,
+				Orphans:      policy,
+				OnOrphans:    func(pids []int) { orphans = pids },
+			}
+			// the background child inherits no output pipes, so Run returns
+			// as soon as the shell exits
+			if err := (CmdExecutor{}).Run(t.Context(), opts, "sh", "-c", "sleep 10 >/dev/null 2>&1 &"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(orphans) != 1 {
+				t.Fatalf("got orphans %v, want one", orphans)
+			}
+			t.Cleanup(func() { syscall.Kill(orphans[0], syscall.SIGKILL) })
+
+			alive := processAlive(orphans[0])
+			if policy == OrphansKill {
+				// the process is killed asynchronously
+				for range 100 {
+					if alive = processAlive(orphans[0]); !alive {
+						break
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+			}
+			if want := policy == OrphansReport; alive != want {
+				t.Errorf("orphan alive = %v, want %v", alive, want)
+			}
+		})
+	}
+
+	t.Run("none", func(t *testing.T) {
+		called := false
+		opts := CommandOpts{
+			ProcessGroup: true,
+			Orphans:      OrphansReport,
+			OnOrphans:    func([]int) { called = true },
+		}
+		if err := (CmdExecutor{}).Run(t.Context(), opts, "true"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if called {
+			t.Error("OnOrphans called with no leftover processes")
+		}
+	})
+
+	t.Run("runner", func(t *testing.T) {
+		r := NewRunner(t.Context(), "sh", "-c", "sleep 10 >/dev/null 2>&1 &")
+		r.SetLogger(slog.New(slog.DiscardHandler))
+		r.CommandOptions.ProcessGroup = true
+		r.CommandOptions.Orphans = OrphansKill
+		var attempt Attempt
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptEnd {
+				attempt = e.Attempt
+			}
+		})
+		if err := r.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(attempt.Orphans) != 1 {
+			t.Errorf("got attempt orphans %v, want one", attempt.Orphans)
+		}
+	})
+
+	t.Run("requires process group", func(t *testing.T) {
+		if err := (CmdExecutor{}).Run(t.Context(), CommandOpts{Orphans: OrphansReport}, "true"); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
@@ -0,0 +1,50 @@
+//go:build windows
+
+package wut
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// setProcessGroup configures cmd to be assigned to a new Job Object, and to
+// terminate all processes in the job when cancelled. The returned attach
+// function must be called once the command has started, and release once it
+// has completed.
+//
+// As the command can only be assigned to the job once it has started, any
+// processes it spawns before then are not part of the job.
+func setProcessGroup(cmd *exec.Cmd) (attach func() error, release func(), err error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	cmd.Cancel = func() error {
+		return windows.TerminateJobObject(job, 1)
+	}
+	attach = func() error {
+		proc, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+		if err != nil {
+			return err
+		}
+		defer windows.CloseHandle(proc)
+		return windows.AssignProcessToJobObject(job, proc)
+	}
+	release = func() { windows.CloseHandle(job) }
+	return attach, release, nil
+}
+
+// setGracefulStop is a no-op on platforms without signals, where commands are
+// always killed immediately when cancelled.
+func setGracefulStop(cmd *exec.Cmd, sig os.Signal, grace time.Duration, group bool) (stop func() (escalated bool)) {
+	return func() bool { return false }
+}
+
+// setCredential is not supported on this platform.
+func setCredential(cmd *exec.Cmd, username, group string) error {
+	return errors.New("wut: running commands as another user is not supported on this platform")
+}
@@ -0,0 +1,53 @@
+package wut
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrPermanentFailure is wrapped by the error returned by Run when it stops
+// after an attempt failed in a way which retrying will not fix, such as by
+// exiting with one of Runner.AbortCodes or none of Runner.RetryCodes, along
+// with the error of the attempt. See also Runner.RetryTimeoutsOnly and
+// Runner.FailOnOutput.
+var ErrPermanentFailure = errors.New("wut: permanent failure")
+
+// permanent reports whether the failed attempt a should stop the Runner
+// rather than be retried, as determined by FailOnOutput, AbortCodes,
+// RetryCodes, and RetryTimeoutsOnly.
+func (r *Runner) permanent(a Attempt) bool {
+	if a.Err == nil || a.Interrupted {
+		return false
+	}
+	if exitedWith(a, r.AbortCodes) || errors.Is(a.Err, ErrFatalOutput) {
+		return true
+	}
+	if len(r.RetryCodes) == 0 && !r.RetryTimeoutsOnly {
+		return false
+	}
+	return !exitedWith(a, r.RetryCodes) && !timedOut(a)
+}
+
+// retryRegardless clears the settings which stop r following a permanent
+// failure, for running the command regardless of outcome. It must only be
+// called on a snapshot or derived copy, never on a Runner of the caller.
+func (r *Runner) retryRegardless() {
+	r.UntilFailure, r.FailOnOutput = false, nil
+	r.AbortCodes, r.RetryCodes, r.RetryTimeoutsOnly = nil, nil, false
+}
+
+// exitedWith reports whether the failed attempt a exited of its own accord
+// with one of codes, rather than being killed or failing to start.
+func exitedWith(a Attempt, codes []int) bool {
+	if a.Err == nil || len(codes) == 0 || a.Interrupted || timedOut(a) {
+		return false
+	}
+	code := a.ExitCode()
+	return code >= 0 && slices.Contains(codes, code)
+}
+
+// timedOut reports whether the attempt a was cancelled for exceeding
+// Runner.ProcessTimeout or Runner.IdleTimeout.
+func timedOut(a Attempt) bool {
+	return errors.Is(a.Err, ErrProcessTimeout) || errors.Is(a.Err, ErrIdleTimeout)
+}
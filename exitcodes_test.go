@@ -0,0 +1,65 @@
+package wut
+
+import (
+	"io"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestRunner_SuccessCodes(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "diff")
+		r.SetExecutor(&scriptedExecutor{outputs: []string{"", ""}, exitcode: []int{2, 1}})
+		r.CommandOptions.Stdout = io.Discard
+		r.SuccessCodes = []int{1}
+		var codes []int
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptEnd {
+				codes = append(codes, e.Attempt.ExitCode())
+			}
+		})
+
+		runAssert(t, r, runnerExpectedResults{err: nil, runs: 2})
+		if len(codes) != 2 || codes[0] != 2 || codes[1] != 0 {
+			t.Errorf("exit codes: got %v, want [2 0]", codes)
+		}
+	})
+}
+
+func TestRunner_AbortCodes(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "sh")
+		r.SetExecutor(&scriptedExecutor{outputs: []string{"", "", ""}, exitcode: []int{1, 127, 0}})
+		r.CommandOptions.Stdout = io.Discard
+		r.AbortCodes = []int{126, 127}
+
+		runAssert(t, r, runnerExpectedResults{err: ErrPermanentFailure, runs: 2})
+	})
+}
+
+func TestRunner_RetryCodes(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "fetch")
+		r.SetExecutor(&scriptedExecutor{outputs: []string{"", "", "", ""}, exitcode: []int{75, 75, 1, 0}})
+		r.CommandOptions.Stdout = io.Discard
+		r.RetryCodes = []int{75}
+
+		runAssert(t, r, runnerExpectedResults{err: ErrPermanentFailure, runs: 3})
+	})
+}
+
+func TestRunner_RetryTimeoutsOnly(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{sleep: 2 * time.Second, exitcode: 1})
+		r.ProcessTimeout = time.Second
+		r.RetryTimeoutsOnly = true
+		r.MaxRuns = 3
+		runAssert(t, r, runnerExpectedResults{err: errMaxRunsCompleted, runs: 3, elapsedTotal: 3 * time.Second})
+
+		r = NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1})
+		r.ProcessTimeout = time.Second
+		r.RetryTimeoutsOnly = true
+		runAssert(t, r, runnerExpectedResults{err: ErrPermanentFailure, runs: 1})
+	})
+}
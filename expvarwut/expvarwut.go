@@ -0,0 +1,70 @@
+// Package expvarwut publishes the counters and last attempt of a [wut.Runner]
+// via [expvar], a dependency-free way to observe a Runner embedded in a Go
+// service.
+package expvarwut
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// Vars is a [wut.Metrics] adapter publishing the measurements of a Runner as
+// an [expvar.Map] with the following keys:
+//
+//   - attempts, successes, failures: the number of attempts by result
+//   - consecutive_failures: the number of failed attempts since the last
+//     successful one
+//   - state: the current [wut.RunnerState] of the Runner
+//   - last_result: the result of the most recent attempt, if any
+//   - last_duration_seconds: the duration of the most recent attempt
+type Vars struct {
+	*expvar.Map
+
+	attempts, successes, failures, consecutive expvar.Int
+	state, lastResult                          expvar.String
+	lastDuration                               expvar.Float
+}
+
+// verify Vars implements the wut.Metrics interface
+var _ wut.Metrics = (*Vars)(nil)
+
+// Publish publishes a new Vars under name. Provide it to a Runner with
+// [wut.Runner.SetMetrics]. As with [expvar.Publish], Publish panics if name is
+// already in use.
+func Publish(name string) *Vars {
+	v := &Vars{Map: expvar.NewMap(name)}
+	v.state.Set(wut.RunnerStateIdle.String())
+	v.Set("attempts", &v.attempts)
+	v.Set("successes", &v.successes)
+	v.Set("failures", &v.failures)
+	v.Set("consecutive_failures", &v.consecutive)
+	v.Set("state", &v.state)
+	v.Set("last_result", &v.lastResult)
+	v.Set("last_duration_seconds", &v.lastDuration)
+	return v
+}
+
+// IncAttempt implements [wut.Metrics].
+func (v *Vars) IncAttempt(result wut.AttemptResult) {
+	v.attempts.Add(1)
+	if result == wut.AttemptFailure {
+		v.failures.Add(1)
+		v.consecutive.Add(1)
+	} else {
+		v.successes.Add(1)
+		v.consecutive.Set(0)
+	}
+	v.lastResult.Set(string(result))
+}
+
+// ObserveDuration implements [wut.Metrics].
+func (v *Vars) ObserveDuration(d time.Duration) {
+	v.lastDuration.Set(d.Seconds())
+}
+
+// SetState implements [wut.Metrics].
+func (v *Vars) SetState(s wut.RunnerState) {
+	v.state.Set(s.String())
+}
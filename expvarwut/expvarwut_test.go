@@ -0,0 +1,48 @@
+package expvarwut
+
+import (
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/mroth/wut"
+	"github.com/mroth/wut/wuttest"
+)
+
+func TestPublish(t *testing.T) {
+	v := Publish("wut_test")
+	synctest.Test(t, func(t *testing.T) {
+		r := wut.NewRunner(t.Context(), "cmd")
+		r.SetLogger(slog.New(slog.DiscardHandler))
+		r.SetExecutor(wuttest.NewExecutor(
+			wuttest.Outcome{},
+			wuttest.Outcome{Sleep: 1500 * time.Millisecond, ExitCode: 3},
+		))
+		r.ContinueOnSuccess = true
+		r.MaxRuns = 2
+		r.SetMetrics(v)
+		r.Run()
+	})
+
+	var got struct {
+		Attempts            int     `json:"attempts"`
+		Successes           int     `json:"successes"`
+		Failures            int     `json:"failures"`
+		ConsecutiveFailures int     `json:"consecutive_failures"`
+		State               string  `json:"state"`
+		LastResult          string  `json:"last_result"`
+		LastDuration        float64 `json:"last_duration_seconds"`
+	}
+	if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", v, err)
+	}
+	if got.Attempts != 2 || got.Successes != 1 || got.Failures != 1 || got.ConsecutiveFailures != 1 {
+		t.Errorf("got counters %+v, want 2 attempts, 1 success, 1 failure, 1 consecutive", got)
+	}
+	if got.State != "errored" || got.LastResult != "failure" || got.LastDuration != 1.5 {
+		t.Errorf("got state %q, last result %q and duration %v, want errored, failure, and 1.5",
+			got.State, got.LastResult, got.LastDuration)
+	}
+}
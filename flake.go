@@ -0,0 +1,182 @@
+package wut
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// defaultFlakeCaptureLimit is the CaptureLimit applied by [Runner.Flakes] if
+// output capture has not otherwise been enabled.
+const defaultFlakeCaptureLimit = 64 * 1024
+
+// FlakeReport summarizes the outcomes of repeatedly running a command, for
+// quantifying how flaky it is.
+type FlakeReport struct {
+	Runs     uint // total number of runs
+	Passes   uint // number of successful runs
+	Failures uint // number of failed runs
+
+	// LongestFailureStreak is the largest number of consecutive failed runs,
+	// which can indicate whether failures occur independently or in clusters.
+	LongestFailureStreak uint
+
+	// Signatures groups the failed runs by their failure signature, most
+	// frequent first.
+	Signatures []FailureSignature
+}
+
+// FailureSignature describes a distinct kind of failure observed in a
+// FlakeReport.
+type FailureSignature struct {
+	// Signature identifies the failure, derived from the exit code and final
+	// line of output, with digits in the output masked so that incidental details such as
+	// timestamps or ports do not distinguish otherwise identical failures.
+	Signature string
+	Count     uint   // number of runs failing with this signature
+	Attempts  []uint // attempt numbers of the runs failing with this signature
+	Output    []byte // captured output of the first run failing with this signature
+}
+
+// FailureRate returns the fraction of runs that failed.
+func (fr FlakeReport) FailureRate() float64 {
+	if fr.Runs == 0 {
+		return 0
+	}
+	return float64(fr.Failures) / float64(fr.Runs)
+}
+
+// String returns a human readable summary of the report.
+func (fr FlakeReport) String() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "runs: %d, passed: %d, failed: %d (%.1f%%), longest failure streak: %d\n",
+		fr.Runs, fr.Passes, fr.Failures, fr.FailureRate()*100, fr.LongestFailureStreak)
+	for _, sig := range fr.Signatures {
+		fmt.Fprintf(&s, "%6dx %s\n", sig.Count, sig.Signature)
+	}
+	return s.String()
+}
+
+// Flakes executes the command the given number of times regardless of outcome,
+// and reports on the successes and failures observed.
+//
+// Like [Runner.Benchmark], Flakes overrides MaxRuns and ContinueOnSuccess for
+// the duration of the call, leaving the Runner itself unchanged, and the
+// configured RetryDelay is applied between each run. Output is captured to
+// determine failure signatures; if CaptureLimit is unset, a default limit of
+// 64KiB per run is used. If the Runner is stopped early via its context, the
+// report so far is returned along with the reason for stopping.
+func (r *Runner) Flakes(runs uint) (FlakeReport, error) {
+	var (
+		report FlakeReport
+		streak uint
+		sigs   signatureSet
+	)
+	err := r.runAll(runs, defaultFlakeCaptureLimit, func(a Attempt) {
+		report.Runs++
+		if a.Err == nil {
+			report.Passes++
+			streak = 0
+			return
+		}
+		report.Failures++
+		streak++
+		report.LongestFailureStreak = max(report.LongestFailureStreak, streak)
+		sigs.add(a, a.Num)
+	})
+
+	report.Signatures = sigs.sorted()
+	return report, err
+}
+
+// runAll executes the command the given number of times regardless of
+// outcome, calling fn with each completed attempt. If captureLimit is greater
+// than zero, it is used as the CaptureLimit if that is unset. As with
+// [Runner.RunSchedule], a snapshot of r is run, so that these overrides do not
+// outlive the call.
+func (r *Runner) runAll(runs uint, captureLimit int, fn func(Attempt)) error {
+	if !r.shared.running.CompareAndSwap(false, true) {
+		return errRedundantStartCall
+	}
+	defer r.shared.running.Store(false)
+	r.shared.runsCompleted.Store(0)
+
+	// the analysis runs neither resume from nor overwrite the checkpoint of
+	// the Runner, are not limited to one Runner holding its lock at once, and
+	// are not skipped by, nor recorded in, its SuccessMarker
+	snap := *r
+	snap.StateFile = ""
+	snap.Lock, snap.LockFile = nil, ""
+	snap.SuccessMarker = ""
+	snap.MaxRuns = runs
+	snap.ContinueOnSuccess = true
+	snap.retryRegardless()
+	if snap.CaptureLimit <= 0 {
+		snap.CaptureLimit = captureLimit
+	}
+	snap.observers = append(slices.Clip(r.observers), func(e Event) {
+		if e.Kind == EventAttemptEnd {
+			fn(e.Attempt)
+		}
+	})
+
+	err := snap.run()
+	if errors.Is(err, errMaxRunsCompleted) {
+		err = nil
+	}
+	return err
+}
+
+// signatureSet groups failed attempts by their failure signature.
+// The zero value is ready to use.
+type signatureSet map[string]*FailureSignature
+
+// add records the failed attempt a under the given attempt number.
+func (ss *signatureSet) add(a Attempt, num uint) {
+	if *ss == nil {
+		*ss = make(signatureSet)
+	}
+	key := failureSignature(a)
+	sig, ok := (*ss)[key]
+	if !ok {
+		sig = &FailureSignature{Signature: key, Output: a.Output}
+		(*ss)[key] = sig
+	}
+	sig.Count++
+	sig.Attempts = append(sig.Attempts, num)
+}
+
+// sorted returns the recorded signatures, most frequent first.
+func (ss signatureSet) sorted() []FailureSignature {
+	var sigs []FailureSignature
+	for _, sig := range ss {
+		sigs = append(sigs, *sig)
+	}
+	slices.SortFunc(sigs, func(a, b FailureSignature) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Attempts[0], b.Attempts[0]))
+	})
+	return sigs
+}
+
+var digitsRE = regexp.MustCompile(`[0-9]+`)
+
+// failureSignature derives the failure signature of a failed attempt.
+func failureSignature(a Attempt) string {
+	sig := fmt.Sprintf("exit code %d", a.ExitCode())
+	if a.ExitCode() == -1 {
+		sig = digitsRE.ReplaceAllString(a.Err.Error(), "N")
+	}
+
+	output := bytes.TrimRight(a.Output, " \t\r\n")
+	if i := bytes.LastIndexByte(output, '\n'); i >= 0 {
+		output = output[i+1:]
+	}
+	if line := strings.TrimSpace(string(output)); line != "" {
+		sig += ": " + digitsRE.ReplaceAllString(line, "N")
+	}
+	return sig
+}
@@ -0,0 +1,153 @@
+package wut
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+// scriptedExecutor is an Executor returning a scripted sequence of outputs
+// and exit codes, one per run.
+type scriptedExecutor struct {
+	runs     int
+	outputs  []string
+	exitcode []int
+}
+
+type exitCodeError int
+
+func (e exitCodeError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitCodeError) ExitCode() int { return int(e) }
+
+func (se *scriptedExecutor) Run(ctx context.Context, opts CommandOpts, name string, args ...string) error {
+	i := se.runs
+	se.runs++
+	opts.Stdout.Write([]byte(se.outputs[i]))
+	if se.exitcode[i] != 0 {
+		return exitCodeError(se.exitcode[i])
+	}
+	return nil
+}
+
+func TestRunner_Flakes(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "flaky")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"ok\n", "conn refused on port 5432\n", "ok\n", "timeout after 31s\n", "conn refused on port 5433\n", "ok\n"},
+			exitcode: []int{0, 1, 0, 2, 1, 0},
+		})
+
+		report, err := r.Flakes(6)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.Runs != 6 || report.Passes != 3 || report.Failures != 3 {
+			t.Errorf("got %d runs, %d passes, %d failures, want 6, 3, 3", report.Runs, report.Passes, report.Failures)
+		}
+		if report.FailureRate() != 0.5 {
+			t.Errorf("failure rate: got %v, want 0.5", report.FailureRate())
+		}
+		if report.LongestFailureStreak != 2 {
+			t.Errorf("longest failure streak: got %d, want 2", report.LongestFailureStreak)
+		}
+
+		if len(report.Signatures) != 2 {
+			t.Fatalf("got %d signatures, want 2: %v", len(report.Signatures), report.Signatures)
+		}
+		first := report.Signatures[0]
+		if first.Signature != "exit code 1: conn refused on port N" || first.Count != 2 || !slices.Equal(first.Attempts, []uint{2, 5}) {
+			t.Errorf("first signature: got %+v", first)
+		}
+		if string(first.Output) != "conn refused on port 5432\n" {
+			t.Errorf("representative output: got %q", first.Output)
+		}
+	})
+
+	t.Run("runner unchanged", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			r := NewRunner(t.Context(), "flaky")
+			r.SetExecutor(&scriptedExecutor{outputs: make([]string, 4), exitcode: []int{1, 0, 1, 1}})
+			r.MaxRuns = 1
+			r.UntilFailure = true
+			for range 2 {
+				report, err := r.Flakes(2)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if report.Runs != 2 || report.Signatures[0].Attempts[0] != 1 {
+					t.Errorf("got %d runs, first failing attempt %d, want 2, 1", report.Runs, report.Signatures[0].Attempts[0])
+				}
+			}
+			if r.MaxRuns != 1 || r.ContinueOnSuccess || !r.UntilFailure || r.CaptureLimit != 0 || len(r.observers) != 0 {
+				t.Error("runner configuration changed")
+			}
+			if got := r.RunsCompleted(); got != 2 {
+				t.Errorf("runs completed: got %d, want 2", got)
+			}
+		})
+	})
+
+	t.Run("state file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "state.json")
+		synctest.Test(t, func(t *testing.T) {
+			// leave a checkpoint of two failed attempts
+			ctx, cancel := context.WithTimeout(t.Context(), 15*time.Second)
+			defer cancel()
+			r := NewRunnerWithExecutor(ctx, mockExecutor{exitcode: 1})
+			r.RetryDelay = 10 * time.Second
+			r.StateFile = path
+			r.Run()
+			saved, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r = NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1})
+			r.StateFile = path
+			report, err := r.Flakes(3)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if report.Runs != 3 || !slices.Equal(report.Signatures[0].Attempts, []uint{1, 2, 3}) {
+				t.Errorf("got %d runs, attempts %v, want 3 runs, attempts [1 2 3]", report.Runs, report.Signatures[0].Attempts)
+			}
+			if got, err := os.ReadFile(path); err != nil || !bytes.Equal(got, saved) {
+				t.Errorf("state file changed: got %q, %v, want %q", got, err, saved)
+			}
+		})
+	})
+
+	t.Run("success marker", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "ok")
+		if err := os.WriteFile(marker, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		synctest.Test(t, func(t *testing.T) {
+			r := NewRunnerWithExecutor(t.Context(), mockExecutor{})
+			r.SuccessMarker = marker
+			report, err := r.Flakes(3)
+			if err != nil || report.Runs != 3 {
+				t.Errorf("got %d runs, error %v, want 3 runs", report.Runs, err)
+			}
+		})
+	})
+
+	t.Run("stopped early", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			cancel()
+			r := NewRunnerWithExecutor(ctx, mockExecutor{})
+			report, err := r.Flakes(10)
+			if !errors.Is(err, context.Canceled) || report.Runs >= 10 {
+				t.Errorf("got %v with %d runs, want %v with fewer than 10 runs", err, report.Runs, context.Canceled)
+			}
+		})
+	})
+}
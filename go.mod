@@ -1,10 +1,10 @@
 module github.com/mroth/wut
 
-go 1.25
-
-require github.com/rogpeppe/go-internal v1.15.0
+go 1.25.0
 
 require (
-	golang.org/x/sys v0.26.0 // indirect
-	golang.org/x/tools v0.26.0 // indirect
+	github.com/rogpeppe/go-internal v1.15.0
+	golang.org/x/sys v0.47.0
 )
+
+require golang.org/x/tools v0.47.0 // indirect
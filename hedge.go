@@ -0,0 +1,144 @@
+package wut
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// defaultHedgeOutputLimit is the number of bytes of output recorded for each
+// copy of a hedged attempt, unless OutputMaxBytes or CaptureLimit is larger.
+const defaultHedgeOutputLimit = 1 << 20
+
+// runHedged executes the command for attempt num as configured by opts,
+// starting a second copy concurrently if the first has not completed after
+// HedgeDelay, and returns the error of the first copy to succeed, or else of
+// the last to fail. The output of each copy is recorded, and only that of the
+// copy whose error is returned is written to opts. Only the most recent output
+// of each copy is recorded, up to the larger of OutputMaxBytes and
+// CaptureLimit, or else defaultHedgeOutputLimit.
+func (r *Runner) runHedged(ctx context.Context, opts CommandOpts, num uint) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		err    error
+		output *recordedOutput
+	}
+	results := make(chan result, 2)
+	limit := cmp.Or(max(r.OutputMaxBytes, r.CaptureLimit), defaultHedgeOutputLimit)
+	start := func(opts CommandOpts) {
+		output := &recordedOutput{limit: limit}
+		if opts.Stdout != nil {
+			opts.Stdout = output.writer(false)
+		}
+		if opts.Stderr != nil {
+			opts.Stderr = output.writer(true)
+		}
+		go func() {
+			results <- result{r.executor.Run(ctx, opts, r.path, r.args...), output}
+		}()
+	}
+
+	start(opts)
+	hedge := r.clock.NewTimer(r.HedgeDelay)
+	defer hedge.Stop()
+	running := 1
+	var final *result
+	for running > 0 {
+		select {
+		case <-hedge.C():
+			r.log(slog.LevelInfo, "Starting hedged copy", "attempt", num, "after", r.HedgeDelay)
+			dup := opts
+			dup.Stdin = nil // already being consumed by the first copy
+			start(dup)
+			running++
+		case res := <-results:
+			running--
+			if final == nil || final.err != nil {
+				final = &res
+			}
+			if res.err == nil {
+				cancel() // the other copy, if any, is no longer needed
+			}
+		}
+	}
+	final.output.replay(opts.Stdout, opts.Stderr)
+	return final.err
+}
+
+// recordedOutput records the output written to the stdout and stderr of a
+// command, in order, so that it may be written later, byte for byte. Only the
+// most recent limit bytes are retained.
+type recordedOutput struct {
+	mu      sync.Mutex
+	limit   int
+	size    int   // bytes retained in chunks
+	omitted int64 // bytes dropped to stay within limit
+	chunks  []recordedChunk
+}
+
+type recordedChunk struct {
+	stderr bool
+	p      []byte
+}
+
+// writer returns a writer recording the output written to it, as that of
+// stderr if set, and otherwise stdout.
+func (ro *recordedOutput) writer(stderr bool) io.Writer {
+	return recordedWriter{ro, stderr}
+}
+
+// replay writes the recorded output to stdout and stderr, preceded by a
+// marker if any output was dropped, in the style of LimitWriter.
+func (ro *recordedOutput) replay(stdout, stderr io.Writer) {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+	if ro.omitted > 0 {
+		if w := cmp.Or(stdout, stderr); w != nil {
+			fmt.Fprintf(w, "... [%d bytes omitted] ...\n", ro.omitted)
+		}
+	}
+	for _, c := range ro.chunks {
+		w := stdout
+		if c.stderr {
+			w = stderr
+		}
+		if w != nil {
+			w.Write(c.p)
+		}
+	}
+}
+
+type recordedWriter struct {
+	ro     *recordedOutput
+	stderr bool
+}
+
+func (rw recordedWriter) Write(p []byte) (int, error) {
+	ro := rw.ro
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+
+	n := len(p)
+	if len(p) > ro.limit {
+		ro.omitted += int64(len(p) - ro.limit)
+		p = p[len(p)-ro.limit:]
+	}
+	ro.chunks = append(ro.chunks, recordedChunk{rw.stderr, append([]byte(nil), p...)})
+	ro.size += len(p)
+	for ro.size > ro.limit {
+		c := &ro.chunks[0]
+		drop := min(ro.size-ro.limit, len(c.p))
+		c.p = c.p[drop:]
+		ro.size -= drop
+		ro.omitted += int64(drop)
+		if len(c.p) == 0 {
+			ro.chunks = ro.chunks[1:]
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,102 @@
+package wut
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+// hedgeExecutor is an Executor whose nth call runs for the nth duration and
+// then fails if the nth entry of fail is set, writing which call it was.
+type hedgeExecutor struct {
+	mu    sync.Mutex
+	calls int
+	sleep []time.Duration
+	fail  []bool
+}
+
+func (he *hedgeExecutor) Run(ctx context.Context, opts CommandOpts, name string, args ...string) error {
+	he.mu.Lock()
+	n := he.calls
+	he.calls++
+	he.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(he.sleep[n]):
+	}
+	fmt.Fprintf(opts.Stdout, "call %d\n", n+1)
+	if he.fail[n] {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestRunner_HedgeDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		sleep      []time.Duration
+		fail       []bool
+		wantErr    error
+		wantCalls  int
+		wantOutput string
+		elapsed    time.Duration
+	}{
+		{"completes before hedging", []time.Duration{time.Second}, []bool{false}, nil, 1, "call 1\n", time.Second},
+		{"fails before hedging", []time.Duration{time.Second}, []bool{true}, errMaxRunsCompleted, 1, "call 1\n", time.Second},
+		{"hedged copy wins", []time.Duration{time.Minute, time.Second}, []bool{false, false}, nil, 2, "call 2\n", 6 * time.Second},
+		{"first copy wins", []time.Duration{6 * time.Second, 5 * time.Second}, []bool{false, false}, nil, 2, "call 1\n", 6 * time.Second},
+		{"first copy fails", []time.Duration{6 * time.Second, 5 * time.Second}, []bool{true, false}, nil, 2, "call 2\n", 10 * time.Second},
+		{"both fail", []time.Duration{6 * time.Second, 5 * time.Second}, []bool{true, true}, errMaxRunsCompleted, 2, "call 2\n", 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				he := &hedgeExecutor{sleep: tt.sleep, fail: tt.fail}
+				var out strings.Builder
+				r := NewRunner(t.Context(), "fetch")
+				r.SetExecutor(he)
+				r.CommandOptions.Stdout = &out
+				r.HedgeDelay = 5 * time.Second
+				r.MaxRuns = 1
+
+				start := time.Now()
+				if err := r.Run(); !errors.Is(err, tt.wantErr) {
+					t.Errorf("error: got %v, want %v", err, tt.wantErr)
+				}
+				if elapsed := time.Since(start); elapsed != tt.elapsed {
+					t.Errorf("elapsed: got %v, want %v", elapsed, tt.elapsed)
+				}
+				if he.calls != tt.wantCalls {
+					t.Errorf("calls: got %d, want %d", he.calls, tt.wantCalls)
+				}
+				if got := out.String(); got != tt.wantOutput {
+					t.Errorf("output: got %q, want %q", got, tt.wantOutput)
+				}
+			})
+		})
+	}
+}
+
+func TestRecordedOutput_Limit(t *testing.T) {
+	ro := &recordedOutput{limit: 8}
+	io.WriteString(ro.writer(false), "out 1\n")
+	io.WriteString(ro.writer(true), "err 1\n")
+	io.WriteString(ro.writer(false), "out 2\n")
+
+	var stdout, stderr strings.Builder
+	ro.replay(&stdout, &stderr)
+	if got, want := stdout.String(), "... [10 bytes omitted] ...\nout 2\n"; got != want {
+		t.Errorf("stdout: got %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), "1\n"; got != want {
+		t.Errorf("stderr: got %q, want %q", got, want)
+	}
+}
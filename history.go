@@ -0,0 +1,136 @@
+package wut
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// History is a persistent, append-only record of the runs of a Runner, stored
+// as a file of JSON lines, so that the behavior of a recurring job can be
+// inspected over time, across invocations of the process running it.
+//
+// To record runs, register the Record method of a History as an observer:
+//
+//	history := wut.OpenHistory("/var/lib/myjob/history.jsonl")
+//	runner.Observe(history.Record)
+//
+// Each run is appended as a [RunRecord] once it ends. History is safe for
+// concurrent use, and as each record is written with a single append, several
+// processes may share the same file.
+type History struct {
+	path string
+
+	mu       sync.Mutex
+	run      *RunRecord // run in progress, if any
+	lastCode int        // exit code of the last attempt of the run in progress
+	err      error
+}
+
+// RunRecord is the JSON object recorded by a History for each run.
+type RunRecord struct {
+	Start    time.Time `json:"start"`
+	Duration float64   `json:"duration_seconds"`
+	Attempts uint      `json:"attempts"`
+	ExitCode int       `json:"exit_code"`       // exit code of the final attempt, see Attempt.ExitCode
+	Error    string    `json:"error,omitempty"` // reason the run stopped, empty on success
+}
+
+// Succeeded reports whether the run ended in success.
+func (rr RunRecord) Succeeded() bool {
+	return rr.Error == ""
+}
+
+// OpenHistory returns a History stored in the file at path, which is created
+// on the first record if it does not exist.
+func OpenHistory(path string) *History {
+	return &History{path: path}
+}
+
+// Record processes an event emitted by a Runner.
+func (h *History) Record(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch e.Kind {
+	case EventRunStart:
+		h.run, h.lastCode = &RunRecord{Start: e.Time}, 0
+	case EventAttemptEnd:
+		if h.run != nil {
+			h.run.Attempts++
+			h.lastCode = e.Attempt.ExitCode()
+		}
+	case EventRunEnd:
+		if h.run == nil {
+			return
+		}
+		rec := *h.run
+		h.run = nil
+		rec.Duration = e.Time.Sub(rec.Start).Seconds()
+		rec.ExitCode = h.lastCode
+		if e.Err != nil {
+			rec.Error = e.Err.Error()
+		}
+		if err := h.append(rec); err != nil && h.err == nil {
+			h.err = err
+		}
+	}
+}
+
+// append writes rec as a line to the end of the history file.
+func (h *History) append(rec RunRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return errors.Join(err, f.Close())
+}
+
+// Err returns the first error encountered writing a record, if any.
+func (h *History) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+// Recent returns up to the n most recent runs recorded in the history, oldest
+// first, or all runs if n is not greater than zero. A missing history file
+// has no runs.
+func (h *History) Recent(n int) ([]RunRecord, error) {
+	f, err := os.Open(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var recs []RunRecord
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec RunRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", h.path, line, err)
+		}
+		if recs = append(recs, rec); n > 0 && len(recs) > n {
+			recs = recs[1:]
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return recs, nil
+}
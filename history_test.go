@@ -0,0 +1,54 @@
+package wut
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	synctest.Test(t, func(t *testing.T) {
+		h := OpenHistory(path)
+		if recs, err := h.Recent(0); err != nil || len(recs) != 0 {
+			t.Fatalf("got %v, %v for a missing file, want no runs", recs, err)
+		}
+
+		// a failing run, then a successful one
+		r := NewRunner(t.Context(), "job")
+		r.SetExecutor(&scriptedExecutor{outputs: []string{"", ""}, exitcode: []int{3, 3}})
+		r.CommandOptions.Stdout = io.Discard
+		r.MaxRuns = 2
+		r.RetryDelay = time.Second
+		r.Observe(h.Record)
+		r.Run()
+
+		r = NewRunnerWithExecutor(t.Context(), mockExecutor{sleep: time.Second})
+		r.Observe(h.Record)
+		r.Run()
+
+		if err := h.Err(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		recs, err := h.Recent(0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(recs) != 2 {
+			t.Fatalf("got %d runs, want 2", len(recs))
+		}
+		if first := recs[0]; first.Succeeded() || first.Attempts != 2 || first.ExitCode != 3 || first.Duration != 2 {
+			t.Errorf("first run: got %+v", first)
+		}
+		if second := recs[1]; !second.Succeeded() || second.Attempts != 1 || second.ExitCode != 0 || second.Duration != 1 {
+			t.Errorf("second run: got %+v", second)
+		}
+
+		recs, err = h.Recent(1)
+		if err != nil || len(recs) != 1 || !recs[0].Succeeded() {
+			t.Errorf("got %+v, %v, want only the most recent run", recs, err)
+		}
+	})
+}
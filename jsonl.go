@@ -0,0 +1,125 @@
+package wut
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+const defaultJSONLOutputLimit = 4 << 10
+
+// JSONLSink writes a JSON object describing each attempt made by a Runner as a
+// line to an io.Writer, providing a stable machine-readable record of its
+// execution independent of log formatting.
+//
+// To enable it, register the Record method of a JSONLSink as an observer:
+//
+//	sink := wut.NewJSONLSink(os.Stdout)
+//	runner.Observe(sink.Record)
+//
+// Each line is written once the delay before the next attempt is known, or
+// the Runner stops, and is an [AttemptRecord]. Output is only included if
+// capture is enabled via [Runner.CaptureLimit]. JSONLSink is safe for
+// concurrent use.
+type JSONLSink struct {
+	// OutputLimit is the maximum number of bytes of captured output included
+	// in each record, retaining the end of the output. If zero, a default of
+	// 4KiB is used; if negative, output is omitted.
+	OutputLimit int
+
+	mu      sync.Mutex
+	w       io.Writer
+	pending *Attempt
+	err     error
+}
+
+// AttemptRecord is the JSON object written by a JSONLSink for each attempt.
+type AttemptRecord struct {
+	Time            time.Time `json:"time"` // time the attempt started
+	Attempt         uint      `json:"attempt"`
+	Duration        float64   `json:"duration_seconds"`
+	ExitCode        int       `json:"exit_code"`
+	Error           string    `json:"error,omitempty"`
+	Output          string    `json:"output,omitempty"`
+	OutputTruncated bool      `json:"output_truncated,omitempty"`
+	OutputSHA256    string    `json:"output_sha256,omitempty"`      // hex encoded Attempt.OutputSum, if output was captured
+	NextDelay       *float64  `json:"next_delay_seconds,omitempty"` // nil if the Runner stopped after the attempt
+}
+
+// NewJSONLSink returns a JSONLSink writing to w.
+func NewJSONLSink(w io.Writer) *JSONLSink {
+	return &JSONLSink{w: w}
+}
+
+// Record processes an event emitted by a Runner.
+func (s *JSONLSink) Record(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch e.Kind {
+	case EventAttemptEnd:
+		a := e.Attempt
+		s.pending = &a
+	case EventDelay:
+		s.flush(&e.Delay)
+	case EventAttemptStart:
+		var none time.Duration
+		s.flush(&none)
+	case EventRunEnd:
+		s.flush(nil)
+	}
+}
+
+// flush writes the pending attempt, if any, followed by nextDelay.
+func (s *JSONLSink) flush(nextDelay *time.Duration) {
+	if s.pending == nil {
+		return
+	}
+	a := s.pending
+	s.pending = nil
+
+	rec := AttemptRecord{
+		Time:     a.Start,
+		Attempt:  a.Num,
+		Duration: a.Duration.Seconds(),
+		ExitCode: a.ExitCode(),
+	}
+	if a.Err != nil {
+		rec.Error = a.Err.Error()
+	}
+	if limit := s.OutputLimit; limit >= 0 {
+		if limit == 0 {
+			limit = defaultJSONLOutputLimit
+		}
+		out := a.Output
+		if len(out) > limit {
+			out, rec.OutputTruncated = out[len(out)-limit:], true
+		}
+		rec.Output = string(out)
+	}
+	if a.OutputSum != ([sha256.Size]byte{}) {
+		rec.OutputSHA256 = hex.EncodeToString(a.OutputSum[:])
+	}
+	if nextDelay != nil {
+		d := nextDelay.Seconds()
+		rec.NextDelay = &d
+	}
+
+	data, err := json.Marshal(rec)
+	if err == nil {
+		_, err = s.w.Write(append(data, '\n'))
+	}
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+// Err returns the first error encountered writing a record, if any.
+func (s *JSONLSink) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
@@ -0,0 +1,77 @@
+package wut
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestJSONLSink(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{
+			sleep:    time.Second,
+			output:   "0123456789",
+			exitcode: 2,
+		})
+		r.MaxRuns = 2
+		r.RetryDelay = 3 * time.Second
+		r.CaptureLimit = 1024
+
+		var buf bytes.Buffer
+		sink := NewJSONLSink(&buf)
+		sink.OutputLimit = 4
+		r.Observe(sink.Record)
+		r.Run()
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+		}
+		var recs [2]AttemptRecord
+		for i, line := range lines {
+			if err := json.Unmarshal([]byte(line), &recs[i]); err != nil {
+				t.Fatalf("line %d: invalid JSON %q: %v", i, line, err)
+			}
+		}
+
+		first, second := recs[0], recs[1]
+		if first.Attempt != 1 || first.Duration != 1 || first.ExitCode != -1 || first.Error == "" {
+			t.Errorf("first record: got %+v", first)
+		}
+		if first.Output != "6789" || !first.OutputTruncated {
+			t.Errorf("first record: got output %q (truncated %v), want %q truncated", first.Output, first.OutputTruncated, "6789")
+		}
+		if want := fmt.Sprintf("%x", sha256.Sum256([]byte("0123456789"))); first.OutputSHA256 != want {
+			t.Errorf("first record: got output hash %q, want %q", first.OutputSHA256, want)
+		}
+		if first.NextDelay == nil || *first.NextDelay != 3 {
+			t.Errorf("first record: got next delay %v, want 3", first.NextDelay)
+		}
+		if second.Attempt != 2 || second.NextDelay == nil || *second.NextDelay != 3 {
+			t.Errorf("second record: got %+v, want attempt 2 with next delay 3", second)
+		}
+		if err := sink.Err(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("success", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			r := NewRunnerWithExecutor(t.Context(), mockExecutor{})
+			var buf bytes.Buffer
+			sink := NewJSONLSink(&buf)
+			r.Observe(sink.Record)
+			r.Run()
+
+			want := `{"time":"2000-01-01T00:00:00Z","attempt":1,"duration_seconds":0,"exit_code":0}` + "\n"
+			if got := buf.String(); got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	})
+}
@@ -0,0 +1,106 @@
+// Package kubeexec provides a [wut.Executor] that runs commands in a Kubernetes
+// cluster by way of kubectl.
+package kubeexec
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// Executor runs commands in a Kubernetes cluster using kubectl.
+//
+// If Pod is set, the command is run inside the existing pod with kubectl exec.
+// Otherwise, a short-lived pod is created from Image for each run with
+// kubectl run, and removed once the run completes. If a run is cancelled, a
+// best effort is made to delete the short-lived pod.
+//
+// The [wut.CommandOpts] are applied to the local kubectl process, so Env and
+// Dir affect kubectl itself (for example, KUBECONFIG) rather than the remote
+// command. Stdin is only attached to the remote command when non-nil.
+type Executor struct {
+	Pod         string // name of an existing pod to exec the command in
+	Image       string // container image used to create a short-lived pod when Pod is unset
+	Container   string // container name within the pod, optional
+	Namespace   string // namespace of the pod, optional
+	KubeContext string // kubeconfig context to use, optional
+
+	Kubectl  string       // path to the kubectl binary, defaults to "kubectl"
+	Executor wut.Executor // executor used to run kubectl, defaults to wut.CmdExecutor
+}
+
+// verify Executor implements the wut.Executor interface
+var _ wut.Executor = Executor{}
+
+// deleteTimeout bounds the cleanup of a short-lived pod after cancellation.
+const deleteTimeout = 30 * time.Second
+
+func (e Executor) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	if e.Pod == "" && e.Image == "" {
+		return fmt.Errorf("kubeexec: one of Pod or Image must be set")
+	}
+
+	if e.Pod != "" {
+		return e.kubectl(ctx, opts, e.execArgs(opts, name, args...)...)
+	}
+
+	pod := "wut-" + strings.ToLower(rand.Text()[:10])
+	err := e.kubectl(ctx, opts, e.runArgs(pod, opts, name, args...)...)
+	if ctx.Err() != nil {
+		// kubectl was killed before it could remove the pod itself.
+		dctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deleteTimeout)
+		defer cancel()
+		_ = e.kubectl(dctx, wut.CommandOpts{Env: opts.Env, Dir: opts.Dir},
+			e.globalArgs("delete", "pod", pod, "--wait=false", "--ignore-not-found")...)
+	}
+	return err
+}
+
+func (e Executor) kubectl(ctx context.Context, opts wut.CommandOpts, args ...string) error {
+	kubectl, executor := e.Kubectl, e.Executor
+	if kubectl == "" {
+		kubectl = "kubectl"
+	}
+	if executor == nil {
+		executor = wut.CmdExecutor{}
+	}
+	return executor.Run(ctx, opts, kubectl, args...)
+}
+
+// globalArgs returns the kubectl arguments for the given subcommand, with
+// the global context and namespace flags applied.
+func (e Executor) globalArgs(subcommand ...string) []string {
+	args := subcommand
+	if e.KubeContext != "" {
+		args = append(args, "--context="+e.KubeContext)
+	}
+	if e.Namespace != "" {
+		args = append(args, "--namespace="+e.Namespace)
+	}
+	return args
+}
+
+func (e Executor) execArgs(opts wut.CommandOpts, name string, args ...string) []string {
+	kargs := e.globalArgs("exec")
+	if opts.Stdin != nil {
+		kargs = append(kargs, "--stdin")
+	}
+	if e.Container != "" {
+		kargs = append(kargs, "--container="+e.Container)
+	}
+	kargs = append(kargs, e.Pod, "--", name)
+	return append(kargs, args...)
+}
+
+func (e Executor) runArgs(pod string, opts wut.CommandOpts, name string, args ...string) []string {
+	kargs := e.globalArgs("run", pod, "--image="+e.Image, "--restart=Never", "--rm", "--attach", "--quiet")
+	if opts.Stdin != nil {
+		kargs = append(kargs, "--stdin")
+	}
+	kargs = append(kargs, "--command", "--", name)
+	return append(kargs, args...)
+}
@@ -0,0 +1,82 @@
+package kubeexec
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mroth/wut"
+)
+
+// recordingExecutor records the commands it is asked to run.
+type recordingExecutor struct {
+	calls [][]string
+	err   error
+}
+
+func (re *recordingExecutor) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	re.calls = append(re.calls, append([]string{name}, args...))
+	if re.err != nil {
+		return re.err
+	}
+	return ctx.Err()
+}
+
+func TestExecutor_Run(t *testing.T) {
+	t.Run("exec in pod", func(t *testing.T) {
+		rec := &recordingExecutor{}
+		e := Executor{Pod: "web-0", Container: "app", Namespace: "prod", KubeContext: "ci", Executor: rec}
+
+		if err := e.Run(t.Context(), wut.CommandOpts{Stdin: strings.NewReader("")}, "ls", "-l"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []string{"kubectl", "exec", "--context=ci", "--namespace=prod", "--stdin", "--container=app", "web-0", "--", "ls", "-l"}
+		if len(rec.calls) != 1 || !slices.Equal(rec.calls[0], want) {
+			t.Errorf("got %q, want %q", rec.calls, want)
+		}
+	})
+
+	t.Run("short-lived pod", func(t *testing.T) {
+		rec := &recordingExecutor{}
+		e := Executor{Image: "busybox", Kubectl: "/opt/kubectl", Executor: rec}
+
+		if err := e.Run(t.Context(), wut.CommandOpts{}, "true"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(rec.calls) != 1 {
+			t.Fatalf("got %d calls, want 1", len(rec.calls))
+		}
+		got := rec.calls[0]
+		if got[0] != "/opt/kubectl" || got[1] != "run" || !strings.HasPrefix(got[2], "wut-") {
+			t.Errorf("unexpected kubectl invocation %q", got)
+		}
+		if !slices.Contains(got, "--image=busybox") || !slices.Contains(got, "--rm") {
+			t.Errorf("missing expected flags in %q", got)
+		}
+	})
+
+	t.Run("short-lived pod deleted on cancel", func(t *testing.T) {
+		rec := &recordingExecutor{}
+		e := Executor{Image: "busybox", Executor: rec}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		if err := e.Run(ctx, wut.CommandOpts{}, "sleep", "60"); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if len(rec.calls) != 2 {
+			t.Fatalf("got %d calls, want 2", len(rec.calls))
+		}
+		pod := rec.calls[0][2]
+		if got := rec.calls[1]; got[1] != "delete" || got[3] != pod {
+			t.Errorf("unexpected cleanup invocation %q", got)
+		}
+	})
+
+	t.Run("requires pod or image", func(t *testing.T) {
+		if err := (Executor{}).Run(t.Context(), wut.CommandOpts{}, "true"); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package wut
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Locker is a mutual exclusion lock held by a Runner while it runs, see
+// [Runner.Lock]. Implementations may be backed by a distributed coordination
+// service, so that only one node in a fleet executes a job at a time.
+type Locker interface {
+	// Acquire acquires the lock, returning ErrLocked if it is held elsewhere
+	// and the implementation does not wait for it, or the cause of ctx if it
+	// is done while waiting.
+	Acquire(ctx context.Context) error
+
+	// Release releases the lock acquired by Acquire.
+	Release() error
+}
+
+// ErrLocked is returned by a Locker when it is held by another instance and
+// is configured not to wait for it.
+var ErrLocked = errors.New("wut: lock is held by another instance")
+
+// lockPollInterval is the interval between attempts to acquire a held lock
+// while waiting for it.
+const lockPollInterval = 100 * time.Millisecond
+
+// FileLock is an exclusive advisory lock on a file, held with flock(2) on Unix
+// and LockFileEx on Windows, such that only one process on a host may hold it
+// at a time. The file is created if it does not exist, and records the pid of
+// the holder. The lock is released if the holding process exits.
+type FileLock struct {
+	Path string
+
+	// Wait determines whether Acquire waits for a held lock to be released,
+	// rather than failing immediately with ErrLocked.
+	Wait bool
+
+	f *os.File
+}
+
+// verify FileLock implements the Locker interface
+var _ Locker = (*FileLock)(nil)
+
+// Acquire acquires the lock, waiting for it until ctx is done if Wait is set.
+func (l *FileLock) Acquire(ctx context.Context) error {
+	f, err := os.OpenFile(l.Path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	for {
+		ok, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("wut: locking %s: %w", l.Path, err)
+		}
+		if ok {
+			break
+		}
+		if !l.Wait {
+			f.Close()
+			return ErrLocked
+		}
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return context.Cause(ctx)
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	// recording the pid is informational, so errors are ignored
+	if f.Truncate(0) == nil {
+		f.WriteAt(strconv.AppendInt(nil, int64(os.Getpid()), 10), 0)
+	}
+	l.f = f
+	return nil
+}
+
+// Release releases the lock. The file is left in place, as removing it would
+// race with other processes opening it to acquire the lock.
+func (l *FileLock) Release() error {
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
@@ -0,0 +1,13 @@
+//go:build !unix && !windows
+
+package wut
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLockFile is not supported on this platform.
+func tryLockFile(f *os.File) (bool, error) {
+	return false, errors.New("file locking is not supported on this platform")
+}
@@ -0,0 +1,85 @@
+package wut
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.lock")
+	held := &FileLock{Path: path}
+	if err := held.Acquire(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	other := &FileLock{Path: path}
+	if err := other.Acquire(t.Context()); !errors.Is(err, ErrLocked) {
+		t.Errorf("got %v, want %v", err, ErrLocked)
+	}
+
+	other.Wait = true
+	ctx, cancel := context.WithTimeout(t.Context(), 3*lockPollInterval)
+	defer cancel()
+	if err := other.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	time.AfterFunc(lockPollInterval, func() { held.Release() })
+	if err := other.Acquire(t.Context()); err != nil {
+		t.Errorf("unexpected error waiting for release: %v", err)
+	}
+	if err := other.Release(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRunner_LockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.lock")
+	held := &FileLock{Path: path}
+	if err := held.Acquire(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer held.Release()
+
+	r := NewRunnerWithExecutor(t.Context(), mockExecutor{})
+	r.LockFile = path
+	if err := r.Run(); !errors.Is(err, ErrLocked) {
+		t.Errorf("got %v, want %v", err, ErrLocked)
+	}
+}
+
+// recordingLocker is a Locker recording calls to it.
+type recordingLocker struct {
+	calls []string
+}
+
+func (rl *recordingLocker) Acquire(ctx context.Context) error {
+	rl.calls = append(rl.calls, "acquire")
+	return nil
+}
+
+func (rl *recordingLocker) Release() error {
+	rl.calls = append(rl.calls, "release")
+	return nil
+}
+
+func TestRunner_Lock(t *testing.T) {
+	lock := &recordingLocker{}
+	r := NewRunnerWithExecutor(t.Context(), mockExecutor{})
+	r.Lock = lock
+	r.Observe(func(e Event) {
+		if e.Kind == EventAttemptStart {
+			lock.calls = append(lock.calls, "attempt")
+		}
+	})
+	if err := r.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"acquire", "attempt", "release"}; !slices.Equal(lock.calls, want) {
+		t.Errorf("got calls %q, want %q", lock.calls, want)
+	}
+}
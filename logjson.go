@@ -0,0 +1,57 @@
+package wut
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+)
+
+// jsonRecord is a line of command output which is a JSON object, such as a
+// log record written by a command using structured logging.
+type jsonRecord struct {
+	msg   string      // value of its msg or message field, if a string
+	level *slog.Level // value of its level field, if a valid level
+	args  []any       // remaining fields as alternating keys and values, in order
+}
+
+// parseJSONRecord parses line as a JSON object, reporting whether it is one.
+// The order of its fields is preserved, and its time field is discarded.
+func parseJSONRecord(line string) (jsonRecord, bool) {
+	var rec jsonRecord
+	b := bytes.TrimSpace([]byte(line))
+	if len(b) < 2 || b[0] != '{' || !json.Valid(b) {
+		return rec, false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil { // opening brace
+		return rec, false
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return rec, false
+		}
+		key := tok.(string)
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return rec, false
+		}
+		switch s, isString := v.(string); {
+		case (key == "msg" || key == "message") && isString && rec.msg == "":
+			rec.msg = s
+		case key == "level" && isString && rec.level == nil:
+			var lvl slog.Level
+			if lvl.UnmarshalText([]byte(s)) == nil {
+				rec.level = &lvl
+				continue
+			}
+			rec.args = append(rec.args, key, v)
+		case key == "time":
+		default:
+			rec.args = append(rec.args, key, v)
+		}
+	}
+	return rec, true
+}
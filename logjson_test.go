@@ -0,0 +1,36 @@
+package wut
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"testing"
+)
+
+func TestParseJSONRecord(t *testing.T) {
+	rec, ok := parseJSONRecord(`{"time":"2024-01-01T00:00:00Z","level":"error","msg":"disk full","path":"/var","free":0,"tags":["a"]}`)
+	if !ok {
+		t.Fatal("expected a JSON record")
+	}
+	if rec.msg != "disk full" {
+		t.Errorf("msg: got %q, want %q", rec.msg, "disk full")
+	}
+	if rec.level == nil || *rec.level != slog.LevelError {
+		t.Errorf("level: got %v, want %v", rec.level, slog.LevelError)
+	}
+	if len(rec.args) != 6 || !slices.Equal([]any{rec.args[0], rec.args[1], rec.args[2], rec.args[3]}, []any{"path", "/var", "free", json.Number("0")}) {
+		t.Errorf("args: got %v", rec.args)
+	}
+
+	// an unrecognized level is kept as a field
+	rec, _ = parseJSONRecord(`{"level":"loud","message":"hi"}`)
+	if rec.level != nil || rec.msg != "hi" || !slices.Equal(rec.args, []any{"level", "loud"}) {
+		t.Errorf("got %+v", rec)
+	}
+
+	for _, line := range []string{"plain text", "[1,2]", `"str"`, `{"unterminated":`, `{"a":1} trailing`, ""} {
+		if _, ok := parseJSONRecord(line); ok {
+			t.Errorf("%q: unexpectedly parsed as a JSON record", line)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package wut
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// markerFresh reports whether the SuccessMarker of r records a success within
+// its SuccessTTL.
+func (r *Runner) markerFresh() bool {
+	fi, err := os.Stat(r.SuccessMarker)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false
+	} else if err != nil {
+		r.log(slog.LevelWarn, "Cannot read success marker", "path", r.SuccessMarker, "error", err)
+		return false
+	}
+	return r.SuccessTTL <= 0 || r.clock.Now().Sub(fi.ModTime()) < r.SuccessTTL
+}
+
+// touchMarker records a success at the current time in the SuccessMarker of r.
+func (r *Runner) touchMarker() {
+	now := r.clock.Now()
+	err := os.WriteFile(r.SuccessMarker, []byte(now.Format(time.RFC3339)+"\n"), 0o644)
+	if err == nil {
+		err = os.Chtimes(r.SuccessMarker, now, now)
+	}
+	if err != nil {
+		r.log(slog.LevelWarn, "Cannot write success marker", "path", r.SuccessMarker, "error", err)
+	}
+}
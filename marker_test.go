@@ -0,0 +1,41 @@
+package wut
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestRunner_SuccessMarker(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "done")
+	synctest.Test(t, func(t *testing.T) {
+		ex := &scriptedExecutor{outputs: []string{"", ""}, exitcode: []int{0, 0}}
+		newRunner := func() *Runner {
+			r := NewRunner(t.Context(), "job")
+			r.SetExecutor(ex)
+			r.CommandOptions.Stdout = io.Discard
+			r.SuccessMarker = marker
+			r.SuccessTTL = time.Hour
+			return r
+		}
+
+		for range 2 {
+			if err := newRunner().Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if ex.runs != 1 {
+			t.Errorf("got %d runs while the marker is fresh, want 1", ex.runs)
+		}
+
+		time.Sleep(time.Hour)
+		if err := newRunner().Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ex.runs != 2 {
+			t.Errorf("got %d runs once the marker expired, want 2", ex.runs)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package wut
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"sync/atomic"
+)
+
+// ErrOutputNotMatched is the error of an attempt which exited successfully,
+// but without any line of its output matching Runner.UntilOutput.
+var ErrOutputNotMatched = errors.New("wut: output did not match")
+
+// ErrFatalOutput is the error of an attempt with output matching
+// Runner.FailOnOutput, wrapping any error with which it failed otherwise.
+var ErrFatalOutput = errors.New("wut: output matched fatal pattern")
+
+// outputMatcher watches the output of an attempt for a line matching a
+// pattern, with ANSI escape sequences removed. Each stream is given its own
+// writer by tee, as lines may be split across writes.
+type outputMatcher struct {
+	re      *regexp.Regexp
+	matched atomic.Bool
+	writers []*lineWriter
+}
+
+func newOutputMatcher(re *regexp.Regexp) *outputMatcher {
+	return &outputMatcher{re: re}
+}
+
+// tee returns a writer writing to w, if not nil, and to the matcher.
+func (m *outputMatcher) tee(w io.Writer) io.Writer {
+	lw := newLineWriter(func(line string) {
+		if !m.matched.Load() && m.re.Match(StripANSI([]byte(line))) {
+			m.matched.Store(true)
+		}
+	})
+	m.writers = append(m.writers, lw)
+	return teeWriter(w, lw)
+}
+
+// Matched reports whether a line of the output matched, once the command has
+// exited, including any final unterminated line.
+func (m *outputMatcher) Matched() bool {
+	for _, lw := range m.writers {
+		lw.Flush()
+	}
+	return m.matched.Load()
+}
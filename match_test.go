@@ -0,0 +1,55 @@
+package wut
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"testing"
+	"testing/synctest"
+)
+
+func TestRunner_UntilOutput(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "poll")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"status: pending\n", "status: failed\n", "status: \x1b[32mready\x1b[0m"},
+			exitcode: []int{0, 1, 3},
+		})
+		r.CommandOptions.Stdout = io.Discard
+		r.UntilOutput = regexp.MustCompile(`^status: ready$`)
+		var errs []error
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptEnd {
+				errs = append(errs, e.Attempt.Err)
+			}
+		})
+
+		runAssert(t, r, runnerExpectedResults{err: nil, runs: 3})
+		if len(errs) != 3 || !errors.Is(errs[0], ErrOutputNotMatched) || errs[1] == nil || errs[2] != nil {
+			t.Errorf("attempt errors: got %v, want [%v, exit status 1, <nil>]", errs, ErrOutputNotMatched)
+		}
+	})
+}
+
+func TestRunner_FailOnOutput(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "deploy")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"connection refused\n", "error: permission denied\n", "ok\n"},
+			exitcode: []int{1, 0, 0},
+		})
+		r.CommandOptions.Stdout = io.Discard
+		r.FailOnOutput = regexp.MustCompile(`permission denied`)
+		var last Attempt
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptEnd {
+				last = e.Attempt
+			}
+		})
+
+		runAssert(t, r, runnerExpectedResults{err: ErrPermanentFailure, runs: 2})
+		if !errors.Is(last.Err, ErrFatalOutput) {
+			t.Errorf("attempt error: got %v, want %v", last.Err, ErrFatalOutput)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package wut
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultRSSPollInterval = 250 * time.Millisecond
+
+// procInfo is a process as listed in the process table.
+type procInfo struct {
+	pid, ppid, pgid int
+	rss             int64 // resident set size in bytes
+	zombie          bool  // exited but not yet reaped by its parent
+}
+
+// treeRSS returns the combined resident set size of the process root and all
+// its descendants in procs, along with their pids.
+func treeRSS(procs []procInfo, root int) (total int64, pids []int) {
+	children := make(map[int][]procInfo)
+	for _, p := range procs {
+		children[p.ppid] = append(children[p.ppid], p)
+	}
+	for _, p := range procs {
+		if p.pid == root {
+			total, pids = p.rss, []int{root}
+			break
+		}
+	}
+	if pids == nil {
+		return 0, nil
+	}
+	for i := 0; i < len(pids); i++ {
+		for _, c := range children[pids[i]] {
+			total += c.rss
+			pids = append(pids, c.pid)
+		}
+	}
+	return total, pids
+}
+
+// watchMemory samples the resident set size of the process pid and its
+// descendants every interval, killing them all if it exceeds limit. It returns
+// an error if the initial sample cannot be taken. The returned stop function
+// must be called once the process has completed, and reports whether it was
+// killed.
+func watchMemory(pid int, limit int64, interval time.Duration) (stop func() (killed bool), err error) {
+	if interval <= 0 {
+		interval = defaultRSSPollInterval
+	}
+	var exceeded atomic.Bool
+	check := func() error {
+		procs, err := processTable()
+		if err != nil {
+			return err
+		}
+		if rss, pids := treeRSS(procs, pid); rss > limit {
+			exceeded.Store(true)
+			for _, p := range pids {
+				if proc, err := os.FindProcess(p); err == nil {
+					proc.Kill()
+				}
+			}
+		}
+		return nil
+	}
+	if err := check(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for !exceeded.Load() {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				check() // the process may have exited between samples
+			}
+		}
+	})
+	return func() bool {
+		close(done)
+		wg.Wait()
+		return exceeded.Load()
+	}, nil
+}
@@ -0,0 +1,49 @@
+package wut
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// processTable lists all running processes, as read from /proc.
+func processTable() ([]procInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("wut: listing processes: %w", err)
+	}
+	pageSize := int64(os.Getpagesize())
+	var procs []procInfo
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile("/proc/" + e.Name() + "/stat")
+		if err != nil {
+			continue // exited since listing
+		}
+		// the command name in parentheses may itself contain spaces, so skip
+		// past it before splitting the remaining fields, starting with state
+		i := bytes.LastIndexByte(stat, ')')
+		if i < 0 {
+			continue
+		}
+		fields := bytes.Fields(stat[i+1:])
+		if len(fields) < 22 {
+			continue
+		}
+		ppid, _ := strconv.Atoi(string(fields[1]))
+		pgid, _ := strconv.Atoi(string(fields[2]))
+		rss, _ := strconv.ParseInt(string(fields[21]), 10, 64)
+		procs = append(procs, procInfo{
+			pid:    pid,
+			ppid:   ppid,
+			pgid:   pgid,
+			rss:    rss * pageSize,
+			zombie: string(fields[0]) == "Z",
+		})
+	}
+	return procs, nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package wut
+
+import "errors"
+
+// processTable is not supported on this platform.
+func processTable() ([]procInfo, error) {
+	return nil, errors.New("wut: listing processes is not supported on this platform")
+}
@@ -0,0 +1,29 @@
+package wut
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTreeRSS(t *testing.T) {
+	procs := []procInfo{
+		{pid: 1, ppid: 0, rss: 1000},
+		{pid: 10, ppid: 1, rss: 100},
+		{pid: 11, ppid: 10, rss: 20},
+		{pid: 12, ppid: 10, rss: 30},
+		{pid: 13, ppid: 12, rss: 4},
+		{pid: 20, ppid: 1, rss: 500},
+	}
+	total, pids := treeRSS(procs, 10)
+	if total != 154 {
+		t.Errorf("total: got %d, want 154", total)
+	}
+	slices.Sort(pids)
+	if want := []int{10, 11, 12, 13}; !slices.Equal(pids, want) {
+		t.Errorf("pids: got %v, want %v", pids, want)
+	}
+
+	if total, pids := treeRSS(procs, 99); total != 0 || pids != nil {
+		t.Errorf("missing root: got %d %v, want 0 []", total, pids)
+	}
+}
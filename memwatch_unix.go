@@ -0,0 +1,44 @@
+//go:build unix && !linux
+
+package wut
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// processTable lists all running processes, as reported by ps(1).
+func processTable() ([]procInfo, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=", "-o", "pgid=", "-o", "rss=", "-o", "stat=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("wut: listing processes: %w", err)
+	}
+	var procs []procInfo
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 5 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		pgid, err3 := strconv.Atoi(fields[2])
+		rss, err4 := strconv.ParseInt(fields[3], 10, 64)
+		if err := errors.Join(err1, err2, err3, err4); err != nil {
+			continue
+		}
+		procs = append(procs, procInfo{
+			pid:    pid,
+			ppid:   ppid,
+			pgid:   pgid,
+			rss:    rss << 10, // ps reports KiB
+			zombie: strings.HasPrefix(fields[4], "Z"),
+		})
+	}
+	return procs, nil
+}
@@ -0,0 +1,69 @@
+package wut
+
+import "time"
+
+// Metrics receives measurements of the attempts made by a Runner, allowing
+// them to be exported to a monitoring system. Adapters for several backends
+// are provided by the otelwut, promwut, expvarwut, and statsdwut packages.
+//
+// Methods are called synchronously from the goroutine executing Run, and so
+// should return promptly. Implementations shared between Runners must be
+// safe for concurrent use.
+type Metrics interface {
+	// IncAttempt is called once each attempt has completed, with its result.
+	IncAttempt(result AttemptResult)
+	// ObserveDuration is called once each attempt has completed, with its
+	// duration.
+	ObserveDuration(d time.Duration)
+	// SetState is called whenever the state of the Runner changes.
+	SetState(s RunnerState)
+}
+
+// AttemptResult is the result of an attempt, as reported to [Metrics].
+type AttemptResult string
+
+const (
+	AttemptSuccess AttemptResult = "success"
+	AttemptFailure AttemptResult = "failure"
+)
+
+// resultOf returns the AttemptResult of a completed attempt.
+func resultOf(a Attempt) AttemptResult {
+	if a.Err != nil {
+		return AttemptFailure
+	}
+	return AttemptSuccess
+}
+
+// MultiMetrics returns a Metrics that reports to all of the provided Metrics,
+// similar to [io.MultiWriter].
+func MultiMetrics(metrics ...Metrics) Metrics {
+	return multiMetrics(metrics)
+}
+
+type multiMetrics []Metrics
+
+func (mm multiMetrics) IncAttempt(result AttemptResult) {
+	for _, m := range mm {
+		m.IncAttempt(result)
+	}
+}
+
+func (mm multiMetrics) ObserveDuration(d time.Duration) {
+	for _, m := range mm {
+		m.ObserveDuration(d)
+	}
+}
+
+func (mm multiMetrics) SetState(s RunnerState) {
+	for _, m := range mm {
+		m.SetState(s)
+	}
+}
+
+// noopMetrics is a Metrics that discards all measurements.
+type noopMetrics struct{}
+
+func (noopMetrics) IncAttempt(AttemptResult)      {}
+func (noopMetrics) ObserveDuration(time.Duration) {}
+func (noopMetrics) SetState(RunnerState)          {}
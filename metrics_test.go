@@ -0,0 +1,57 @@
+package wut
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+// recordingMetrics is a Metrics that records all calls as strings.
+type recordingMetrics struct {
+	calls []string
+}
+
+func (m *recordingMetrics) IncAttempt(result AttemptResult) {
+	m.calls = append(m.calls, "attempt "+string(result))
+}
+
+func (m *recordingMetrics) ObserveDuration(d time.Duration) {
+	m.calls = append(m.calls, fmt.Sprint("duration ", d))
+}
+
+func (m *recordingMetrics) SetState(s RunnerState) {
+	m.calls = append(m.calls, "state "+s.String())
+}
+
+func TestRunner_SetMetrics(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{sleep: time.Second, exitcode: 1})
+		r.MaxRuns = 2
+		r.RetryDelay = time.Second
+
+		var m1, m2 recordingMetrics
+		r.SetMetrics(MultiMetrics(&m1, &m2))
+		r.Run()
+
+		want := []string{
+			"state idle",
+			"state running",
+			"attempt failure",
+			"duration 1s",
+			"state idle",
+			"state running",
+			"attempt failure",
+			"duration 1s",
+			"state idle",
+			"state errored",
+		}
+		if !slices.Equal(m1.calls, want) {
+			t.Errorf("got calls:\n%q\nwant:\n%q", m1.calls, want)
+		}
+		if !slices.Equal(m2.calls, m1.calls) {
+			t.Errorf("MultiMetrics: got different calls %q and %q", m1.calls, m2.calls)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package wut
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// muxColors are the ANSI SGR codes cycled through for the prefixes of a
+// Multiplexer, skipping red which is conventionally reserved for errors.
+var muxColors = []string{"36", "33", "32", "35", "34", "96", "93", "92", "95", "94"}
+
+// Multiplexer interleaves the output of several concurrently running commands
+// onto a single writer a line at a time, prefixing each line with the name of
+// the command it came from, padded to align, in the style of foreman. It is
+// intended for runners executing commands in parallel, such as
+// [Runner.Stress], so that their output remains readable on one terminal.
+type Multiplexer struct {
+	// Color, if set, colors each prefix with an ANSI escape sequence, using a
+	// distinct color per name.
+	Color bool
+
+	mu     sync.Mutex // guards writes to w and the fields below
+	w      io.Writer
+	width  int            // length of the longest name
+	colors map[string]int // index into muxColors by name
+}
+
+// isColorTerminal reports whether w appears to be a terminal on which colored
+// output is wanted, that is, unless the NO_COLOR environment variable is set.
+func isColorTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// NewMultiplexer returns a Multiplexer writing to w.
+func NewMultiplexer(w io.Writer) *Multiplexer {
+	return &Multiplexer{w: w, colors: make(map[string]int)}
+}
+
+// Writer returns a MuxWriter for the output of the command with the given
+// name. It may be called more than once per name, such as for both the stdout
+// and stderr of a command. Names should be registered before output is
+// written, so that all prefixes are aligned.
+func (m *Multiplexer) Writer(name string) *MuxWriter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.width = max(m.width, len(name))
+	if _, ok := m.colors[name]; !ok {
+		m.colors[name] = len(m.colors) % len(muxColors)
+	}
+	mw := &MuxWriter{}
+	mw.lines = newLineWriter(func(line string) { m.writeLine(name, line) })
+	return mw
+}
+
+// writeLine writes a single prefixed line to the underlying writer.
+func (m *Multiplexer) writeLine(name, line string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Color {
+		fmt.Fprintf(m.w, "\x1b[%sm%-*s |\x1b[0m %s\n", muxColors[m.colors[name]], m.width, name, line)
+	} else {
+		fmt.Fprintf(m.w, "%-*s | %s\n", m.width, name, line)
+	}
+}
+
+// MuxWriter is an io.Writer for the output of one command of a Multiplexer.
+// Complete lines are written through immediately, so that the lines of
+// different commands are never interleaved mid-line. It is safe for
+// concurrent use.
+type MuxWriter struct {
+	lines *lineWriter
+}
+
+// Write writes each complete line of p to the Multiplexer, buffering any
+// trailing partial line.
+func (mw *MuxWriter) Write(p []byte) (int, error) {
+	return mw.lines.Write(p)
+}
+
+// Flush writes any buffered partial line to the Multiplexer. It should be
+// called once the command has exited.
+func (mw *MuxWriter) Flush() {
+	mw.lines.Flush()
+}
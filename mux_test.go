@@ -0,0 +1,61 @@
+package wut
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMultiplexer(t *testing.T) {
+	var buf strings.Builder
+	m := NewMultiplexer(&buf)
+	web, worker := m.Writer("web"), m.Writer("worker")
+
+	web.Write([]byte("listening\nready"))
+	worker.Write([]byte("sta"))
+	worker.Write([]byte("rted\n"))
+	web.Flush()
+	worker.Flush()
+
+	want := "web    | listening\nworker | started\nweb    | ready\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	t.Run("color", func(t *testing.T) {
+		var buf strings.Builder
+		m := NewMultiplexer(&buf)
+		m.Color = true
+		a, b := m.Writer("a"), m.Writer("b")
+		a.Write([]byte("1\n"))
+		b.Write([]byte("2\n"))
+		m.Writer("a").Write([]byte("3\n"))
+
+		want := "\x1b[36ma |\x1b[0m 1\n\x1b[33mb |\x1b[0m 2\n\x1b[36ma |\x1b[0m 3\n"
+		if got := buf.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestIsColorTerminal(t *testing.T) {
+	if isColorTerminal(&strings.Builder{}) {
+		t.Error("got true for a non-file writer, want false")
+	}
+
+	// the null device is a character device, as a terminal is
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	t.Setenv("NO_COLOR", "")
+	if runtime.GOOS != "windows" && !isColorTerminal(f) {
+		t.Errorf("got false for %s, want true", os.DevNull)
+	}
+	t.Setenv("NO_COLOR", "1")
+	if isColorTerminal(f) {
+		t.Error("got true with NO_COLOR set, want false")
+	}
+}
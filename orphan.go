@@ -0,0 +1,77 @@
+package wut
+
+import (
+	"errors"
+	"os"
+	"runtime"
+)
+
+// OrphanPolicy determines how processes left running by a command once it
+// has exited are handled, see [CommandOpts.Orphans].
+type OrphanPolicy int
+
+const (
+	OrphansIgnore OrphanPolicy = iota // do not look for leftover processes
+	OrphansReport                     // report leftover processes, leaving them running
+	OrphansKill                       // report and kill leftover processes
+)
+
+func (p OrphanPolicy) String() string {
+	switch p {
+	case OrphansIgnore:
+		return "ignore"
+	case OrphansReport:
+		return "report"
+	case OrphansKill:
+		return "kill"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements [encoding.TextMarshaler], encoding the policy as its
+// String, such as for a [Config].
+func (p OrphanPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler], decoding a policy
+// encoded by MarshalText.
+func (p *OrphanPolicy) UnmarshalText(text []byte) error {
+	return unmarshalEnum(p, text, OrphansKill)
+}
+
+// checkOrphansSupported returns an error if leftover processes cannot be
+// detected for a command run with opts.
+func checkOrphansSupported(opts CommandOpts) error {
+	if !opts.ProcessGroup {
+		return errors.New("wut: detecting leftover processes requires ProcessGroup")
+	}
+	if runtime.GOOS == "windows" {
+		return errors.New("wut: detecting leftover processes is not supported on Windows")
+	}
+	return nil
+}
+
+// handleOrphans finds any live processes remaining in the process group pgid,
+// and kills them if policy is OrphansKill, returning their pids.
+func handleOrphans(pgid int, policy OrphanPolicy) ([]int, error) {
+	procs, err := processTable()
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, p := range procs {
+		if p.pgid == pgid && !p.zombie {
+			pids = append(pids, p.pid)
+		}
+	}
+	if policy == OrphansKill {
+		for _, pid := range pids {
+			if proc, err := os.FindProcess(pid); err == nil {
+				proc.Kill()
+			}
+		}
+	}
+	return pids, nil
+}
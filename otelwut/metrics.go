@@ -0,0 +1,118 @@
+package otelwut
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/mroth/wut"
+)
+
+// Attribute keys recorded on metrics.
+const (
+	OutcomeKey = attribute.Key("wut.outcome") // outcome of an attempt, see wut.AttemptResult
+	StateKey   = attribute.Key("wut.state")   // state of a Runner, see wut.RunnerState
+)
+
+var states = []wut.RunnerState{
+	wut.RunnerStateIdle,
+	wut.RunnerStateRunning,
+	wut.RunnerStateCompleted,
+	wut.RunnerStateErrored,
+}
+
+// Metrics is a [wut.Metrics] adapter recording the following OpenTelemetry
+// metrics, each carrying the attributes it was created with:
+//
+//   - wut.attempts: a counter of attempts at executing the command, by outcome
+//   - wut.attempt.duration: a histogram of attempt durations in seconds
+//   - wut.runner.state: a gauge which is 1 for the current state of the Runner
+//     and 0 for all others, by state
+type Metrics struct {
+	attempts metric.Int64Counter
+	duration metric.Float64Histogram
+	state    atomic.Int32 // current wut.RunnerState
+
+	success, failure, all metric.MeasurementOption
+}
+
+// verify Metrics implements the wut.Metrics interface
+var _ wut.Metrics = (*Metrics)(nil)
+
+// NewMetrics returns a Metrics whose instruments are created by mp, carrying
+// attrs. If mp is nil, the global MeterProvider is used. Provide it to a
+// Runner with [wut.Runner.SetMetrics].
+func NewMetrics(mp metric.MeterProvider, attrs ...attribute.KeyValue) (*Metrics, error) {
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	meter := mp.Meter(ScopeName)
+	m := &Metrics{
+		success: metric.WithAttributeSet(attribute.NewSet(withAttr(attrs, OutcomeKey.String(string(wut.AttemptSuccess)))...)),
+		failure: metric.WithAttributeSet(attribute.NewSet(withAttr(attrs, OutcomeKey.String(string(wut.AttemptFailure)))...)),
+		all:     metric.WithAttributeSet(attribute.NewSet(attrs...)),
+	}
+
+	var err error
+	m.attempts, err = meter.Int64Counter("wut.attempts",
+		metric.WithDescription("Number of attempts at executing the command."),
+		metric.WithUnit("{attempt}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.duration, err = meter.Float64Histogram("wut.attempt.duration",
+		metric.WithDescription("Duration of attempts at executing the command."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	_, err = meter.Int64ObservableGauge("wut.runner.state",
+		metric.WithDescription("Current state of the Runner, which is 1 for the current state and 0 otherwise."),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			current := wut.RunnerState(m.state.Load())
+			for _, s := range states {
+				var v int64
+				if s == current {
+					v = 1
+				}
+				o.Observe(v, metric.WithAttributes(withAttr(attrs, StateKey.String(s.String()))...))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// withAttr returns attrs with kv appended, without modifying attrs.
+func withAttr(attrs []attribute.KeyValue, kv attribute.KeyValue) []attribute.KeyValue {
+	return append(slices.Clip(attrs), kv)
+}
+
+// IncAttempt implements [wut.Metrics].
+func (m *Metrics) IncAttempt(result wut.AttemptResult) {
+	outcome := m.success
+	if result == wut.AttemptFailure {
+		outcome = m.failure
+	}
+	m.attempts.Add(context.Background(), 1, outcome)
+}
+
+// ObserveDuration implements [wut.Metrics].
+func (m *Metrics) ObserveDuration(d time.Duration) {
+	m.duration.Record(context.Background(), d.Seconds(), m.all)
+}
+
+// SetState implements [wut.Metrics].
+func (m *Metrics) SetState(s wut.RunnerState) {
+	m.state.Store(int32(s))
+}
@@ -0,0 +1,73 @@
+package otelwut
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/mroth/wut"
+	"github.com/mroth/wut/wuttest"
+)
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	r := wut.NewRunner(t.Context(), "cmd")
+	r.SetLogger(slog.New(slog.DiscardHandler))
+	r.SetExecutor(wuttest.FailTimes(2))
+	m, err := NewMetrics(mp, attribute.String("job", "test"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.SetMetrics(m)
+	if err := r.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = m.Data
+		}
+	}
+
+	counts := make(map[string]int64)
+	for _, dp := range got["wut.attempts"].(metricdata.Sum[int64]).DataPoints {
+		if v, _ := dp.Attributes.Value("job"); v.AsString() != "test" {
+			t.Errorf("attempts: missing job attribute in %v", dp.Attributes)
+		}
+		outcome, _ := dp.Attributes.Value(OutcomeKey)
+		counts[outcome.AsString()] = dp.Value
+	}
+	if counts["success"] != 1 || counts["failure"] != 2 {
+		t.Errorf("attempts: got %v, want 1 success and 2 failures", counts)
+	}
+
+	var observations uint64
+	for _, dp := range got["wut.attempt.duration"].(metricdata.Histogram[float64]).DataPoints {
+		observations += dp.Count
+	}
+	if observations != 3 {
+		t.Errorf("attempt.duration: got %d observations, want 3", observations)
+	}
+
+	for _, dp := range got["wut.runner.state"].(metricdata.Gauge[int64]).DataPoints {
+		state, _ := dp.Attributes.Value(StateKey)
+		want := int64(0)
+		if state.AsString() == wut.RunnerStateCompleted.String() {
+			want = 1
+		}
+		if dp.Value != want {
+			t.Errorf("runner.state{%s}: got %d, want %d", state.AsString(), dp.Value, want)
+		}
+	}
+}
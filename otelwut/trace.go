@@ -0,0 +1,124 @@
+// Package otelwut instruments a [wut.Runner] with OpenTelemetry.
+//
+// [Trace] records a span for each run of a Runner, with a child span for each
+// attempt at executing its command, so that retries show up in the
+// distributed traces of the CI and automation systems embedding it. [Metrics]
+// records metrics of the attempts made by a Runner and its current state, so
+// that fleets of Runners can be monitored uniformly.
+//
+// To keep the OpenTelemetry SDK out of the dependencies of wut itself, this
+// package is its own module, github.com/mroth/wut/otelwut.
+package otelwut
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mroth/wut"
+)
+
+// ScopeName is the instrumentation scope name used for spans and metrics.
+const ScopeName = "github.com/mroth/wut/otelwut"
+
+// Attribute keys recorded on spans.
+const (
+	AttemptKey     = attribute.Key("wut.attempt")       // attempt number, starting from 1
+	AttemptsKey    = attribute.Key("wut.attempts")      // number of attempts made by a run
+	RetryDelayKey  = attribute.Key("wut.retry_delay")   // retry delay preceding an attempt, in seconds
+	ExitCodeKey    = attribute.Key("process.exit.code") // exit code of an attempt, see wut.Attempt.ExitCode
+	OOMKilledKey   = attribute.Key("wut.oom_killed")    // whether an attempt was killed for exceeding its memory limit
+	ForceKilledKey = attribute.Key("wut.force_killed")  // whether an attempt was forcibly stopped after failing to exit
+)
+
+// DelaySkippedEvent is the name of the event recorded on a run span when a
+// retry delay is skipped.
+const DelaySkippedEvent = "wut.delay_skipped"
+
+// Trace registers an observer with r which records a "wut.run" span for each
+// run of r, with a "wut.attempt" child span for each attempt at executing the
+// command. Run spans are parented on any span in ctx, and carry attrs.
+//
+// If tp is nil, the global TracerProvider is used. Trace must not be called
+// while the Runner is running.
+func Trace(ctx context.Context, r *wut.Runner, tp trace.TracerProvider, attrs ...attribute.KeyValue) {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	t := &tracer{
+		parent: ctx,
+		tracer: tp.Tracer(ScopeName),
+		attrs:  attrs,
+	}
+	r.Observe(t.observe)
+}
+
+// tracer tracks the spans of a Runner. As observers are called synchronously
+// from the goroutine executing Run, it requires no locking.
+type tracer struct {
+	parent context.Context
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+
+	runCtx   context.Context
+	run      trace.Span
+	attempt  trace.Span
+	attempts int
+	delay    float64 // seconds of retry delay preceding the next attempt
+}
+
+func (t *tracer) observe(e wut.Event) {
+	switch e.Kind {
+	case wut.EventRunStart:
+		t.runCtx, t.run = t.tracer.Start(t.parent, "wut.run",
+			trace.WithTimestamp(e.Time),
+			trace.WithAttributes(t.attrs...),
+		)
+		t.attempts, t.delay = 0, 0
+
+	case wut.EventDelay:
+		t.delay = e.Delay.Seconds()
+
+	case wut.EventDelaySkipped:
+		t.run.AddEvent(DelaySkippedEvent, trace.WithTimestamp(e.Time))
+
+	case wut.EventAttemptStart:
+		t.attempts++
+		_, t.attempt = t.tracer.Start(t.runCtx, "wut.attempt",
+			trace.WithTimestamp(e.Time),
+			trace.WithAttributes(
+				AttemptKey.Int(int(e.Attempt.Num)),
+				RetryDelayKey.Float64(t.delay),
+			),
+		)
+		t.delay = 0
+
+	case wut.EventAttemptEnd:
+		t.attempt.SetAttributes(ExitCodeKey.Int(e.Attempt.ExitCode()))
+		if e.Attempt.OOMKilled {
+			t.attempt.SetAttributes(OOMKilledKey.Bool(true))
+		}
+		if e.Attempt.ForceKilled {
+			t.attempt.SetAttributes(ForceKilledKey.Bool(true))
+		}
+		endSpan(t.attempt, e, e.Attempt.Err)
+		t.attempt = nil
+
+	case wut.EventRunEnd:
+		t.run.SetAttributes(AttemptsKey.Int(t.attempts))
+		endSpan(t.run, e, e.Err)
+		t.run, t.runCtx = nil, nil
+	}
+}
+
+// endSpan ends span at the time of e, with an error status if err is set.
+func endSpan(span trace.Span, e wut.Event, err error) {
+	if err != nil {
+		span.RecordError(err, trace.WithTimestamp(e.Time))
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(e.Time))
+}
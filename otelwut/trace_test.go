@@ -0,0 +1,78 @@
+package otelwut
+
+import (
+	"log/slog"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/mroth/wut"
+	"github.com/mroth/wut/wuttest"
+)
+
+func TestTrace(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		sr := tracetest.NewSpanRecorder()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+		r := wut.NewRunner(t.Context(), "cmd")
+		r.SetLogger(slog.New(slog.DiscardHandler))
+		r.SetExecutor(wuttest.FailTimes(2))
+		r.RetryDelay = time.Second
+		Trace(t.Context(), r, tp, attribute.String("job", "test"))
+		if err := r.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		spans := sr.Ended()
+		if len(spans) != 4 {
+			t.Fatalf("got %d spans, want 4", len(spans))
+		}
+		run := spans[3]
+		if run.Name() != "wut.run" || run.Status().Code == codes.Error {
+			t.Errorf("run span: got %s with status %v", run.Name(), run.Status())
+		}
+		assertAttr(t, run.Attributes(), "job", attribute.StringValue("test"))
+		assertAttr(t, run.Attributes(), AttemptsKey, attribute.IntValue(3))
+
+		for i, span := range spans[:3] {
+			if span.Name() != "wut.attempt" {
+				t.Errorf("span %d: got name %q, want wut.attempt", i, span.Name())
+			}
+			if span.Parent().SpanID() != run.SpanContext().SpanID() {
+				t.Errorf("span %d: not a child of the run span", i)
+			}
+			wantDelay, wantCode, wantStatus := 1.0, 1, codes.Error
+			if i == 0 {
+				wantDelay = 0
+			}
+			if i == 2 {
+				wantCode, wantStatus = 0, codes.Unset
+			}
+			assertAttr(t, span.Attributes(), AttemptKey, attribute.IntValue(i+1))
+			assertAttr(t, span.Attributes(), RetryDelayKey, attribute.Float64Value(wantDelay))
+			assertAttr(t, span.Attributes(), ExitCodeKey, attribute.IntValue(wantCode))
+			if span.Status().Code != wantStatus {
+				t.Errorf("span %d: got status %v, want %v", i, span.Status().Code, wantStatus)
+			}
+		}
+	})
+}
+
+func assertAttr(t *testing.T, attrs []attribute.KeyValue, key attribute.Key, want attribute.Value) {
+	t.Helper()
+	for _, kv := range attrs {
+		if kv.Key == key {
+			if kv.Value != want {
+				t.Errorf("attribute %s: got %v, want %v", key, kv.Value.Emit(), want.Emit())
+			}
+			return
+		}
+	}
+	t.Errorf("attribute %s: not found", key)
+}
@@ -0,0 +1,482 @@
+package wut
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"time"
+)
+
+// tailBuffer is an io.Writer retaining only the most recent bytes written to
+// it, up to a limit, along with a SHA-256 hash of all bytes written to it. It
+// is safe for concurrent use, as the stdout and stderr streams of a command
+// are written from separate goroutines.
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	buf   []byte
+	hash  hash.Hash
+	sum   []byte // scratch space for Sum
+}
+
+// newTailBuffer returns an empty tailBuffer with the given limit.
+func newTailBuffer(limit int) *tailBuffer {
+	return &tailBuffer{limit: limit, hash: sha256.New()}
+}
+
+func (tb *tailBuffer) Write(p []byte) (int, error) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.hash.Write(p)
+	n := len(p)
+	if len(p) >= tb.limit {
+		tb.buf = append(tb.buf[:0], p[len(p)-tb.limit:]...)
+		return n, nil
+	}
+	if excess := len(tb.buf) + len(p) - tb.limit; excess > 0 {
+		tb.buf = append(tb.buf[:0], tb.buf[excess:]...)
+	}
+	tb.buf = append(tb.buf, p...)
+	return n, nil
+}
+
+// Bytes returns a copy of the retained bytes.
+func (tb *tailBuffer) Bytes() []byte {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return append([]byte(nil), tb.buf...)
+}
+
+// Sum returns the SHA-256 hash of all bytes written, including any no longer
+// retained.
+func (tb *tailBuffer) Sum() (sum [sha256.Size]byte) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.sum = tb.hash.Sum(tb.sum[:0])
+	copy(sum[:], tb.sum)
+	return sum
+}
+
+// teeWriter returns a writer duplicating writes to w and capture, or just
+// capture if w is nil.
+func teeWriter(w io.Writer, capture io.Writer) io.Writer {
+	if w == nil {
+		return capture
+	}
+	return io.MultiWriter(w, capture)
+}
+
+// syncWriter is an io.Writer serializing writes to w, such as a writer shared
+// by the stdout and stderr of a command.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(p)
+}
+
+// idleWriter is an io.Writer discarding its input, which restarts timer to
+// fire after d on each write, detecting output from a command.
+type idleWriter struct {
+	timer Timer
+	d     time.Duration
+}
+
+func (w idleWriter) Write(p []byte) (int, error) {
+	w.timer.Reset(w.d)
+	return len(p), nil
+}
+
+// maxLineLength is the length beyond which a lineWriter emits a partial line,
+// bounding its memory usage for output without line breaks.
+const maxLineLength = 64 << 10
+
+// lineWriter is an io.Writer calling fn with each line written to it, without
+// its line ending. Any final unterminated line is passed to fn by Flush.
+type lineWriter struct {
+	mu  sync.Mutex
+	fn  func(line string)
+	buf []byte
+}
+
+func newLineWriter(fn func(line string)) *lineWriter {
+	return &lineWriter{fn: fn}
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			lw.buf = append(lw.buf, p...)
+			for len(lw.buf) >= maxLineLength {
+				lw.fn(string(lw.buf[:maxLineLength]))
+				lw.buf = append(lw.buf[:0], lw.buf[maxLineLength:]...)
+			}
+			break
+		}
+		lw.buf = append(lw.buf, p[:i]...)
+		lw.emit()
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// Flush passes any buffered unterminated line to fn.
+func (lw *lineWriter) Flush() {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if len(lw.buf) > 0 {
+		lw.emit()
+	}
+}
+
+// emit passes the buffered line to fn, trimming any carriage return.
+// The caller must hold lw.mu.
+func (lw *lineWriter) emit() {
+	lw.fn(string(bytes.TrimSuffix(lw.buf, []byte("\r"))))
+	lw.buf = lw.buf[:0]
+}
+
+// heldOutput holds back the lines written to the stdout and stderr of a
+// command, retaining only the most recent limit lines if limit is greater
+// than zero, so that they may be released to their destination writers
+// later, such as only if the command fails. It is safe for concurrent use.
+type heldOutput struct {
+	mu    sync.Mutex
+	limit int
+	lines []heldLine // ring buffer once limit lines are held
+	next  int        // index of the oldest line once the ring buffer is full
+
+	stdout, stderr       *lineWriter
+	stdoutDst, stderrDst io.Writer
+}
+
+type heldLine struct {
+	stderr bool
+	text   string
+}
+
+func newHeldOutput(limit int, stdout, stderr io.Writer) *heldOutput {
+	h := &heldOutput{limit: limit, stdoutDst: stdout, stderrDst: stderr}
+	h.stdout = newLineWriter(func(line string) { h.add(heldLine{text: line}) })
+	h.stderr = newLineWriter(func(line string) { h.add(heldLine{stderr: true, text: line}) })
+	return h
+}
+
+func (h *heldOutput) add(l heldLine) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.limit > 0 && len(h.lines) == h.limit {
+		h.lines[h.next] = l
+		h.next = (h.next + 1) % h.limit
+		return
+	}
+	h.lines = append(h.lines, l)
+}
+
+// Release flushes any unterminated lines, and writes all held lines in order
+// to the destination writer for the stream they were written to, skipping
+// those for a nil writer.
+func (h *heldOutput) Release() {
+	h.stdout.Flush()
+	h.stderr.Flush()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range h.lines {
+		l := h.lines[(h.next+i)%len(h.lines)]
+		w := h.stdoutDst
+		if l.stderr {
+			w = h.stderrDst
+		}
+		if w != nil {
+			io.WriteString(w, l.text+"\n")
+		}
+	}
+}
+
+// PrefixWriter is an io.Writer that writes a prefix before each line of the
+// output written through it, such as to distinguish the output of attempts.
+// It is not safe for concurrent use.
+type PrefixWriter struct {
+	w       io.Writer
+	prefix  func() string
+	midLine bool // whether the last write ended without a line ending
+}
+
+// NewPrefixWriter returns a PrefixWriter writing to w, prefixing each line
+// with prefix.
+func NewPrefixWriter(w io.Writer, prefix string) *PrefixWriter {
+	return &PrefixWriter{w: w, prefix: func() string { return prefix }}
+}
+
+// Write writes p to the underlying writer, inserting the prefix at the start
+// of each line.
+func (pw *PrefixWriter) Write(p []byte) (int, error) {
+	var buf []byte
+	for rest := p; len(rest) > 0; {
+		if !pw.midLine {
+			buf = append(buf, pw.prefix()...)
+		}
+		line, after, found := bytes.Cut(rest, []byte("\n"))
+		buf = append(buf, line...)
+		if found {
+			buf = append(buf, '\n')
+		}
+		pw.midLine, rest = !found, after
+	}
+	if _, err := pw.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// TimestampLayout is the layout used by a Runner for absolute timestamps, see
+// [Runner.OutputTimestamps].
+const TimestampLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// NewTimestampWriter returns a PrefixWriter writing to w, prefixing each line
+// with the time it was written formatted with layout, followed by a space,
+// similar to ts(1).
+func NewTimestampWriter(w io.Writer, layout string) *PrefixWriter {
+	return &PrefixWriter{w: w, prefix: func() string { return time.Now().Format(layout) + " " }}
+}
+
+// NewElapsedWriter returns a PrefixWriter writing to w, prefixing each line
+// with the time elapsed since the writer was created, such as "+1.234s ",
+// similar to ts -s.
+func NewElapsedWriter(w io.Writer) *PrefixWriter {
+	start := time.Now()
+	return &PrefixWriter{w: w, prefix: func() string {
+		return fmt.Sprintf("+%.3fs ", time.Since(start).Seconds())
+	}}
+}
+
+// Timestamps determines how lines of output are timestamped, see
+// [Runner.OutputTimestamps].
+type Timestamps int
+
+const (
+	TimestampsNone     Timestamps = iota // lines are not timestamped
+	TimestampsAbsolute                   // lines are prefixed with the time they were written, in TimestampLayout
+	TimestampsRelative                   // lines are prefixed with the time elapsed since the start of the attempt
+)
+
+func (ts Timestamps) String() string {
+	switch ts {
+	case TimestampsNone:
+		return "none"
+	case TimestampsAbsolute:
+		return "absolute"
+	case TimestampsRelative:
+		return "relative"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements [encoding.TextMarshaler], encoding ts as its String,
+// such as for a [Config].
+func (ts Timestamps) MarshalText() ([]byte, error) {
+	return []byte(ts.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler], decoding a value
+// encoded by MarshalText.
+func (ts *Timestamps) UnmarshalText(text []byte) error {
+	return unmarshalEnum(ts, text, TimestampsRelative)
+}
+
+// timestampWriter returns w decorated according to ts.
+func timestampWriter(w io.Writer, ts Timestamps) io.Writer {
+	switch {
+	case w == nil:
+		return nil
+	case ts == TimestampsAbsolute:
+		return NewTimestampWriter(w, TimestampLayout)
+	case ts == TimestampsRelative:
+		return NewElapsedWriter(w)
+	default:
+		return w
+	}
+}
+
+// LimitWriter is an io.Writer limiting the output written through it to a
+// maximum number of bytes and/or lines, preserving both its head and its tail.
+// The first half of the permitted output is written through immediately, and
+// the most recent second half is buffered, to be written by Flush along with
+// a marker noting how much output was omitted in between. It is not safe for
+// concurrent use.
+type LimitWriter struct {
+	w                    io.Writer
+	headBytes, headLines int // remaining head output to write through, or -1 if unlimited
+	tailBytes, tailLines int // tail output to retain, or 0 if unlimited
+	tail                 []byte
+	omitted              int64
+	midLine              bool // whether the head written so far ends without a line ending
+}
+
+// NewLimitWriter returns a LimitWriter writing to w, permitting at most
+// maxBytes bytes and maxLines lines of output, where zero means unlimited.
+func NewLimitWriter(w io.Writer, maxBytes, maxLines int) *LimitWriter {
+	lw := &LimitWriter{w: w, headBytes: -1, headLines: -1}
+	if maxBytes > 0 {
+		lw.headBytes = maxBytes - maxBytes/2
+		lw.tailBytes = max(maxBytes/2, 1)
+	}
+	if maxLines > 0 {
+		lw.headLines = maxLines - maxLines/2
+		lw.tailLines = max(maxLines/2, 1)
+	}
+	return lw
+}
+
+// Write writes the head of the output through to the underlying writer, and
+// buffers the remainder.
+func (lw *LimitWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if head := lw.headLen(p); head > 0 {
+		if _, err := lw.w.Write(p[:head]); err != nil {
+			return 0, err
+		}
+		lw.midLine = p[head-1] != '\n'
+		p = p[head:]
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+
+	lw.tail = append(lw.tail, p...)
+	keep := len(lw.tail)
+	if lw.tailBytes > 0 {
+		keep = min(keep, lw.tailBytes)
+	}
+	if lw.tailLines > 0 {
+		keep = min(keep, len(lastLines(lw.tail, lw.tailLines)))
+	}
+	if drop := len(lw.tail) - keep; drop > 0 {
+		lw.omitted += int64(drop)
+		lw.tail = append(lw.tail[:0], lw.tail[drop:]...)
+	}
+	return n, nil
+}
+
+// headLen returns the length of the prefix of p to write through as part of
+// the head of the output, updating the remaining head limits.
+func (lw *LimitWriter) headLen(p []byte) int {
+	if lw.headBytes == 0 || lw.headLines == 0 {
+		return 0
+	}
+	if lw.headBytes < 0 && lw.headLines < 0 {
+		return len(p)
+	}
+	head := len(p)
+	if lw.headBytes > 0 {
+		head = min(head, lw.headBytes)
+	}
+	if lw.headLines > 0 {
+		lines := 0
+		for i, c := range p[:head] {
+			if c == '\n' {
+				if lines++; lines == lw.headLines {
+					head = i + 1
+					break
+				}
+			}
+		}
+		lw.headLines -= lines
+	}
+	if lw.headBytes > 0 {
+		lw.headBytes -= head
+	}
+	return head
+}
+
+// lastLines returns the suffix of b containing at most its last n lines.
+func lastLines(b []byte, n int) []byte {
+	pos := len(b)
+	if pos > 0 && b[pos-1] == '\n' {
+		pos--
+	}
+	for range n {
+		i := bytes.LastIndexByte(b[:pos], '\n')
+		if i < 0 {
+			return b
+		}
+		pos = i
+	}
+	return b[pos+1:]
+}
+
+// Flush writes the buffered tail of the output to the underlying writer,
+// preceded by a marker if any output was omitted. It should be called once
+// all output has been written.
+func (lw *LimitWriter) Flush() error {
+	var buf []byte
+	if lw.omitted > 0 {
+		if lw.midLine {
+			buf = append(buf, '\n')
+		}
+		buf = fmt.Appendf(buf, "... [%d bytes omitted] ...\n", lw.omitted)
+	}
+	buf = append(buf, lw.tail...)
+	lw.tail, lw.omitted = lw.tail[:0], 0
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := lw.w.Write(buf)
+	return err
+}
+
+// OutputPolicy determines when the output of a command run by a Runner is
+// written, see [Runner.OutputPolicy].
+type OutputPolicy int
+
+const (
+	// OutputPassthrough writes output as it is produced.
+	OutputPassthrough OutputPolicy = iota
+	// OutputOnFailure holds back the output of each attempt, writing it only
+	// once the attempt has completed, if it failed.
+	OutputOnFailure
+	// OutputOnFinalFailure holds back the output of each attempt, writing
+	// only that of the final attempt, if the Runner stops without success.
+	// Like chronic(1), the command is silent unless something went wrong.
+	OutputOnFinalFailure
+)
+
+func (p OutputPolicy) String() string {
+	switch p {
+	case OutputPassthrough:
+		return "passthrough"
+	case OutputOnFailure:
+		return "on-failure"
+	case OutputOnFinalFailure:
+		return "on-final-failure"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements [encoding.TextMarshaler], encoding the policy as its
+// String, such as for a [Config].
+func (p OutputPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler], decoding a policy
+// encoded by MarshalText.
+func (p *OutputPolicy) UnmarshalText(text []byte) error {
+	return unmarshalEnum(p, text, OutputOnFinalFailure)
+}
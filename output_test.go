@@ -0,0 +1,156 @@
+package wut
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestTailBuffer(t *testing.T) {
+	tb := newTailBuffer(8)
+	for _, s := range []string{"abc", "defg", "hij"} {
+		tb.Write([]byte(s))
+	}
+	if got, want := string(tb.Bytes()), "cdefghij"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	tb.Write([]byte("0123456789"))
+	if got, want := string(tb.Bytes()), "23456789"; got != want {
+		t.Errorf("oversized write: got %q, want %q", got, want)
+	}
+	if got, want := tb.Sum(), sha256.Sum256([]byte("abcdefghij0123456789")); got != want {
+		t.Errorf("sum: got %x, want %x", got, want)
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	lw := newLineWriter(func(line string) { lines = append(lines, line) })
+	for _, s := range []string{"one\ntw", "o\r\n", "\nthree\nfo", "ur"} {
+		lw.Write([]byte(s))
+	}
+	lw.Flush()
+	if want := []string{"one", "two", "", "three", "four"}; !slices.Equal(lines, want) {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+
+	lines = nil
+	lw.Write([]byte(strings.Repeat("x", maxLineLength+1)))
+	lw.Flush()
+	if len(lines) != 2 || len(lines[0]) != maxLineLength {
+		t.Errorf("long line: got %d lines, want 2 with the first of maximum length", len(lines))
+	}
+}
+
+func TestHeldOutput(t *testing.T) {
+	var stdout, stderr strings.Builder
+	h := newHeldOutput(3, &stdout, &stderr)
+	h.stdout.Write([]byte("one\ntwo\n"))
+	h.stderr.Write([]byte("three\n"))
+	h.stdout.Write([]byte("four\nfive"))
+
+	h.Release()
+	if got, want := stdout.String(), "four\nfive\n"; got != want {
+		t.Errorf("stdout: got %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), "three\n"; got != want {
+		t.Errorf("stderr: got %q, want %q", got, want)
+	}
+
+	var combined strings.Builder
+	unlimited := newHeldOutput(0, &combined, &combined)
+	unlimited.stderr.Write([]byte("a\n"))
+	unlimited.stdout.Write([]byte("b\nc\n"))
+	unlimited.Release()
+	if got, want := combined.String(), "a\nb\nc\n"; got != want {
+		t.Errorf("unlimited: got %q, want %q", got, want)
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var buf strings.Builder
+	pw := NewPrefixWriter(&buf, "[run 3] ")
+	for _, s := range []string{"one\ntw", "o\n", "\nthree\n"} {
+		if n, err := pw.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	want := "[run 3] one\n[run 3] two\n[run 3] \n[run 3] three\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTimestampWriter(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var buf strings.Builder
+		tw := NewTimestampWriter(&buf, time.TimeOnly)
+		ew := NewElapsedWriter(&buf)
+		time.Sleep(90 * time.Minute)
+		tw.Write([]byte("one\n"))
+		time.Sleep(1234 * time.Millisecond)
+		ew.Write([]byte("two\n"))
+
+		want := fmt.Sprintf("%s one\n+5401.234s two\n", time.Now().Add(-1234*time.Millisecond).Format(time.TimeOnly))
+		if got := buf.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestLimitWriter(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxBytes, lines int
+		writes          []string
+		want            string
+	}{
+		{
+			name:     "within limit",
+			maxBytes: 100,
+			writes:   []string{"one\n", "two\n"},
+			want:     "one\ntwo\n",
+		},
+		{
+			name:     "bytes",
+			maxBytes: 8,
+			writes:   []string{"0123", "456789", "abcdef\n"},
+			want:     "0123\n... [9 bytes omitted] ...\ndef\n",
+		},
+		{
+			name:   "lines",
+			lines:  4,
+			writes: []string{"1\n2\n3\n", "4\n5\n6\n7\n"},
+			want:   "1\n2\n... [6 bytes omitted] ...\n6\n7\n",
+		},
+		{
+			name:     "bytes and lines",
+			maxBytes: 30,
+			lines:    2,
+			writes:   []string{"first line\nsecond line\nthird line\n"},
+			want:     "first line\n... [12 bytes omitted] ...\nthird line\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			lw := NewLimitWriter(&buf, tt.maxBytes, tt.lines)
+			for _, s := range tt.writes {
+				if n, err := lw.Write([]byte(s)); n != len(s) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", s, n, err)
+				}
+			}
+			if err := lw.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/mroth/wut"
+)
+
+// File is an Executor that succeeds if a file exists at a path, such as one
+// written by another process once it is ready.
+//
+// If Path is empty, the command name is used as the path and any arguments
+// are ignored.
+type File struct {
+	Path string // path of the file, defaults to the command name
+
+	// NonEmpty requires the file to be non-empty for success, for files
+	// which are created before they are written.
+	NonEmpty bool
+}
+
+// verify File implements the wut.Executor interface
+var _ wut.Executor = File{}
+
+func (p File) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	path := p.Path
+	if path == "" {
+		path = name
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if p.NonEmpty && fi.Size() == 0 {
+		return fmt.Errorf("probe: %s is empty", path)
+	}
+	return nil
+}
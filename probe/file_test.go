@@ -0,0 +1,33 @@
+package probe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mroth/wut"
+)
+
+func TestFile_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ready")
+	if err := (File{}).Run(t.Context(), wut.CommandOpts{}, path); err == nil {
+		t.Error("missing file: expected error, got nil")
+	}
+
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (File{}).Run(t.Context(), wut.CommandOpts{}, path); err != nil {
+		t.Errorf("empty file: unexpected error: %v", err)
+	}
+	if err := (File{Path: path, NonEmpty: true}).Run(t.Context(), wut.CommandOpts{}, "ignored"); err == nil {
+		t.Error("empty file via Path with NonEmpty: expected error, got nil")
+	}
+
+	if err := os.WriteFile(path, []byte("ok\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (File{NonEmpty: true}).Run(t.Context(), wut.CommandOpts{}, path); err != nil {
+		t.Errorf("non-empty file with NonEmpty: unexpected error: %v", err)
+	}
+}
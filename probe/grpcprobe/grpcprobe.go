@@ -0,0 +1,66 @@
+// Package grpcprobe provides a [wut.Executor] that checks the health of a gRPC
+// server using the standard gRPC health checking protocol (grpc.health.v1).
+//
+// It is provided as a separate module, github.com/mroth/wut/probe/grpcprobe,
+// so that users of package probe and the rest of wut do not need to depend on
+// gRPC.
+package grpcprobe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mroth/wut"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Health is an Executor that performs a gRPC health check, succeeding if the
+// server reports the service as SERVING.
+//
+// If Target is empty, the command name is used as the target and any arguments
+// are ignored. The reported serving status is written to the Stdout stream of
+// [wut.CommandOpts], if set.
+type Health struct {
+	Target  string // gRPC target, e.g. "localhost:50051", defaults to the command name
+	Service string // name of the service to check, or empty for the overall server health
+
+	// DialOptions are used when creating the client connection. If empty,
+	// insecure transport credentials (no TLS) are used.
+	DialOptions []grpc.DialOption
+}
+
+// verify Health implements the wut.Executor interface
+var _ wut.Executor = Health{}
+
+func (h Health) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	target := h.Target
+	if target == "" {
+		target = name
+	}
+	dialOpts := h.DialOptions
+	if len(dialOpts) == 0 {
+		dialOpts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: h.Service})
+	if err != nil {
+		return err
+	}
+	if opts.Stdout != nil {
+		if _, err := fmt.Fprintln(opts.Stdout, resp.GetStatus()); err != nil {
+			return err
+		}
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("grpcprobe: service status %s", resp.GetStatus())
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package grpcprobe
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/mroth/wut"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealth_Run(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("ready", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("starting", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+	addr := ln.Addr().String()
+
+	tests := []struct {
+		name    string
+		probe   Health
+		wantOut string
+		wantErr bool
+	}{
+		{name: "server health", probe: Health{}, wantOut: "SERVING\n"},
+		{name: "serving service", probe: Health{Service: "ready"}, wantOut: "SERVING\n"},
+		{name: "not serving service", probe: Health{Service: "starting"}, wantOut: "NOT_SERVING\n", wantErr: true},
+		{name: "unknown service", probe: Health{Service: "unknown"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := tt.probe.Run(t.Context(), wut.CommandOpts{Stdout: &stdout}, addr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error: got %v, want error %v", err, tt.wantErr)
+			}
+			if got := stdout.String(); got != tt.wantOut {
+				t.Errorf("stdout: got %q, want %q", got, tt.wantOut)
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		err := Health{Target: "127.0.0.1:1"}.Run(t.Context(), wut.CommandOpts{}, "ignored")
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
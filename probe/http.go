@@ -0,0 +1,102 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mroth/wut"
+)
+
+// HTTP is an Executor that performs an HTTP request, succeeding if the
+// response has an expected status code and body.
+//
+// If URL is empty, the command name is used as the URL and any arguments are
+// ignored. Up to the first MiB of the response body is read, which is written
+// to the Stdout stream of [wut.CommandOpts], if set, and matched against
+// ExpectBody.
+type HTTP struct {
+	Method string      // HTTP method, defaults to GET
+	URL    string      // URL to request, defaults to the command name
+	Header http.Header // additional request headers, optional
+	Body   string      // request body, optional
+
+	// ExpectStatus lists the status codes considered successful.
+	// If empty, any 2xx status code is considered successful.
+	ExpectStatus []int
+	// ExpectBody, if set, must match the response body for success.
+	ExpectBody *regexp.Regexp
+
+	// Client is used to perform the request, defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// maxHTTPBody is the limit on the number of bytes of a response body read by
+// the HTTP probe, so that an unexpectedly large or endless response cannot
+// exhaust memory. Any remainder is discarded unread.
+const maxHTTPBody = 1 << 20
+
+// verify HTTP implements the wut.Executor interface
+var _ wut.Executor = HTTP{}
+
+func (h HTTP) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	url := h.URL
+	if url == "" {
+		url = name
+	}
+	method := h.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var body io.Reader
+	if h.Body != "" {
+		body = strings.NewReader(h.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	for k, vs := range h.Header {
+		req.Header[k] = vs
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody))
+	if err != nil {
+		return err
+	}
+	if opts.Stdout != nil {
+		if _, err := opts.Stdout.Write(respBody); err != nil {
+			return err
+		}
+	}
+
+	if !h.statusOK(resp.StatusCode) {
+		return fmt.Errorf("probe: unexpected HTTP status %s", resp.Status)
+	}
+	if h.ExpectBody != nil && !h.ExpectBody.Match(respBody) {
+		return fmt.Errorf("probe: HTTP response body does not match %q", h.ExpectBody)
+	}
+	return nil
+}
+
+func (h HTTP) statusOK(code int) bool {
+	if len(h.ExpectStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	return slices.Contains(h.ExpectStatus, code)
+}
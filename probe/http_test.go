@@ -0,0 +1,78 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+func TestHTTP_Run(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("status: ok"))
+		case "/teapot":
+			w.WriteHeader(http.StatusTeapot)
+		case "/method":
+			w.Write([]byte(r.Method + " " + r.Header.Get("X-Test")))
+		case "/large":
+			w.Write(bytes.Repeat([]byte("x"), maxHTTPBody+1))
+		case "/slow":
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name    string
+		probe   HTTP
+		cmd     string
+		wantOut string
+		wantErr bool
+	}{
+		{name: "url from command", cmd: srv.URL + "/ok", wantOut: "status: ok"},
+		{name: "url from field", probe: HTTP{URL: srv.URL + "/ok"}, cmd: "ignored", wantOut: "status: ok"},
+		{name: "not found", cmd: srv.URL + "/missing", wantOut: "404 page not found\n", wantErr: true},
+		{name: "expected status", probe: HTTP{ExpectStatus: []int{418}}, cmd: srv.URL + "/teapot"},
+		{name: "unexpected status", probe: HTTP{ExpectStatus: []int{200}}, cmd: srv.URL + "/teapot", wantErr: true},
+		{name: "body matches", probe: HTTP{ExpectBody: regexp.MustCompile("ok$")}, cmd: srv.URL + "/ok", wantOut: "status: ok"},
+		{name: "body mismatch", probe: HTTP{ExpectBody: regexp.MustCompile("ready")}, cmd: srv.URL + "/ok", wantOut: "status: ok", wantErr: true},
+		{name: "body truncated", cmd: srv.URL + "/large", wantOut: string(bytes.Repeat([]byte("x"), maxHTTPBody))},
+		{
+			name:    "method and headers",
+			probe:   HTTP{Method: http.MethodPost, Header: http.Header{"X-Test": {"yes"}}},
+			cmd:     srv.URL + "/method",
+			wantOut: "POST yes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := tt.probe.Run(t.Context(), wut.CommandOpts{Stdout: &stdout}, tt.cmd)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error: got %v, want error %v", err, tt.wantErr)
+			}
+			if got := stdout.String(); got != tt.wantOut {
+				t.Errorf("stdout: got %q, want %q", got, tt.wantOut)
+			}
+		})
+	}
+
+	t.Run("context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		err := HTTP{}.Run(ctx, wut.CommandOpts{}, srv.URL+"/slow")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}
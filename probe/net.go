@@ -0,0 +1,75 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/mroth/wut"
+)
+
+// TCP is an Executor that succeeds if a TCP connection can be established to
+// an address.
+//
+// If Addr is empty, the command name is used as the address and any arguments
+// are ignored. The connection is closed immediately once established.
+type TCP struct {
+	Addr string // address in host:port form, defaults to the command name
+}
+
+// verify TCP implements the wut.Executor interface
+var _ wut.Executor = TCP{}
+
+func (p TCP) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	addr := p.Addr
+	if addr == "" {
+		addr = name
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// DNS is an Executor that succeeds if a host name resolves to at least one
+// address.
+//
+// If Host is empty, the command name is used as the host name and any
+// arguments are ignored. The resolved addresses are written one per line to
+// the Stdout stream of [wut.CommandOpts], if set.
+type DNS struct {
+	Host string // host name to resolve, defaults to the command name
+
+	// Resolver is used to perform the lookup, defaults to net.DefaultResolver.
+	Resolver *net.Resolver
+}
+
+// verify DNS implements the wut.Executor interface
+var _ wut.Executor = DNS{}
+
+func (p DNS) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	host := p.Host
+	if host == "" {
+		host = name
+	}
+	resolver := p.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	addrs, err := resolver.LookupHost(ctx, host)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("probe: no addresses found for %s", host)
+	}
+	if opts.Stdout != nil {
+		_, err = fmt.Fprintln(opts.Stdout, strings.Join(addrs, "\n"))
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package probe
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/mroth/wut"
+)
+
+func TestTCP_Run(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	if err := (TCP{}).Run(t.Context(), wut.CommandOpts{}, addr); err != nil {
+		t.Errorf("listening port: unexpected error: %v", err)
+	}
+	if err := (TCP{Addr: addr}).Run(t.Context(), wut.CommandOpts{}, "ignored"); err != nil {
+		t.Errorf("listening port via Addr: unexpected error: %v", err)
+	}
+
+	ln.Close()
+	if err := (TCP{}).Run(t.Context(), wut.CommandOpts{}, addr); err == nil {
+		t.Error("closed port: expected error, got nil")
+	}
+}
+
+func TestDNS_Run(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := (DNS{}).Run(t.Context(), wut.CommandOpts{Stdout: &stdout}, "localhost"); err != nil {
+		t.Fatalf("localhost: unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "127.0.0.1") && !strings.Contains(stdout.String(), "::1") {
+		t.Errorf("localhost: got addresses %q, want loopback", stdout.String())
+	}
+
+	if err := (DNS{Host: "nonexistent.invalid"}).Run(t.Context(), wut.CommandOpts{}, "ignored"); err == nil {
+		t.Error("invalid host: expected error, got nil")
+	}
+}
@@ -0,0 +1,7 @@
+// Package probe provides [wut.Executor] implementations that check the
+// availability of network services, or other resources such as files,
+// natively, without running a subprocess.
+//
+// Probes allow a Runner to be used as a wait-for or health-check tool, for
+// example to wait until a service is accepting requests before proceeding.
+package probe
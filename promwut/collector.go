@@ -0,0 +1,108 @@
+// Package promwut exposes the health of a [wut.Runner] as Prometheus metrics.
+//
+// It is published as a module of its own, github.com/mroth/wut/promwut, so
+// that the Prometheus client library is only a dependency of its users.
+package promwut
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mroth/wut"
+)
+
+// Collector is a [prometheus.Collector] and [wut.Metrics] adapter exposing the
+// following metrics about the attempts made by a Runner, optionally prefixed
+// by a namespace:
+//
+//   - runs_total: a counter of attempts at executing the command, by result
+//     ("success" or "failure")
+//   - run_duration_seconds: a histogram of attempt durations
+//   - consecutive_failures: the number of failed attempts since the last
+//     successful one
+//   - last_success_timestamp_seconds: the Unix time the last successful
+//     attempt completed, or 0 if there has been none
+type Collector struct {
+	runsTotal           *prometheus.CounterVec
+	runDuration         prometheus.Histogram
+	consecutiveFailures prometheus.Gauge
+	lastSuccess         prometheus.Gauge
+}
+
+// verify Collector implements the prometheus.Collector and wut.Metrics interfaces
+var (
+	_ prometheus.Collector = (*Collector)(nil)
+	_ wut.Metrics          = (*Collector)(nil)
+)
+
+// NewCollector returns a Collector, whose metrics all carry constLabels, which
+// may be nil. Provide it to a Runner with [wut.Runner.SetMetrics], and
+// register it with a [prometheus.Registerer].
+func NewCollector(namespace string, constLabels prometheus.Labels) *Collector {
+	c := &Collector{
+		runsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "runs_total",
+			Help:        "Number of attempts at executing the command, by result.",
+			ConstLabels: constLabels,
+		}, []string{"result"}),
+		runDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "run_duration_seconds",
+			Help:        "Duration of attempts at executing the command.",
+			ConstLabels: constLabels,
+		}),
+		consecutiveFailures: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "consecutive_failures",
+			Help:        "Number of failed attempts since the last successful attempt.",
+			ConstLabels: constLabels,
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "last_success_timestamp_seconds",
+			Help:        "Unix time the last successful attempt completed.",
+			ConstLabels: constLabels,
+		}),
+	}
+	// initialize both results so that they are exported before any attempts
+	c.runsTotal.WithLabelValues("success")
+	c.runsTotal.WithLabelValues("failure")
+	return c
+}
+
+// IncAttempt implements [wut.Metrics].
+func (c *Collector) IncAttempt(result wut.AttemptResult) {
+	c.runsTotal.WithLabelValues(string(result)).Inc()
+	if result == wut.AttemptFailure {
+		c.consecutiveFailures.Inc()
+		return
+	}
+	c.consecutiveFailures.Set(0)
+	c.lastSuccess.SetToCurrentTime()
+}
+
+// ObserveDuration implements [wut.Metrics].
+func (c *Collector) ObserveDuration(d time.Duration) {
+	c.runDuration.Observe(d.Seconds())
+}
+
+// SetState implements [wut.Metrics]. The state of the Runner is not exported.
+func (c *Collector) SetState(wut.RunnerState) {}
+
+// Describe implements [prometheus.Collector].
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.runsTotal.Describe(ch)
+	c.runDuration.Describe(ch)
+	c.consecutiveFailures.Describe(ch)
+	c.lastSuccess.Describe(ch)
+}
+
+// Collect implements [prometheus.Collector].
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.runsTotal.Collect(ch)
+	c.runDuration.Collect(ch)
+	c.consecutiveFailures.Collect(ch)
+	c.lastSuccess.Collect(ch)
+}
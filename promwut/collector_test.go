@@ -0,0 +1,54 @@
+package promwut
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/mroth/wut"
+	"github.com/mroth/wut/wuttest"
+)
+
+func TestCollector(t *testing.T) {
+	r := wut.NewRunner(t.Context(), "cmd")
+	r.SetLogger(slog.New(slog.DiscardHandler))
+	r.SetExecutor(wuttest.NewExecutor(
+		wuttest.Outcome{},
+		wuttest.Outcome{ExitCode: 1},
+		wuttest.Outcome{ExitCode: 1},
+	))
+	r.ContinueOnSuccess = true
+	r.MaxRuns = 3
+
+	c := NewCollector("wut", prometheus.Labels{"job": "test"})
+	r.SetMetrics(c)
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+	start := time.Now()
+	r.Run()
+
+	want := `
+# HELP wut_consecutive_failures Number of failed attempts since the last successful attempt.
+# TYPE wut_consecutive_failures gauge
+wut_consecutive_failures{job="test"} 2
+# HELP wut_runs_total Number of attempts at executing the command, by result.
+# TYPE wut_runs_total counter
+wut_runs_total{job="test",result="failure"} 2
+wut_runs_total{job="test",result="success"} 1
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"wut_consecutive_failures", "wut_runs_total")
+	if err != nil {
+		t.Error(err)
+	}
+	if ts := testutil.ToFloat64(c.lastSuccess); ts < float64(start.Unix()) {
+		t.Errorf("last_success_timestamp_seconds: got %v, want at least %v", ts, start.Unix())
+	}
+	if n := testutil.CollectAndCount(c, "wut_run_duration_seconds"); n != 1 {
+		t.Errorf("run_duration_seconds: got %d metrics, want 1", n)
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/mroth/wut/ptyexec
+
+go 1.25.0
+
+require (
+	github.com/creack/pty v1.1.24
+	github.com/mroth/wut v0.0.0
+)
+
+require golang.org/x/sys v0.47.0 // indirect
+
+replace github.com/mroth/wut => ..
@@ -0,0 +1,124 @@
+// Package ptyexec provides a [wut.Executor] that runs commands attached to a
+// pseudo-terminal.
+//
+// Many commands change their behavior when their output is not a terminal,
+// for example by disabling progress bars, prompts, or colored output. Running
+// them under a pseudo-terminal allows them to behave as they would when run
+// interactively. Pseudo-terminals are not supported on Windows.
+//
+// The package is a separate module, github.com/mroth/wut/ptyexec, so that
+// only its users depend on github.com/creack/pty.
+package ptyexec
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/mroth/wut"
+)
+
+// Executor runs commands in a local subprocess attached to a pseudo-terminal.
+//
+// As the child's stdout and stderr are both attached to the same terminal,
+// all output is written to the Stdout stream of [wut.CommandOpts], and the
+// Stderr stream is unused. Output is written as the terminal presents it, so
+// line endings will typically be translated to "\r\n".
+//
+// Any Stdin stream is copied to the terminal until the command exits. As a
+// pending Read cannot be interrupted unless the stream supports read
+// deadlines, as pipes and network connections do, other streams may have
+// one more Read performed on them once Run returns, whose data is discarded.
+type Executor struct {
+	Rows, Cols uint16 // terminal size, defaults to 24x80 if unset
+}
+
+// verify Executor implements the wut.Executor interface
+var _ wut.Executor = Executor{}
+
+func (e Executor) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = opts.Env
+	cmd.Dir = opts.Dir
+	cmd.WaitDelay = opts.WaitDelay
+	if cmd.WaitDelay == 0 {
+		cmd.WaitDelay = wut.DefaultWaitDelay
+	} else if cmd.WaitDelay < 0 {
+		cmd.WaitDelay = 0 // wait indefinitely
+	}
+	if opts.Cancel != nil {
+		cmd.Cancel = opts.Cancel // not safe to set to nil
+	}
+
+	size := &pty.Winsize{Rows: e.Rows, Cols: e.Cols}
+	if size.Rows == 0 || size.Cols == 0 {
+		size.Rows, size.Cols = 24, 80
+	}
+	ptmx, err := pty.StartWithSize(cmd, size)
+	if err != nil {
+		return err
+	}
+	defer ptmx.Close()
+
+	stopInput := func() {}
+	if opts.Stdin != nil {
+		stopInput = copyInput(ptmx, opts.Stdin)
+	}
+	stdout := opts.Stdout
+	if stdout == nil {
+		stdout = io.Discard // output must be drained for the child to progress
+	}
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(stdout, ptmx) // returns once all handles to the terminal are closed
+		close(copied)
+	}()
+
+	err = cmd.Wait()
+	stopInput()
+	// Drain any remaining output, unless descendants of the command are holding
+	// the terminal open after the run was cancelled.
+	select {
+	case <-copied:
+	case <-ctx.Done():
+	}
+	return err
+}
+
+// copyInput copies from r to the terminal in the background, until stopped by
+// the returned function. If r supports read deadlines, a pending Read is
+// interrupted, and copying has ended once the function returns. Otherwise,
+// copying ends once the pending Read returns, discarding what it read.
+func copyInput(ptmx io.Writer, r io.Reader) (stop func()) {
+	var stopped atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(buf)
+			if stopped.Load() {
+				return
+			}
+			if n > 0 {
+				if _, err := ptmx.Write(buf[:n]); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return func() {
+		stopped.Store(true)
+		d, ok := r.(interface{ SetReadDeadline(time.Time) error })
+		if ok && d.SetReadDeadline(time.Now()) == nil {
+			<-done
+			d.SetReadDeadline(time.Time{})
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package ptyexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+func TestExecutor_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("pseudo-terminals are not supported on windows")
+	}
+
+	t.Run("output is a terminal", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := Executor{}.Run(t.Context(), wut.CommandOpts{Stdout: &stdout},
+			"sh", "-c", "test -t 0 && test -t 1 && test -t 2 && stty size")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, want := strings.TrimSpace(stdout.String()), "24 80"; got != want {
+			t.Errorf("stdout: got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		err := Executor{}.Run(t.Context(), wut.CommandOpts{}, "sh", "-c", "exit 3")
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+			t.Errorf("got %v, want exit code 3", err)
+		}
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pr.Close()
+		defer pw.Close()
+
+		var stdout bytes.Buffer
+		opts := wut.CommandOpts{Stdin: pr, Stdout: &stdout}
+		pw.Write([]byte("hello\n"))
+		err = Executor{}.Run(t.Context(), opts, "sh", "-c", "read line && echo \"got $line\"")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := stdout.String(); !strings.Contains(got, "got hello") {
+			t.Errorf("stdout: got %q, want it to contain %q", got, "got hello")
+		}
+
+		// once Run returns, stdin is no longer read from
+		pw.Write([]byte("next\n"))
+		pw.Close()
+		if got, err := io.ReadAll(pr); err != nil || string(got) != "next\n" {
+			t.Errorf("remaining stdin: got %q, %v; want %q", got, err, "next\n")
+		}
+	})
+
+	t.Run("context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		err := Executor{}.Run(ctx, wut.CommandOpts{}, "sleep", "10")
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package wut
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets redacted by a [Redactor].
+const Redacted = "[REDACTED]"
+
+// Redactor replaces secrets, such as tokens passed to a command, with
+// [Redacted] to prevent them from leaking into logs and captured output.
+type Redactor struct {
+	Values   []string         // literal secret values
+	Patterns []*regexp.Regexp // patterns matching secrets
+	EnvVars  []string         // names of environment variables whose values are secret
+}
+
+// Redact returns s with all secrets replaced. The values of EnvVars are taken
+// from the environment of the current process.
+func (rd *Redactor) Redact(s string) string {
+	return rd.redact(s, nil)
+}
+
+// redact returns s with all secrets replaced, taking the values of EnvVars
+// from env, a list of "key=value" pairs as in [CommandOpts.Env], or from the
+// environment of the current process if not present in env.
+func (rd *Redactor) redact(s string, env []string) string {
+	values := rd.Values
+	for _, name := range rd.EnvVars {
+		if v := lookupEnv(env, name); v != "" {
+			values = append(values[:len(values):len(values)], v)
+		}
+	}
+	for _, v := range values {
+		if v != "" {
+			s = strings.ReplaceAll(s, v, Redacted)
+		}
+	}
+	for _, re := range rd.Patterns {
+		s = re.ReplaceAllLiteralString(s, Redacted)
+	}
+	return s
+}
+
+// lookupEnv returns the value of the named variable in env, falling back to
+// the environment of the current process if not present.
+func lookupEnv(env []string, name string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if v, ok := strings.CutPrefix(env[i], name+"="); ok {
+			return v
+		}
+	}
+	return os.Getenv(name)
+}
@@ -0,0 +1,60 @@
+package wut
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/synctest"
+)
+
+func TestRedactor(t *testing.T) {
+	t.Setenv("WUT_TEST_TOKEN", "s3cret")
+	rd := &Redactor{
+		Values:   []string{"hunter2", ""},
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`ghp_[A-Za-z0-9]+`)},
+		EnvVars:  []string{"WUT_TEST_TOKEN", "WUT_TEST_UNSET"},
+	}
+
+	got := rd.Redact("pass=hunter2 token=s3cret gh=ghp_abc123 ok")
+	if want := "pass=[REDACTED] token=[REDACTED] gh=[REDACTED] ok"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// variables set for the command take precedence
+	got = rd.redact("s3cret other", []string{"WUT_TEST_TOKEN=other"})
+	if want := "s3cret [REDACTED]"; got != want {
+		t.Errorf("with env: got %q, want %q", got, want)
+	}
+}
+
+func TestRunner_Redactor(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "curl", "-H", "Authorization: Bearer tok123")
+		r.SetExecutor(mockExecutor{output: "echo tok123", exitcode: 1})
+		r.MaxRuns = 1
+		r.CaptureLimit = 1024
+		r.Redactor = &Redactor{Values: []string{"tok123"}}
+
+		var buf bytes.Buffer
+		r.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+		var output []byte
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptEnd {
+				output = e.Attempt.Output
+			}
+		})
+		r.Run()
+
+		if strings.Contains(buf.String(), "tok123") {
+			t.Errorf("secret in log:\n%s", buf.String())
+		}
+		if !strings.Contains(buf.String(), "Bearer [REDACTED]") {
+			t.Errorf("redacted args missing from log:\n%s", buf.String())
+		}
+		if got, want := string(output), "echo [REDACTED]"; got != want {
+			t.Errorf("captured output: got %q, want %q", got, want)
+		}
+	})
+}
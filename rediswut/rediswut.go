@@ -0,0 +1,223 @@
+// Package rediswut provides a [wut.Locker] backed by a Redis lease, so that
+// only one node in a fleet executes a job supervised by a [wut.Runner] at a
+// time.
+//
+// It is a minimal implementation of the single instance locking pattern
+// described in the Redis documentation, speaking the Redis protocol directly
+// rather than depending on a client library, and serves as an example for
+// implementations backed by other coordination services, such as etcd.
+package rediswut
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// ErrLeaseLost is returned by Release if the lease expired or was taken over
+// while the lock was held, such that it may not have been exclusive.
+var ErrLeaseLost = errors.New("rediswut: lease lost while held")
+
+const (
+	defaultTTL   = 30 * time.Second
+	pollInterval = time.Second
+)
+
+// Scripts run atomically by Redis, which only renew or release the lease if
+// it is still held with the token of this Lock.
+const (
+	renewScript   = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`
+	releaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+)
+
+// Lock is a [wut.Locker] holding a lease on a key in Redis. The lease expires
+// after TTL unless renewed, which is done every third of TTL while the lock is
+// held, so that the lock is released even if its holder crashes.
+type Lock struct {
+	Addr     string        // address of the Redis server, such as "localhost:6379"
+	Key      string        // key holding the lease
+	Password string        // password to authenticate with, if any
+	TTL      time.Duration // duration of the lease, or 30s if zero
+
+	// Wait determines whether Acquire waits for a lease held elsewhere to be
+	// released, rather than failing immediately with wut.ErrLocked.
+	Wait bool
+
+	mu    sync.Mutex // guards conn, and its use by the renewal goroutine
+	conn  *conn
+	token string
+	stop  chan struct{}
+	done  chan struct{}
+	lost  bool
+}
+
+// verify Lock implements the wut.Locker interface
+var _ wut.Locker = (*Lock)(nil)
+
+// NewLock returns a Lock on key at the Redis server at addr.
+func NewLock(addr, key string) *Lock {
+	return &Lock{Addr: addr, Key: key}
+}
+
+func (l *Lock) ttl() time.Duration {
+	if l.TTL <= 0 {
+		return defaultTTL
+	}
+	return l.TTL
+}
+
+// Acquire implements [wut.Locker].
+func (l *Lock) Acquire(ctx context.Context) error {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", l.Addr)
+	if err != nil {
+		return err
+	}
+	c := newConn(nc)
+	if l.Password != "" {
+		if _, err := c.do("AUTH", l.Password); err != nil {
+			c.Close()
+			return err
+		}
+	}
+
+	token := make([]byte, 16)
+	rand.Read(token)
+	l.token = hex.EncodeToString(token)
+	ttl := strconv.FormatInt(l.ttl().Milliseconds(), 10)
+	for {
+		reply, err := c.do("SET", l.Key, l.token, "NX", "PX", ttl)
+		if err != nil {
+			c.Close()
+			return err
+		}
+		if reply != nil {
+			break
+		}
+		if !l.Wait {
+			c.Close()
+			return wut.ErrLocked
+		}
+		select {
+		case <-ctx.Done():
+			c.Close()
+			return context.Cause(ctx)
+		case <-time.After(pollInterval):
+		}
+	}
+
+	l.conn, l.lost = c, false
+	l.stop, l.done = make(chan struct{}), make(chan struct{})
+	go l.renew()
+	return nil
+}
+
+// renew extends the lease every third of its TTL until stopped.
+func (l *Lock) renew() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl() / 3)
+	defer ticker.Stop()
+	ttl := strconv.FormatInt(l.ttl().Milliseconds(), 10)
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+		l.mu.Lock()
+		reply, err := l.conn.do("EVAL", renewScript, "1", l.Key, l.token, ttl)
+		if err == nil && reply == int64(0) {
+			l.lost = true
+		}
+		l.mu.Unlock()
+	}
+}
+
+// Release implements [wut.Locker]. It returns ErrLeaseLost if the lease was
+// lost while held.
+func (l *Lock) Release() error {
+	if l.conn == nil {
+		return nil
+	}
+	close(l.stop)
+	<-l.done
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	reply, err := l.conn.do("EVAL", releaseScript, "1", l.Key, l.token)
+	if err == nil && (l.lost || reply == int64(0)) {
+		err = ErrLeaseLost
+	}
+	err = errors.Join(err, l.conn.Close())
+	l.conn = nil
+	return err
+}
+
+// conn is a connection to a Redis server speaking RESP2.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func newConn(nc net.Conn) *conn {
+	return &conn{Conn: nc, r: bufio.NewReader(nc)}
+}
+
+// do sends a command, and returns its reply, which is a string, an int64, or
+// nil. Error replies are returned as errors.
+func (c *conn) do(args ...string) (any, error) {
+	buf := fmt.Appendf(nil, "*%d\r\n", len(args))
+	for _, arg := range args {
+		buf = fmt.Appendf(buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := c.Write(buf); err != nil {
+		return nil, err
+	}
+
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("rediswut: %s", line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	default:
+		return nil, fmt.Errorf("rediswut: unexpected reply %q", line)
+	}
+}
+
+// readLine reads a line of a reply without its line ending.
+func (c *conn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("rediswut: malformed reply %q", line)
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,154 @@
+package rediswut
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// fakeRedis is a Redis server supporting only the commands used by Lock.
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+	expiry map[string]time.Time
+}
+
+func startFakeRedis(t *testing.T) (*fakeRedis, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	fr := &fakeRedis{values: make(map[string]string), expiry: make(map[string]time.Time)}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go fr.serve(c)
+		}
+	}()
+	return fr, ln.Addr().String()
+}
+
+func (fr *fakeRedis) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	for {
+		var n int
+		if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+			return
+		}
+		args := make([]string, n)
+		for i := range args {
+			var size int
+			if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+				return
+			}
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			args[i] = string(data[:size])
+		}
+		io.WriteString(c, fr.exec(args))
+	}
+}
+
+// exec executes a command, returning its encoded reply.
+func (fr *fakeRedis) exec(args []string) string {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	get := func(key string) (string, bool) {
+		if exp, ok := fr.expiry[key]; ok && time.Now().After(exp) {
+			delete(fr.values, key)
+			delete(fr.expiry, key)
+		}
+		v, ok := fr.values[key]
+		return v, ok
+	}
+	switch {
+	case args[0] == "SET" && len(args) == 6: // key value NX PX ms
+		if _, ok := get(args[1]); ok {
+			return "$-1\r\n"
+		}
+		ms, _ := strconv.Atoi(args[5])
+		fr.values[args[1]] = args[2]
+		fr.expiry[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		return "+OK\r\n"
+	case args[0] == "EVAL" && args[1] == renewScript:
+		if v, _ := get(args[3]); v != args[4] {
+			return ":0\r\n"
+		}
+		ms, _ := strconv.Atoi(args[5])
+		fr.expiry[args[3]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		return ":1\r\n"
+	case args[0] == "EVAL" && args[1] == releaseScript:
+		if v, _ := get(args[3]); v != args[4] {
+			return ":0\r\n"
+		}
+		delete(fr.values, args[3])
+		return ":1\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func TestLock(t *testing.T) {
+	_, addr := startFakeRedis(t)
+
+	held := NewLock(addr, "job")
+	held.TTL = 150 * time.Millisecond
+	if err := held.Acquire(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the lease is renewed beyond its TTL while held
+	time.Sleep(2 * held.TTL)
+	other := NewLock(addr, "job")
+	if err := other.Acquire(t.Context()); !errors.Is(err, wut.ErrLocked) {
+		t.Errorf("got %v, want %v", err, wut.ErrLocked)
+	}
+
+	other.Wait = true
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	if err := other.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if err := held.Release(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := other.Acquire(t.Context()); err != nil {
+		t.Fatalf("unexpected error after release: %v", err)
+	}
+	if err := other.Release(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLock_lost(t *testing.T) {
+	fr, addr := startFakeRedis(t)
+
+	l := NewLock(addr, "job")
+	if err := l.Acquire(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fr.mu.Lock()
+	fr.values["job"] = "someone else"
+	fr.mu.Unlock()
+	if err := l.Release(); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("got %v, want %v", err, ErrLeaseLost)
+	}
+}
@@ -0,0 +1,212 @@
+// Package replay provides [wut.Executor] implementations for recording command
+// invocations and replaying them deterministically.
+//
+// A [Recorder] wraps a real Executor, writing an [Entry] describing every
+// invocation and its result as a line of JSON. A [Player] reads those entries
+// back and reproduces the recorded output, timing, and results without running
+// any commands, making integration tests of wut-based tooling reproducible.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// Entry records a single command invocation and its result.
+type Entry struct {
+	Time     time.Time     `json:"time"`
+	Name     string        `json:"name"`
+	Args     []string      `json:"args,omitempty"`
+	Env      []string      `json:"env,omitempty"`
+	Dir      string        `json:"dir,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+	ExitCode int           `json:"exit_code"`       // exit code of the command, or -1 if it did not exit normally
+	Error    string        `json:"error,omitempty"` // error message for a failed invocation
+}
+
+// A Recorder is an Executor that records every invocation of an underlying
+// Executor as a line of JSON written to an io.Writer.
+//
+// Note that the environment of each invocation is recorded, so care should be
+// taken to not record sensitive values.
+type Recorder struct {
+	executor wut.Executor
+	mu       sync.Mutex // guards w
+	w        io.Writer
+}
+
+// verify Recorder implements the wut.Executor interface
+var _ wut.Executor = (*Recorder)(nil)
+
+// NewRecorder creates a Recorder that runs commands with executor, writing
+// entries to w. If executor is nil, [wut.CmdExecutor] is used.
+func NewRecorder(w io.Writer, executor wut.Executor) *Recorder {
+	if executor == nil {
+		executor = wut.CmdExecutor{}
+	}
+	return &Recorder{executor: executor, w: w}
+}
+
+func (r *Recorder) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	var stdout, stderr bytes.Buffer
+	runOpts := opts
+	runOpts.Stdout = tee(opts.Stdout, &stdout)
+	runOpts.Stderr = tee(opts.Stderr, &stderr)
+
+	start := time.Now()
+	err := r.executor.Run(ctx, runOpts, name, args...)
+	entry := Entry{
+		Time:     start,
+		Name:     name,
+		Args:     args,
+		Env:      opts.Env,
+		Dir:      opts.Dir,
+		Duration: time.Since(start),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: exitCode(err),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	data, merr := json.Marshal(entry)
+	if merr != nil {
+		return errors.Join(err, merr)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, werr := r.w.Write(append(data, '\n')); werr != nil {
+		return errors.Join(err, fmt.Errorf("replay: recording entry: %w", werr))
+	}
+	return err
+}
+
+func tee(w io.Writer, buf *bytes.Buffer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(w, buf)
+}
+
+func exitCode(err error) int {
+	var coder interface{ ExitCode() int } // such as *exec.ExitError, or an *Error being recorded again
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &coder):
+		return coder.ExitCode()
+	default:
+		return -1
+	}
+}
+
+// ErrExhausted is returned by a Player when all recorded entries have been
+// replayed.
+var ErrExhausted = errors.New("replay: recorded entries exhausted")
+
+// Error is returned by a Player when replaying a failed invocation.
+type Error struct {
+	Code    int    // recorded exit code
+	Message string // recorded error message
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// ExitCode returns the recorded exit code, mirroring [os/exec.ExitError], so
+// that it is reported by [wut.Attempt.ExitCode].
+func (e *Error) ExitCode() int { return e.Code }
+
+// A Player is an Executor that replays invocations previously recorded by a
+// Recorder, in order.
+//
+// Each run writes the recorded output to the provided streams, waits for the
+// recorded duration (or until the context is done), and returns the recorded
+// result as an *Error. No commands are executed.
+type Player struct {
+	// Strict causes a run to fail if the invoked command name and arguments
+	// differ from those recorded.
+	Strict bool
+
+	mu      sync.Mutex // guards entries
+	entries []Entry
+}
+
+// verify Player implements the wut.Executor interface
+var _ wut.Executor = (*Player)(nil)
+
+// NewPlayer creates a Player from the entries read from r.
+func NewPlayer(r io.Reader) (*Player, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("replay: parsing entry %d: %w", len(entries)+1, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &Player{entries: entries}, nil
+}
+
+// Remaining returns the number of recorded entries not yet replayed.
+func (p *Player) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+func (p *Player) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	p.mu.Lock()
+	if len(p.entries) == 0 {
+		p.mu.Unlock()
+		return ErrExhausted
+	}
+	e := p.entries[0]
+	p.entries = p.entries[1:]
+	p.mu.Unlock()
+
+	if p.Strict && (name != e.Name || !slices.Equal(args, e.Args)) {
+		return fmt.Errorf("replay: got command %q %q, recorded %q %q", name, args, e.Name, e.Args)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(e.Duration):
+	}
+
+	if opts.Stdout != nil && e.Stdout != "" {
+		if _, err := io.WriteString(opts.Stdout, e.Stdout); err != nil {
+			return err
+		}
+	}
+	if opts.Stderr != nil && e.Stderr != "" {
+		if _, err := io.WriteString(opts.Stderr, e.Stderr); err != nil {
+			return err
+		}
+	}
+	if e.Error != "" {
+		return &Error{Code: e.ExitCode, Message: e.Error}
+	}
+	return nil
+}
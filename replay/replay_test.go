@@ -0,0 +1,117 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+func TestRecordReplay(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands require a POSIX shell")
+	}
+
+	// record a successful and a failed invocation
+	var log bytes.Buffer
+	rec := NewRecorder(&log, nil)
+
+	var stdout bytes.Buffer
+	if err := rec.Run(t.Context(), wut.CommandOpts{Stdout: &stdout}, "echo", "hello"); err != nil {
+		t.Fatalf("recording: unexpected error: %v", err)
+	}
+	if got := stdout.String(); got != "hello\n" {
+		t.Errorf("recording: stdout passthrough: got %q, want %q", got, "hello\n")
+	}
+	if err := rec.Run(t.Context(), wut.CommandOpts{}, "sh", "-c", "echo oops >&2; exit 3"); err == nil {
+		t.Fatal("recording: expected error, got nil")
+	}
+
+	// replay them in order
+	p, err := NewPlayer(&log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Strict = true
+	if got := p.Remaining(); got != 2 {
+		t.Fatalf("remaining: got %d, want 2", got)
+	}
+
+	stdout.Reset()
+	if err := p.Run(t.Context(), wut.CommandOpts{Stdout: &stdout}, "echo", "hello"); err != nil {
+		t.Errorf("replay 1: unexpected error: %v", err)
+	}
+	if got := stdout.String(); got != "hello\n" {
+		t.Errorf("replay 1: stdout: got %q, want %q", got, "hello\n")
+	}
+
+	var stderr bytes.Buffer
+	err = p.Run(t.Context(), wut.CommandOpts{Stderr: &stderr}, "sh", "-c", "echo oops >&2; exit 3")
+	var replayErr *Error
+	if !errors.As(err, &replayErr) || replayErr.ExitCode() != 3 {
+		t.Errorf("replay 2: got %v, want exit code 3", err)
+	}
+	if got := stderr.String(); got != "oops\n" {
+		t.Errorf("replay 2: stderr: got %q, want %q", got, "oops\n")
+	}
+
+	if err := p.Run(t.Context(), wut.CommandOpts{}, "echo"); !errors.Is(err, ErrExhausted) {
+		t.Errorf("replay 3: got %v, want %v", err, ErrExhausted)
+	}
+}
+
+func TestPlayer_Run(t *testing.T) {
+	const log = `{"name":"slow","duration":1000000000,"exit_code":0}
+{"name":"slow","duration":1000000000,"exit_code":0}
+`
+
+	t.Run("strict mismatch", func(t *testing.T) {
+		p, _ := NewPlayer(bytes.NewBufferString(log))
+		p.Strict = true
+		if err := p.Run(t.Context(), wut.CommandOpts{}, "fast"); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		p, _ := NewPlayer(bytes.NewBufferString(`{"name":"fail","exit_code":3,"error":"exit status 3"}` + "\n"))
+		r := wut.NewRunner(t.Context(), "fail")
+		r.SetExecutor(p)
+		r.MaxRuns = 1
+		var code int
+		r.Observe(func(e wut.Event) {
+			if e.Kind == wut.EventAttemptEnd {
+				code = e.Attempt.ExitCode()
+			}
+		})
+		r.Run()
+		if code != 3 {
+			t.Errorf("attempt exit code: got %d, want 3", code)
+		}
+	})
+
+	t.Run("recorded duration", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			p, _ := NewPlayer(bytes.NewBufferString(log))
+
+			start := time.Now()
+			if err := p.Run(t.Context(), wut.CommandOpts{}, "slow"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed != time.Second {
+				t.Errorf("elapsed: got %v, want %v", elapsed, time.Second)
+			}
+
+			ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+			defer cancel()
+			if err := p.Run(ctx, wut.CommandOpts{}, "slow"); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+			}
+		})
+	})
+}
@@ -0,0 +1,118 @@
+package wut
+
+import (
+	"errors"
+	"time"
+)
+
+// Resources constrains the system resources available to a command run by
+// [CmdExecutor]. The zero value imposes no constraints.
+//
+// Constraints are applied immediately after the command has started, so
+// there is a brief window during which the command runs unconstrained.
+type Resources struct {
+	// Nice adjusts the scheduling priority of the command, from -20 (most
+	// favorable) to 19 (least favorable); see setpriority(2). Lowering
+	// niceness below that of the current process typically requires
+	// privileges. Only supported on Unix.
+	Nice int
+
+	// IOClass and IOLevel set the I/O scheduling class and priority level of
+	// the command, with levels ranging from 0 (highest) to 7 (lowest) for the
+	// realtime and best-effort classes; see ioprio_set(2). Only supported on
+	// Linux.
+	IOClass IOClass
+	IOLevel int
+
+	// Rlimits sets resource limits on the command; see setrlimit(2). Only
+	// supported on Linux.
+	Rlimits []Rlimit
+
+	// MemoryMax and CPUMax, if set, confine each run of the command and all
+	// its descendants to a transient cgroup limiting its memory usage in bytes
+	// and its CPU usage as a number of CPUs (e.g. 0.5 for half of one CPU).
+	// If the command is killed for exceeding its memory limit, the error
+	// returned for the run will match [ErrOOMKilled]. Unlike other constraints,
+	// these apply from the moment the command starts. Only supported on Linux
+	// with cgroup v2.
+	MemoryMax int64
+	CPUMax    float64
+
+	// CgroupParent is the directory of the cgroup under which transient
+	// cgroups are created, which must be writable and have the memory and cpu
+	// controllers enabled in its cgroup.subtree_control. Defaults to the root
+	// cgroup at /sys/fs/cgroup, which typically requires root privileges; a
+	// cgroup delegated to the current user can be used instead.
+	CgroupParent string
+
+	// MaxRSS, if set, kills the command if the combined resident set size of
+	// it and its descendants exceeds this many bytes, as sampled every
+	// RSSPollInterval (default 250ms). The error returned for the run will
+	// then match [ErrOOMKilled]. As memory usage is only sampled, short-lived
+	// spikes may go unnoticed; prefer MemoryMax where cgroups are available.
+	// Only supported on Unix.
+	MaxRSS          int64
+	RSSPollInterval time.Duration
+}
+
+// isZero reports whether r imposes no constraints to be applied after the
+// command has started.
+func (r Resources) isZero() bool {
+	return r.Nice == 0 && r.IOClass == IOClassNone && len(r.Rlimits) == 0
+}
+
+// usesCgroup reports whether r requires the command to run in a cgroup.
+func (r Resources) usesCgroup() bool {
+	return r.MemoryMax > 0 || r.CPUMax > 0
+}
+
+// ErrOOMKilled is matched by the error of a run that was killed for exceeding
+// its memory limit, see [Resources.MemoryMax] and [Resources.MaxRSS].
+var ErrOOMKilled = errors.New("wut: command killed for exceeding memory limit")
+
+// IOClass is an I/O scheduling class, see ioprio_set(2).
+type IOClass int
+
+const (
+	IOClassNone       IOClass = iota // no I/O class set, the command inherits that of its parent
+	IOClassRealtime                  // realtime class, requires privileges
+	IOClassBestEffort                // best-effort class, the default for most processes
+	IOClassIdle                      // idle class, only performing I/O when no other process needs it
+)
+
+func (c IOClass) String() string {
+	switch c {
+	case IOClassNone:
+		return "none"
+	case IOClassRealtime:
+		return "realtime"
+	case IOClassBestEffort:
+		return "best-effort"
+	case IOClassIdle:
+		return "idle"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements [encoding.TextMarshaler], encoding the class as its
+// String, such as for a [Config].
+func (c IOClass) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler], decoding a class
+// encoded by MarshalText.
+func (c *IOClass) UnmarshalText(text []byte) error {
+	return unmarshalEnum(c, text, IOClassIdle)
+}
+
+// Rlimit is a resource limit applied to a command, see setrlimit(2).
+type Rlimit struct {
+	// Resource is the resource to limit, such as syscall.RLIMIT_NOFILE,
+	// syscall.RLIMIT_CPU, or syscall.RLIMIT_FSIZE.
+	Resource int
+	// Cur and Max are the soft and hard limits. If Max is zero, it is set to
+	// the value of Cur.
+	Cur, Max uint64
+}
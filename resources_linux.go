@@ -0,0 +1,39 @@
+package wut
+
+import (
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// constants for ioprio_set(2), not provided by x/sys/unix
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+)
+
+// applyResources applies resource constraints to the running process pid.
+func applyResources(pid int, res Resources) error {
+	if res.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, res.Nice); err != nil {
+			return fmt.Errorf("wut: setting niceness: %w", err)
+		}
+	}
+	if res.IOClass != IOClassNone {
+		prio := uintptr(res.IOClass)<<ioprioClassShift | uintptr(res.IOLevel)
+		if _, _, errno := syscall.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), prio); errno != 0 {
+			return fmt.Errorf("wut: setting I/O priority: %w", errno)
+		}
+	}
+	for _, rl := range res.Rlimits {
+		lim := unix.Rlimit{Cur: rl.Cur, Max: rl.Max}
+		if lim.Max == 0 {
+			lim.Max = lim.Cur
+		}
+		if err := unix.Prlimit(pid, rl.Resource, &lim, nil); err != nil {
+			return fmt.Errorf("wut: setting resource limit %d: %w", rl.Resource, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package wut
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestCmdExecutor_Resources(t *testing.T) {
+	// The command reports its constraints after a delay, so that they have
+	// been applied by the time it does so.
+	const script = "sleep 0.2; echo $(nice) $(ulimit -n)"
+
+	var stdout bytes.Buffer
+	opts := CommandOpts{
+		Stdout: &stdout,
+		Resources: Resources{
+			Nice:    7,
+			IOClass: IOClassIdle,
+			Rlimits: []Rlimit{{Resource: syscall.RLIMIT_NOFILE, Cur: 64}},
+		},
+	}
+	if err := (CmdExecutor{}).Run(t.Context(), opts, "sh", "-c", script); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := strings.TrimSpace(stdout.String()), "7 64"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := exec.LookPath("ionice"); err == nil {
+		stdout.Reset()
+		err := CmdExecutor{}.Run(t.Context(), opts, "sh", "-c", "sleep 0.2; ionice -p $$")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := strings.TrimSpace(stdout.String()); got != "idle" {
+			t.Errorf("ionice: got %q, want %q", got, "idle")
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package wut
+
+import "errors"
+
+// applyResources is not supported on this platform.
+func applyResources(pid int, res Resources) error {
+	return errors.New("wut: resource constraints are not supported on this platform")
+}
@@ -0,0 +1,20 @@
+//go:build unix && !linux
+
+package wut
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// applyResources applies resource constraints to the running process pid.
+func applyResources(pid int, res Resources) error {
+	if res.IOClass != IOClassNone || len(res.Rlimits) > 0 {
+		return errors.New("wut: I/O priority and resource limits are only supported on Linux")
+	}
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, res.Nice); err != nil {
+		return fmt.Errorf("wut: setting niceness: %w", err)
+	}
+	return nil
+}
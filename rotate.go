@@ -0,0 +1,108 @@
+package wut
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is an io.WriteCloser appending to a file, which is rotated once
+// writing to it would exceed a maximum size, so that long-lived Runners can
+// keep durable logs of their output without external log rotation.
+//
+// On rotation, the file is renamed with a ".1" suffix, any previous backups
+// are shifted to ".2", ".3", and so on, and backups beyond the retention limit
+// are removed. RotatingFile is safe for concurrent use.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenRotatingFile opens the file at path for appending, creating it if
+// necessary, rotating it whenever writing to it would exceed maxSize bytes,
+// and retaining at most maxBackups rotated files. A maxSize of zero or less
+// disables rotation.
+func OpenRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	rf := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.f, rf.size = f, info.Size()
+	return nil
+}
+
+// Write appends p to the file, first rotating it if p would cause it to
+// exceed its maximum size. A single write larger than the maximum size is
+// written in full to a new file.
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.f == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate closes the current file, shifts it and its backups, and opens a new
+// file. The caller must hold rf.mu.
+func (rf *RotatingFile) rotate() error {
+	if err := rf.f.Close(); err != nil {
+		return err
+	}
+	rf.f = nil
+
+	backup := func(n int) string { return fmt.Sprintf("%s.%d", rf.path, n) }
+	os.Remove(backup(rf.maxBackups))
+	for n := rf.maxBackups - 1; n >= 1; n-- {
+		os.Rename(backup(n), backup(n+1))
+	}
+	var err error
+	if rf.maxBackups > 0 {
+		err = os.Rename(rf.path, backup(1))
+	} else {
+		err = os.Remove(rf.path)
+	}
+	if err != nil {
+		return err
+	}
+	return rf.open()
+}
+
+// Close closes the file.
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.f == nil {
+		return os.ErrClosed
+	}
+	err := rf.f.Close()
+	rf.f = nil
+	return err
+}
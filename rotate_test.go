@@ -0,0 +1,47 @@
+package wut
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.log")
+	os.WriteFile(path, []byte("old\n"), 0o644)
+
+	rf, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n", "eeee\n", "ffff\n"} {
+		if _, err := rf.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"out.log":   "ffff\n",
+		"out.log.1": "dddd\neeee\n",
+		"out.log.2": "bbbb\ncccc\n",
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != len(want) {
+		t.Errorf("got %d files, want %d", len(entries), len(want))
+	}
+	for name, content := range want {
+		got, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if string(got) != content {
+			t.Errorf("%s: got %q, want %q", name, got, content)
+		}
+	}
+
+	if _, err := rf.Write([]byte("x")); err == nil {
+		t.Error("write after close: expected error, got nil")
+	}
+}
@@ -3,26 +3,108 @@ package wut
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Runner manages the repeated execution of commands with retry and timeout capabilities.
+//
+// The configuration of a Runner, both its fields and that set by its methods,
+// is snapshotted when Run is called, so changes to fields made while it is
+// running take effect only from the next call to Run, and the setters panic.
 type Runner struct {
 	name    string
 	args    []string
+	path    string // name as resolved by Run, see resolvePath
 	baseCtx context.Context
 
 	// ProcessTimeout is the timeout duration for individual command run execution.
 	// If a command execution does not complete within this duration, it will be cancelled.
 	ProcessTimeout time.Duration
 
+	// IdleTimeout, if set, cancels an attempt which has written no output
+	// for this long, so that a hung command is retried.
+	IdleTimeout time.Duration
+
+	// HedgeDelay, if set, starts a duplicate copy of the command concurrently
+	// should an attempt not have completed after this long, such as for
+	// network requests with occasional high latency. The first copy to
+	// succeed determines the outcome of the attempt, and the other is
+	// cancelled; should both fail, the attempt fails with the error of the
+	// last. The output of each copy is held until it completes, so that only
+	// that of the copy determining the outcome is written, and only the first
+	// copy is given Stdin. Only the final 1MiB of the output of each copy is
+	// held, or more if OutputMaxBytes or CaptureLimit is larger. As output is
+	// seen by IdleTimeout and UntilOutput only once a copy has completed,
+	// HedgeDelay must not be combined with them, and [NewRunnerFromConfig]
+	// rejects a Config doing so.
+	HedgeDelay time.Duration
+
 	// RetryDelay is the delay between retries of the command execution.
 	RetryDelay time.Duration
 
+	// Backoff is the strategy by which the delay grows from RetryDelay over
+	// consecutive failed attempts, and BackoffFactor the factor by which it
+	// grows, for the strategies which use one. The delay returns to
+	// RetryDelay following a successful attempt. By default, the delay is
+	// fixed.
+	Backoff       Backoff
+	BackoffFactor float64
+
+	// Delays, if set, is an explicit schedule of delays between retries over
+	// consecutive failed attempts, taking precedence over RetryDelay and
+	// Backoff. Its final delay is repeated once the schedule is exhausted,
+	// and it restarts from the first following a successful attempt.
+	Delays []time.Duration
+
+	// MaxDelay, if set, caps the delay between retries chosen by Backoff or
+	// Delays, prior to any Jitter.
+	MaxDelay time.Duration
+
+	// Interval, if set, starts attempts at a fixed rate rather than RetryDelay
+	// after the end of the previous attempt. Start times are computed as
+	// multiples of Interval from the start of the first attempt, so that they
+	// do not drift over many attempts, and any missed by an attempt running
+	// longer than Interval are skipped.
+	Interval time.Duration
+
+	// StartAt, if set, delays the first attempt until the given time. The
+	// attempts following it are delayed as usual.
+	StartAt time.Time
+
+	// Splay, if set, delays the first attempt by a random duration of up to
+	// Splay, after any StartAt, so that many Runners started at the same time,
+	// such as on a schedule across a fleet of hosts, do not run in lockstep.
+	Splay time.Duration
+
+	// DrainTimeout, if set, permits an attempt running when the context of the
+	// Runner is done to continue for up to this long to finish cleanly, rather
+	// than being cancelled immediately. No further attempts are started. An
+	// attempt still running after DrainTimeout is cancelled, and reported as
+	// [Attempt.Interrupted].
+	DrainTimeout time.Duration
+
+	// Jitter is the maximum random duration added to each retry delay.
+	// Randomizing delays avoids many Runners retrying in lockstep.
+	// See [Runner.SetRandSource] to control the source of randomness.
+	Jitter time.Duration
+
+	// JitterFraction is the maximum random fraction of each retry delay added
+	// to it, such as 0.1 for up to 10%, in addition to any Jitter.
+	JitterFraction float64
+
 	// MaxRuns is the maximum number of times the command will be executed before the Runner stops.
 	// If MaxRuns is set to 0, there will be no cap on the number of times the command can be run,
 	// prior to the Runner encountering another stop condition.
@@ -31,13 +113,225 @@ type Runner struct {
 	// ContinueOnSuccess allows the Runner to continue executing commands even after a successful run.
 	ContinueOnSuccess bool
 
+	// ConsecutiveSuccesses, if greater than one, is the number of successful
+	// attempts in a row required for the Runner to succeed, such as for a
+	// health check gate requiring sustained success. A failed attempt starts
+	// the count over.
+	ConsecutiveSuccesses uint
+
+	// UntilFailure inverts the Runner, so that it executes the command
+	// repeatedly until it fails, such as to reproduce a flaky test, and then
+	// stops with an error wrapping ErrAttemptFailed. Should MaxRuns be
+	// reached first, Run returns nil, as the command never failed.
+	UntilFailure bool
+
+	// MinUptime, if set, is how long an attempt must run before failing for
+	// it to be considered to have been healthy, as for a service which runs
+	// until it eventually fails. The retry following such an attempt is not
+	// backed off further, but delayed as after a first failure, and it does
+	// not count towards CrashLoopLimit.
+	MinUptime time.Duration
+
+	// CrashLoopLimit, if greater than zero, stops the Runner with an error
+	// wrapping ErrCrashLoop once this many consecutive attempts have failed
+	// within MinUptime of starting, or failed at all if MinUptime is unset,
+	// so that a command which cannot start is not restarted indefinitely.
+	CrashLoopLimit uint
+
+	// SuccessCodes are nonzero exit codes which are treated as success, in
+	// addition to 0, such as 1 for diff(1) reporting differences. An attempt
+	// exiting with one of them has its Err cleared, so that it is reported
+	// as a success.
+	SuccessCodes []int
+
+	// UntilOutput, if set, determines the success of each attempt by its
+	// output rather than its exit status, for polling commands which always
+	// exit successfully: an attempt succeeds if any line of its output
+	// matches UntilOutput, even if it fails or exceeds ProcessTimeout, and
+	// otherwise fails, with ErrOutputNotMatched if it exited successfully.
+	UntilOutput *regexp.Regexp
+
+	// Verify, if set, is called after each attempt which succeeded, to check
+	// that it had the intended effect, such as by running a second command,
+	// so that the attempt fails, wrapping ErrVerifyFailed, if it returns an
+	// error. It is called with the context of the Runner.
+	Verify func(ctx context.Context) error
+
+	// FailOnOutput, if set, is a pattern matching output which indicates a
+	// permanent failure, such as "permission denied", so that an attempt
+	// with any line of output matching it fails with ErrFatalOutput, even if
+	// it exited successfully, and stops the Runner rather than retrying.
+	FailOnOutput *regexp.Regexp
+
+	// AbortCodes are exit codes which indicate a permanent failure, such as
+	// 127 for a command not found by the shell, so that an attempt exiting
+	// with one of them stops the Runner immediately, rather than retrying,
+	// with an error wrapping ErrPermanentFailure.
+	AbortCodes []int
+
+	// RetryCodes, if set, are the only exit codes with which a failed attempt
+	// is retried, such as 75 (EX_TEMPFAIL), so that an attempt failing in any
+	// other way, other than by exceeding ProcessTimeout or IdleTimeout, stops
+	// the Runner as if it had exited with one of AbortCodes.
+	RetryCodes []int
+
+	// RetryTimeoutsOnly, if set, retries only attempts which exceeded
+	// ProcessTimeout or IdleTimeout, along with any exiting with one of
+	// RetryCodes, for commands which may hang transiently but whose errors
+	// are permanent.
+	RetryTimeoutsOnly bool
+
 	// CommandOptions are options for the underlying process command execution.
 	CommandOptions CommandOpts
 
-	runlock       sync.Mutex // locked when a command is running
-	runsCompleted uint
-	executor      executor
-	logger        *slog.Logger
+	// CaptureLimit, if greater than zero, enables capturing the combined
+	// stdout and stderr output of each attempt into [Attempt.Output], which is
+	// made available to observers, along with a diff against the output of
+	// the previous attempt in [Attempt.OutputDiff]. Only the final CaptureLimit
+	// bytes of output are retained. Output is captured in addition to being
+	// written to any writers configured in CommandOptions.
+	//
+	// When enabled, the output of failed attempts is also logged, with output
+	// identical to the previous failure logged as a compact repetition count.
+	CaptureLimit int
+
+	// OutputPolicy determines when the output of the command is written to
+	// the Stdout and Stderr writers configured in CommandOptions. By default,
+	// output is passed through as it is produced.
+	OutputPolicy OutputPolicy
+
+	// OutputTailLines, if greater than zero, limits the output held back by
+	// an OutputPolicy other than OutputPassthrough to a ring buffer of the
+	// most recent lines of each attempt, so that only the end of the output
+	// of a verbose command is written.
+	OutputTailLines int
+
+	// OutputPrefix, if set, is a format string prefixed to each line of
+	// output written to the Stdout and Stderr writers configured in
+	// CommandOptions, formatted with the attempt number, such as "[run %d] ".
+	OutputPrefix string
+
+	// OutputTee, if set, receives the combined output of every attempt as it
+	// is produced, regardless of OutputPolicy and other output options, such
+	// as to keep a durable log of it in a [RotatingFile]. It must be safe for
+	// concurrent use, as stdout and stderr are written from separate
+	// goroutines.
+	OutputTee io.Writer
+
+	// OutputMaxBytes and OutputMaxLines, if greater than zero, limit the
+	// output of each attempt written to each of the Stdout and Stderr writers
+	// configured in CommandOptions, preserving its head and tail, so that a
+	// runaway attempt cannot flood its destination. See [LimitWriter].
+	OutputMaxBytes int
+	OutputMaxLines int
+
+	// OutputTimestamps, if set, prefixes each line of output written to the
+	// Stdout and Stderr writers configured in CommandOptions with the time it
+	// was produced, either absolute or relative to the start of the attempt.
+	OutputTimestamps Timestamps
+
+	// OutputStripANSI, if set, removes ANSI escape sequences, such as colors,
+	// from the output written to OutputTee, captured in [Attempt.Output], and
+	// logged by LogStdout and LogStderr, so that logs and event payloads stay
+	// clean. Output written to the Stdout and Stderr writers configured in
+	// CommandOptions is left untouched.
+	OutputStripANSI bool
+
+	// LogStdout and LogStderr, if set, log each line the command writes to
+	// stdout and stderr respectively as a record at the given level with the
+	// Runner's logger, so that its output lands in the same structured
+	// pipeline as the Runner's own logs. Output is logged in addition to
+	// being written to any writers configured in CommandOptions.
+	LogStdout slog.Leveler
+	LogStderr slog.Leveler
+
+	// LogJSONOutput, if set, logs each line of output logged by LogStdout and
+	// LogStderr which is a JSON object as a structured record, with its
+	// fields as attributes alongside those of the attempt, rather than as an
+	// opaque string. Its msg or message field becomes the message of the
+	// record, and a valid level field its level, while its time field is
+	// discarded in favor of the time of the record.
+	LogJSONOutput bool
+
+	// Redactor, if set, redacts secrets from the attributes of records logged
+	// by the Runner, including the command's arguments and any logged output,
+	// and from the output captured in [Attempt.Output]. The values of its
+	// EnvVars are taken from CommandOptions.Env, falling back to the
+	// environment of the current process.
+	Redactor *Redactor
+
+	// StateFile, if set, is the path of a file to which the Runner persists
+	// the number of attempts it has made after each one, so that a Runner
+	// restarted mid-campaign, such as after a reboot, resumes from where it
+	// left off: attempts continue to be numbered and counted towards MaxRuns
+	// from the previous total, and any retry delay is measured from the end
+	// of the previous attempt. The file is removed once the Runner succeeds
+	// or reaches MaxRuns, but kept if it is stopped via its context.
+	StateFile string
+
+	// Lock, if set, is acquired by Run before executing the command, and
+	// released once it stops, so that only one instance of a job runs at a
+	// time. If it cannot be acquired, Run returns the error from Acquire,
+	// such as ErrLocked.
+	Lock Locker
+
+	// LockFile, if set and Lock is not, is the path of a [FileLock] which the
+	// Runner holds while running, so that only one instance of a job runs on
+	// a host at a time. If the lock is held by another instance, Run waits for
+	// it if LockWait is set, or otherwise returns ErrLocked.
+	LockFile string
+	LockWait bool
+
+	// SuccessMarker, if set, is the path of a file recording the time of the
+	// last success of the Runner, so that it can skip work which was done
+	// recently. While the marker is fresh, Run returns nil immediately without
+	// executing the command. The marker is fresh for SuccessTTL after being
+	// written, or indefinitely if SuccessTTL is zero.
+	SuccessMarker string
+	SuccessTTL    time.Duration
+
+	// LogAttrs are added to every record logged by the Runner, such as a job
+	// name or labels identifying it.
+	LogAttrs []slog.Attr
+
+	// LogGroup, if set, nests the attributes of each record logged by the
+	// Runner, other than LogAttrs, in a group with this name.
+	LogGroup string
+
+	// LogKeys renames the attributes of records logged by the Runner, mapping
+	// a default key, such as "error" or "attempts", to its replacement.
+	LogKeys map[string]string
+
+	shared       *sharedState // execution state shared with the snapshot taken by Run
+	executor     Executor
+	logger       *slog.Logger
+	clock        Clock
+	rand         *rand.Rand // nil uses the top-level math/rand/v2 functions
+	observers    []func(Event)
+	metrics      Metrics
+	prevOutput   []byte            // captured output of the previous attempt
+	prevSum      [sha256.Size]byte // OutputSum of the previous attempt
+	failSum      [sha256.Size]byte // OutputSum of the previous failed attempt
+	failRepeats  int               // consecutive failed attempts with output identical to that of failSum
+	resumedAfter time.Time         // end of the last attempt recorded by the StateFile, until the next delay
+	anchor       time.Time         // start of the first attempt, from which Interval schedules the others
+	failures     uint              // consecutive failed attempts, for Backoff
+	successes    uint              // consecutive successful attempts, for ConsecutiveSuccesses
+	crashes      uint              // consecutive attempts failing within MinUptime, for CrashLoopLimit
+	prevDelay    time.Duration     // previous delay chosen by Backoff, prior to Jitter
+	finalOutput  *heldOutput       // held output of the previous attempt if it failed, for OutputOnFinalFailure
+	kickC        chan struct{}     // signals to skip the current retry delay
+	resumeC      chan struct{}     // signals that the Runner may have been resumed
+}
+
+// sharedState is the execution state of a Runner which may be accessed from
+// other goroutines while it is running.
+type sharedState struct {
+	running       atomic.Bool   // set for the duration of Run
+	runsCompleted atomic.Uint64 // attempts completed
+	paused        atomic.Bool   // set by Pause, cleared by Resume
+	state         atomic.Int32  // current RunnerState
+	randlock      sync.Mutex    // guards the rand of the Runner
 }
 
 // CommandOpts provides options to configure the execution of [exec.Cmd] commands.
@@ -52,12 +346,79 @@ type CommandOpts struct {
 	Stdout    io.Writer     // standard output for Cmd execution, see https://pkg.go.dev/os/exec#Cmd.Stdout
 	Stderr    io.Writer     // standard error for Cmd execution, see https://pkg.go.dev/os/exec#Cmd.Stderr
 	Cancel    func() error  // cancel function for Cmd processeses, see https://pkg.go.dev/os/exec#Cmd.Cancel
-	WaitDelay time.Duration // wait delay for Cmd processeses, see https://pkg.go.dev/os/exec#Cmd.WaitDelay
+	WaitDelay time.Duration // wait delay for Cmd processeses, see https://pkg.go.dev/os/exec#Cmd.WaitDelay and DefaultWaitDelay
+
+	// ProcessGroup runs the command in its own process group (or on Windows,
+	// Job Object), and kills the entire group when the command is cancelled,
+	// so that any processes it has spawned are not left behind. If Cancel is
+	// also set, it takes precedence in determining how the command is
+	// cancelled. Only supported on Unix and Windows.
+	ProcessGroup bool
+
+	// GracePeriod, if greater than zero, enables graceful termination: when
+	// the command is cancelled, it is first sent StopSignal (SIGTERM if nil),
+	// and only killed if it has not exited after the grace period, allowing
+	// it to flush output and clean up. If ProcessGroup is set, the signals are
+	// sent to the entire process group. If Cancel is also set, it takes
+	// precedence in determining how the command is cancelled. Only supported
+	// on Unix; elsewhere the command is always killed immediately.
+	GracePeriod time.Duration
+	StopSignal  os.Signal
+
+	// ParentDeathSignal, if set, is sent to the command should the process
+	// running the Runner exit, such as when it is killed abruptly, preventing
+	// the command from being left running as an orphan (see PR_SET_PDEATHSIG
+	// in prctl(2)). Only supported on Linux and FreeBSD; elsewhere the command
+	// fails to start.
+	ParentDeathSignal os.Signal
+
+	// User and Group, if set, run the command with the credentials of the
+	// given user and group, each specified by name or numeric id, allowing a
+	// privileged process to drop privileges for the command. If Group is not
+	// set, the primary and supplementary groups of User are used. Doing so
+	// typically requires root privileges. Only supported on Unix.
+	User  string
+	Group string
+
+	// Resources constrains the system resources available to the command,
+	// such as its scheduling priority and resource limits.
+	Resources Resources
+
+	// Orphans determines whether to look for processes left running in the
+	// command's process group once it has exited, such as background
+	// children it spawned but never waited for, and whether to kill them.
+	// Any found are passed to OnOrphans, and recorded in [Attempt.Orphans]
+	// by the Runner. Requires ProcessGroup, and is only supported on Unix.
+	Orphans   OrphanPolicy
+	OnOrphans func(pids []int)
 }
 
+// Errors wrapping the error of an attempt interrupted by its context, such as
+// in [Attempt.Err], identifying why it was interrupted. For ErrRunnerStopped
+// and ErrRunnerDeadline, the cause of the context of the Runner is wrapped too.
+var (
+	ErrProcessTimeout = errors.New("wut: process timeout exceeded") // the attempt exceeded Runner.ProcessTimeout
+	ErrIdleTimeout    = errors.New("wut: idle timeout exceeded")    // the attempt wrote no output for Runner.IdleTimeout
+	ErrRunnerStopped  = errors.New("wut: runner stopped")           // the context of the Runner was canceled
+	ErrRunnerDeadline = errors.New("wut: runner deadline exceeded") // the deadline of the context of the Runner passed
+)
+
+// ErrAttemptFailed is wrapped by the error returned by Run when it stops
+// following a failed attempt under Runner.UntilFailure, along with the error
+// of the attempt.
+var ErrAttemptFailed = errors.New("wut: attempt failed")
+
+// ErrCrashLoop is wrapped by the error returned by Run when it stops on
+// reaching Runner.CrashLoopLimit, along with the error of the last attempt.
+var ErrCrashLoop = errors.New("wut: crash loop detected")
+
+// ErrVerifyFailed is wrapped by the error of an attempt which succeeded, but
+// whose Runner.Verify returned an error, along with that error.
+var ErrVerifyFailed = errors.New("wut: verification failed")
+
 var (
-	errMaxRunsCompleted = errors.New("wut: maximum number of runs completed")
-	// errRedundantStartCall = errors.New("wut: runner already started")
+	errMaxRunsCompleted   = errors.New("wut: maximum number of runs completed")
+	errRedundantStartCall = errors.New("wut: runner already started")
 	// errRedundantWaitCall  = errors.New("wut: runner already waiting for completion")
 )
 
@@ -71,14 +432,56 @@ func NewRunner(ctx context.Context, name string, arg ...string) *Runner {
 		name:     name,
 		args:     arg,
 		baseCtx:  ctx,
-		executor: cmdExecutor{},
+		executor: CmdExecutor{},
 		logger:   slog.New(slog.DiscardHandler),
+		clock:    realClock{},
+		metrics:  noopMetrics{},
+		kickC:    make(chan struct{}, 1),
+		resumeC:  make(chan struct{}, 1),
+		shared:   &sharedState{},
+	}
+}
+
+// NewShellRunner creates a new Runner instance that executes the provided
+// script through the system shell using a [ShellExecutor].
+//
+// This is a convenience for creating a Runner with [NewRunner] and setting its
+// executor to a ShellExecutor, and permits the use of shell features such as
+// pipelines and redirects in the command, e.g. "curl -s localhost | grep OK".
+func NewShellRunner(ctx context.Context, script string) *Runner {
+	r := NewRunner(ctx, script)
+	r.SetExecutor(ShellExecutor{})
+	return r
+}
+
+// derive creates a new Runner with the provided context, sharing the command
+// and configuration of r, but none of its execution state, including its
+// StateFile, locks, and SuccessMarker, observers, or metrics. If r has a rand
+// source, the new Runner is given its own, seeded from it, so that its delays
+// remain reproducible.
+func (r *Runner) derive(ctx context.Context) *Runner {
+	d := *r
+	d.baseCtx = ctx
+	d.StateFile = ""
+	d.Lock, d.LockFile = nil, ""
+	d.SuccessMarker = ""
+	d.observers = nil
+	d.metrics = noopMetrics{}
+	d.shared = &sharedState{}
+	d.kickC = make(chan struct{}, 1)
+	d.resumeC = make(chan struct{}, 1)
+	if r.rand != nil {
+		r.shared.randlock.Lock()
+		d.rand = rand.New(rand.NewPCG(r.rand.Uint64(), r.rand.Uint64()))
+		r.shared.randlock.Unlock()
 	}
+	return &d
 }
 
 // SetLogger sets the logger for the Runner.
 // If nil, it will use a discard logger.
 func (r *Runner) SetLogger(logger *slog.Logger) {
+	r.mustNotBeRunning()
 	if logger != nil {
 		r.logger = logger
 	} else {
@@ -86,56 +489,737 @@ func (r *Runner) SetLogger(logger *slog.Logger) {
 	}
 }
 
+// SetExecutor sets the Executor used to run the command.
+// If nil, it will use the default [CmdExecutor].
+func (r *Runner) SetExecutor(executor Executor) {
+	r.mustNotBeRunning()
+	if executor != nil {
+		r.executor = executor
+	} else {
+		r.executor = CmdExecutor{}
+	}
+}
+
+// SetClock sets the Clock used by the Runner to schedule retry delays.
+// If nil, it will use the real clock.
+func (r *Runner) SetClock(clock Clock) {
+	r.mustNotBeRunning()
+	if clock != nil {
+		r.clock = clock
+	} else {
+		r.clock = realClock{}
+	}
+}
+
+// SetMetrics sets the Metrics to which the Runner reports its attempts and
+// state. Use [MultiMetrics] to report to several backends.
+// If nil, measurements are discarded.
+func (r *Runner) SetMetrics(m Metrics) {
+	r.mustNotBeRunning()
+	if m != nil {
+		r.metrics = m
+	} else {
+		r.metrics = noopMetrics{}
+	}
+}
+
+// SetRandSource sets the source of randomness used by the Runner, such as
+// for jitter. Providing a seeded source, e.g. [rand.NewPCG], makes the delay
+// sequence reproducible across executions. The source need not be safe for
+// concurrent use. If nil, it will use a randomly seeded source.
+func (r *Runner) SetRandSource(src rand.Source) {
+	r.mustNotBeRunning()
+	r.shared.randlock.Lock()
+	defer r.shared.randlock.Unlock()
+
+	if src != nil {
+		r.rand = rand.New(src)
+	} else {
+		r.rand = nil
+	}
+}
+
+// RunsCompleted returns the number of attempts the Runner has completed. It
+// may be called while the Runner is running, from any goroutine.
+func (r *Runner) RunsCompleted() uint {
+	return uint(r.shared.runsCompleted.Load())
+}
+
+// mustNotBeRunning panics if r is running, as its configuration may not be
+// changed until Run returns.
+func (r *Runner) mustNotBeRunning() {
+	if r.shared.running.Load() {
+		panic("wut: Runner modified while running")
+	}
+}
+
+// Observe registers fn to be called with each Event emitted by the Runner.
+//
+// Observers are called synchronously from the goroutine executing Run, in the
+// order they were registered, and so should return promptly. Observe panics
+// if called while the Runner is running.
+func (r *Runner) Observe(fn func(Event)) {
+	r.mustNotBeRunning()
+	r.observers = append(r.observers, fn)
+}
+
+// emit records an event, and sends it to all registered observers.
+func (r *Runner) emit(e Event) {
+	r.record(e)
+	if len(r.observers) == 0 {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = r.clock.Now()
+	}
+	for _, fn := range r.observers {
+		fn(e)
+	}
+}
+
+// record updates the state of the Runner and reports to its Metrics
+// following e.
+func (r *Runner) record(e Event) {
+	if e.Kind == EventAttemptEnd {
+		r.metrics.IncAttempt(resultOf(e.Attempt))
+		r.metrics.ObserveDuration(e.Attempt.Duration)
+	}
+	s := stateAfter(e)
+	if prev := RunnerState(r.shared.state.Swap(int32(s))); prev != s || e.Kind == EventRunStart {
+		r.metrics.SetState(s)
+	}
+}
+
 // Run starts the Runner and executes the command repeatedly until it succeeds or a stop condition is reached.
+//
+// Run executes with a snapshot of the configuration of the Runner taken when
+// it is called, and returns an error if the Runner is already running.
 func (r *Runner) Run() error {
-	r.logger.Info("Starting runner", "command", r.name, "args", r.args)
+	if !r.shared.running.CompareAndSwap(false, true) {
+		return errRedundantStartCall
+	}
+	defer r.shared.running.Store(false)
+
+	snap := *r
+	return snap.run()
+}
+
+// run executes the Runner, as described by Run.
+func (r *Runner) run() error {
+	if len(r.LogAttrs) > 0 {
+		// precompute the attributes common to every record, as the snapshot
+		// of the configuration ensures they do not change while running
+		r.logger = slog.New(r.logger.Handler().WithAttrs(r.LogAttrs))
+		r.LogAttrs = nil
+	}
+	r.log(slog.LevelInfo, "Starting runner", "command", r.name, "args", r.args)
+	r.emit(Event{Kind: EventRunStart})
+	if err := r.resolvePath(); err != nil {
+		r.log(slog.LevelWarn, "Runner stopped", "reason", err)
+		r.emit(Event{Kind: EventRunEnd, Err: err})
+		return err
+	}
+	lock := r.Lock
+	if lock == nil && r.LockFile != "" {
+		lock = &FileLock{Path: r.LockFile, Wait: r.LockWait}
+	}
+	if lock != nil {
+		if err := lock.Acquire(r.baseCtx); err != nil {
+			r.log(slog.LevelWarn, "Runner stopped", "reason", err)
+			r.emit(Event{Kind: EventRunEnd, Err: err})
+			return err
+		}
+		defer func() {
+			if err := lock.Release(); err != nil {
+				r.log(slog.LevelWarn, "Cannot release lock", "error", err)
+			}
+		}()
+	}
+	if r.SuccessMarker != "" && r.markerFresh() {
+		r.log(slog.LevelInfo, "Skipping, succeeded recently", "marker", r.SuccessMarker)
+		r.emit(Event{Kind: EventRunEnd})
+		return nil
+	}
+	if r.StateFile != "" {
+		r.loadCheckpoint()
+	}
+	// a single timer is reset for each delay, rather than allocating one per
+	// attempt, as continuous runners may go through a great many of them
+	var timer Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
 	for {
+		delay := r.nextExecDelay()
+		if delay > 0 {
+			r.emit(Event{Kind: EventDelay, Delay: delay})
+		}
+
+		if timer == nil {
+			timer = r.clock.NewTimer(delay)
+		} else {
+			timer.Reset(delay)
+		}
 		select {
 		case <-r.baseCtx.Done():
-			r.logger.Warn("Runner stopped", "reason", context.Cause(r.baseCtx))
-			return context.Cause(r.baseCtx)
-		case <-time.After(r.nextExecDelay()):
-			if r.MaxRuns > 0 && r.runsCompleted >= r.MaxRuns {
-				r.logger.Warn("Runner stopped", "reason", errMaxRunsCompleted)
-				return errMaxRunsCompleted
-			}
+		case <-r.kickC:
+			stopTimer(timer)
+			r.log(slog.LevelInfo, "Retry delay skipped")
+			r.emit(Event{Kind: EventDelaySkipped})
+		case <-timer.C():
+		}
+		// no attempt is started once the Runner is stopped, even when its delay
+		// elapsed at the same time, such as after an attempt drained
+		if r.baseCtx.Err() != nil {
+			err := context.Cause(r.baseCtx)
+			r.writeFinalOutput()
+			r.log(slog.LevelWarn, "Runner stopped", "reason", err)
+			r.emit(Event{Kind: EventRunEnd, Err: err})
+			return err
+		}
+		if err := r.waitWhilePaused(); err != nil {
+			r.writeFinalOutput()
+			r.log(slog.LevelWarn, "Runner stopped", "reason", err)
+			r.emit(Event{Kind: EventRunEnd, Err: err})
+			return err
+		}
 
-			err := r.executeCommand()
-			r.logger.Info("Command executed", "error", err)
-			if err == nil && !r.ContinueOnSuccess {
-				r.logger.Info("Completed successfully", "name", r.name, "attempts", r.runsCompleted)
+		if r.MaxRuns > 0 && r.RunsCompleted() >= r.MaxRuns {
+			if r.StateFile != "" {
+				r.clearCheckpoint()
+			}
+			if r.UntilFailure {
+				r.log(slog.LevelInfo, "Completed without failure", "name", r.name, "attempts", r.RunsCompleted())
+				r.emit(Event{Kind: EventRunEnd})
 				return nil
 			}
+			r.writeFinalOutput()
+			r.log(slog.LevelWarn, "Runner stopped", "reason", errMaxRunsCompleted)
+			r.emit(Event{Kind: EventRunEnd, Err: errMaxRunsCompleted})
+			return errMaxRunsCompleted
 		}
+
+		attempt := Attempt{Num: r.RunsCompleted() + 1, Start: r.clock.Now()}
+		if r.anchor.IsZero() {
+			r.anchor = attempt.Start
+		}
+		r.emit(Event{Time: attempt.Start, Kind: EventAttemptStart, Attempt: attempt})
+		r.executeCommand(&attempt)
+		attempt.Duration = r.clock.Now().Sub(attempt.Start)
+		attempt.OOMKilled = errors.Is(attempt.Err, ErrOOMKilled)
+		attempt.ForceKilled = errors.Is(attempt.Err, ErrForceKilled)
+		if exitedWith(attempt, r.SuccessCodes) {
+			attempt.Err = nil
+		}
+		if attempt.Err == nil && r.Verify != nil {
+			if err := r.Verify(r.baseCtx); err != nil {
+				attempt.Err = fmt.Errorf("%w: %w", ErrVerifyFailed, err)
+			}
+		}
+		switch {
+		case attempt.Err == nil:
+			r.failures, r.successes, r.crashes = 0, r.successes+1, 0
+		case r.MinUptime > 0 && attempt.Duration >= r.MinUptime:
+			r.failures, r.successes, r.crashes = 1, 0, 0
+		default:
+			r.failures, r.successes, r.crashes = r.failures+1, 0, r.crashes+1
+		}
+		if r.StateFile != "" {
+			r.saveCheckpoint(attempt.Start.Add(attempt.Duration))
+		}
+		if r.CaptureLimit > 0 {
+			if attempt.Num > 1 && attempt.OutputSum != r.prevSum {
+				attempt.OutputDiff = lineDiff(r.prevOutput, attempt.Output)
+			}
+			r.prevOutput, r.prevSum = attempt.Output, attempt.OutputSum
+		}
+		r.emit(Event{Kind: EventAttemptEnd, Attempt: attempt})
+
+		r.logAttempt(attempt)
+		var stopErr error
+		switch {
+		case r.UntilFailure && attempt.Err != nil:
+			stopErr = ErrAttemptFailed
+		case r.permanent(attempt):
+			stopErr = ErrPermanentFailure
+		case r.CrashLoopLimit > 0 && r.crashes >= r.CrashLoopLimit:
+			stopErr = ErrCrashLoop
+		}
+		if stopErr != nil {
+			err := fmt.Errorf("%w: %w", stopErr, attempt.Err)
+			if r.StateFile != "" {
+				r.clearCheckpoint()
+			}
+			r.writeFinalOutput()
+			r.log(slog.LevelWarn, "Runner stopped", "reason", err)
+			r.emit(Event{Kind: EventRunEnd, Err: err})
+			return err
+		}
+		if attempt.Err == nil && !r.ContinueOnSuccess && !r.UntilFailure && r.successes >= r.ConsecutiveSuccesses {
+			if r.StateFile != "" {
+				r.clearCheckpoint()
+			}
+			if r.SuccessMarker != "" {
+				r.touchMarker()
+			}
+			r.log(slog.LevelInfo, "Completed successfully", "name", r.name, "attempts", r.RunsCompleted())
+			r.emit(Event{Kind: EventRunEnd})
+			return nil
+		}
+	}
+}
+
+// resolvePath sets the path of the command executed by each attempt. For the
+// default CmdExecutor, a name without a path separator is looked up in PATH
+// once, rather than on every attempt, so that a missing executable stops the
+// Runner immediately instead of failing each of its retries identically.
+// Other executors are passed the name unchanged.
+func (r *Runner) resolvePath() error {
+	r.path = r.name
+	if _, ok := r.executor.(CmdExecutor); !ok || filepath.Base(r.name) != r.name {
+		return nil
+	}
+	path, err := exec.LookPath(r.name)
+	if err != nil {
+		return err
+	}
+	r.path = path
+	return nil
+}
+
+// drainContext returns the context for an attempt under DrainTimeout, which
+// is only cancelled once DrainTimeout has passed since the context of the
+// Runner was done, with the same cause. The returned stop function must be
+// called once the attempt has completed.
+func (r *Runner) drainContext() (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(context.WithoutCancel(r.baseCtx))
+	var timer Timer
+	var mu sync.Mutex // guards timer
+	stopDrain := context.AfterFunc(r.baseCtx, func() {
+		r.log(slog.LevelInfo, "Waiting for the running attempt to finish", "drain_timeout", r.DrainTimeout)
+		mu.Lock()
+		defer mu.Unlock()
+		timer = r.clock.AfterFunc(r.DrainTimeout, func() { cancel(context.Cause(r.baseCtx)) })
+	})
+	return ctx, func() {
+		stopDrain()
+		mu.Lock()
+		if timer != nil {
+			timer.Stop()
+		}
+		mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// interruption returns the reason the context ctx of an attempt is done.
+func (r *Runner) interruption(ctx context.Context) error {
+	cause := context.Cause(ctx)
+	if cause == ErrProcessTimeout || cause == ErrIdleTimeout {
+		return cause
+	}
+	if errors.Is(r.baseCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w (%w)", ErrRunnerDeadline, cause)
+	}
+	return fmt.Errorf("%w (%w)", ErrRunnerStopped, cause)
+}
+
+// writeFinalOutput writes the output held back from the final attempt, if it
+// failed, when the Runner stops with OutputOnFinalFailure.
+func (r *Runner) writeFinalOutput() {
+	if r.finalOutput != nil {
+		r.finalOutput.Release()
+		r.finalOutput = nil
+	}
+}
+
+// logAttempt logs the result of a completed attempt.
+//
+// If output capture is enabled, the output of failed attempts is included.
+// To avoid flooding the log in long retry loops, output identical to that of
+// the previous failed attempt is replaced by a compact repetition count.
+func (r *Runner) logAttempt(a Attempt) {
+	if len(a.Orphans) > 0 && r.logEnabled(slog.LevelWarn) {
+		r.log(slog.LevelWarn, "Command left processes running", "pids", a.Orphans,
+			"killed", r.CommandOptions.Orphans == OrphansKill)
+	}
+	if a.Err == nil || r.CaptureLimit <= 0 {
+		if r.logEnabled(slog.LevelInfo) {
+			r.log(slog.LevelInfo, "Command executed", "error", a.Err)
+		}
+		return
+	}
+
+	repeated := a.Num > 1 && a.OutputSum == r.failSum
+	if repeated {
+		r.failRepeats++
+	} else {
+		r.failSum, r.failRepeats = a.OutputSum, 0
+	}
+	if !r.logEnabled(slog.LevelInfo) {
+		return
+	}
+	if repeated {
+		r.log(slog.LevelInfo, "Command executed", "error", a.Err,
+			"output", fmt.Sprintf("same as previous (x%d)", r.failRepeats+1))
+		return
+	}
+	r.log(slog.LevelInfo, "Command executed", "error", a.Err, "output", string(a.Output))
+}
+
+// logEnabled reports whether records of the given level would be logged. Call
+// sites on the path of every attempt check it before building their arguments,
+// so that a Runner which discards its logs does not pay for them.
+func (r *Runner) logEnabled(level slog.Level) bool {
+	return r.logger.Enabled(r.baseCtx, level)
+}
+
+// log logs a record with the given level, message, and alternating keys and
+// values, customized according to the Log fields of the Runner.
+func (r *Runner) log(level slog.Level, msg string, args ...any) {
+	if !r.logEnabled(level) {
+		return
+	}
+	attrs := make([]slog.Attr, 0, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		key := args[i].(string)
+		if k, ok := r.LogKeys[key]; ok {
+			key = k
+		}
+		attrs = append(attrs, slog.Any(key, r.redactValue(args[i+1])))
+	}
+	if r.LogGroup != "" && len(attrs) > 0 {
+		attrs = []slog.Attr{{Key: r.LogGroup, Value: slog.GroupValue(attrs...)}}
+	}
+	if len(r.LogAttrs) > 0 {
+		attrs = slices.Concat(r.LogAttrs, attrs)
+	}
+	r.logger.LogAttrs(r.baseCtx, level, msg, attrs...)
+}
+
+// logJSONRecord logs a line of output parsed as a JSON record, with the
+// given default level.
+func (r *Runner) logJSONRecord(level slog.Level, stream string, num uint, rec jsonRecord) {
+	msg := "Command output"
+	if rec.msg != "" {
+		msg = rec.msg
+	}
+	if rec.level != nil {
+		level = *rec.level
+	}
+	r.log(level, msg, append([]any{"stream", stream, "attempt", num}, rec.args...)...)
+}
+
+// redactValue returns v with secrets redacted if a Redactor is set, for
+// values which may contain them.
+func (r *Runner) redactValue(v any) any {
+	if r.Redactor == nil {
+		return v
+	}
+	redact := func(s string) string { return r.Redactor.redact(s, r.CommandOptions.Env) }
+	switch v := v.(type) {
+	case string:
+		return redact(v)
+	case []string:
+		redacted := make([]string, len(v))
+		for i, s := range v {
+			redacted[i] = redact(s)
+		}
+		return redacted
+	case error:
+		return redact(v.Error())
+	default:
+		return v
+	}
+}
+
+// Kick causes the Runner to skip any remaining retry delay and execute the
+// command immediately.
+//
+// If a command is currently executing, the kick is held until it completes,
+// and the delay before the following attempt is skipped. Multiple kicks
+// issued before they can take effect are coalesced into one. Kick never
+// blocks and is safe to call from any goroutine.
+func (r *Runner) Kick() {
+	select {
+	case r.kickC <- struct{}{}:
+	default:
 	}
 }
 
+// Pause causes the Runner to stop executing the command until Resume is
+// called. A command already executing is not interrupted, but no further
+// attempts are started. Pause never blocks and is safe to call from any
+// goroutine.
+func (r *Runner) Pause() {
+	r.shared.paused.Store(true)
+}
+
+// Resume resumes a Runner paused by Pause. Resume never blocks and is safe to
+// call from any goroutine.
+func (r *Runner) Resume() {
+	if r.shared.paused.Swap(false) {
+		select {
+		case r.resumeC <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Paused reports whether the Runner is paused. It is safe to call from any
+// goroutine.
+func (r *Runner) Paused() bool {
+	return r.shared.paused.Load()
+}
+
+// waitWhilePaused blocks while the Runner is paused, returning the cause of
+// the context of the Runner if it is done first.
+func (r *Runner) waitWhilePaused() error {
+	if !r.shared.paused.Load() {
+		return nil
+	}
+	r.log(slog.LevelInfo, "Runner paused")
+	for r.shared.paused.Load() {
+		select {
+		case <-r.baseCtx.Done():
+			return context.Cause(r.baseCtx)
+		case <-r.resumeC:
+		}
+	}
+	r.log(slog.LevelInfo, "Runner resumed")
+	return nil
+}
+
 func (r *Runner) nextExecDelay() time.Duration {
-	r.runlock.Lock()
-	defer r.runlock.Unlock()
+	if r.RunsCompleted() == 0 {
+		var delay time.Duration // no delay for the first run, unless requested
+		if !r.StartAt.IsZero() {
+			delay = max(r.StartAt.Sub(r.clock.Now()), 0)
+		}
+		if r.Splay > 0 {
+			delay += r.randDuration(r.Splay)
+		}
+		if delay > 0 {
+			r.log(slog.LevelInfo, "Delaying first run", "until", r.clock.Now().Add(delay))
+		}
+		return delay
+	}
 
-	if r.runsCompleted == 0 {
-		return 0 // no delay for the first run
+	delay := r.backoffDelay(r.failures)
+	r.prevDelay = delay
+	if r.Interval > 0 {
+		delay = r.Interval
+		if !r.anchor.IsZero() {
+			delay = r.untilNextSlot()
+		}
+	}
+	if r.JitterFraction > 0 {
+		if n := time.Duration(float64(delay) * r.JitterFraction); n > 0 {
+			delay += r.randDuration(n)
+		}
 	}
-	return r.RetryDelay
+	if r.Jitter > 0 {
+		delay += r.randDuration(r.Jitter)
+	}
+	if !r.resumedAfter.IsZero() {
+		delay = max(delay-r.clock.Now().Sub(r.resumedAfter), 0)
+		r.resumedAfter = time.Time{}
+	}
+	return delay
+}
+
+// untilNextSlot returns the delay until the next start time of an attempt
+// according to Interval. Slots are counted from the anchor, rather than from
+// the start of the previous attempt, so that timer latency does not accumulate.
+func (r *Runner) untilNextSlot() time.Duration {
+	now := r.clock.Now()
+	n := now.Sub(r.anchor)/r.Interval + 1
+	return r.anchor.Add(n * r.Interval).Sub(now)
 }
 
-func (r *Runner) executeCommand() error {
-	r.runlock.Lock()
-	defer r.runlock.Unlock()
+// randDuration returns a random duration in the half-open interval [0,n).
+func (r *Runner) randDuration(n time.Duration) time.Duration {
+	r.shared.randlock.Lock()
+	defer r.shared.randlock.Unlock()
 
+	if r.rand == nil {
+		return rand.N(n)
+	}
+	return time.Duration(r.rand.Int64N(int64(n)))
+}
+
+// executeCommand executes the command, recording its result in a.
+func (r *Runner) executeCommand(a *Attempt) {
 	ctx := r.baseCtx
+	if r.DrainTimeout > 0 {
+		dctx, stop := r.drainContext()
+		ctx = dctx
+		defer stop()
+	}
 	if r.ProcessTimeout > 0 {
-		pctx, cf := context.WithTimeout(r.baseCtx, r.ProcessTimeout)
+		pctx, cf := context.WithTimeoutCause(ctx, r.ProcessTimeout, ErrProcessTimeout)
 		ctx = pctx
 		defer cf()
 	}
+	var idle Timer
+	if r.IdleTimeout > 0 {
+		ictx, cancel := context.WithCancelCause(ctx)
+		ctx = ictx
+		defer cancel(nil)
+		idle = r.clock.AfterFunc(r.IdleTimeout, func() { cancel(ErrIdleTimeout) })
+		defer idle.Stop()
+	}
 
 	defer func() {
-		r.runsCompleted++
+		r.shared.runsCompleted.Add(1)
 	}()
 
-	return r.executor.Run(ctx, r.CommandOptions, r.name, r.args...)
+	opts := r.CommandOptions
+	if opts.Stdout != nil && opts.Stdout == opts.Stderr {
+		// once wrapped, each stream is copied to the writer from its own goroutine
+		shared := &syncWriter{w: opts.Stdout}
+		opts.Stdout, opts.Stderr = shared, shared
+	}
+	if r.OutputPrefix != "" {
+		prefix := fmt.Sprintf(r.OutputPrefix, a.Num)
+		if opts.Stdout != nil {
+			opts.Stdout = NewPrefixWriter(opts.Stdout, prefix)
+		}
+		if opts.Stderr != nil {
+			opts.Stderr = NewPrefixWriter(opts.Stderr, prefix)
+		}
+	}
+	var held *heldOutput
+	if r.OutputPolicy != OutputPassthrough && (opts.Stdout != nil || opts.Stderr != nil) {
+		held = newHeldOutput(r.OutputTailLines, opts.Stdout, opts.Stderr)
+		opts.Stdout, opts.Stderr = held.stdout, held.stderr
+	}
+	var limiters []*LimitWriter
+	limit := func(w io.Writer) io.Writer {
+		if w == nil || (r.OutputMaxBytes <= 0 && r.OutputMaxLines <= 0) {
+			return w
+		}
+		lw := NewLimitWriter(w, r.OutputMaxBytes, r.OutputMaxLines)
+		limiters = append(limiters, lw)
+		return lw
+	}
+	opts.Stdout, opts.Stderr = limit(opts.Stdout), limit(opts.Stderr)
+	// timestamp output as it is produced, prior to being held back
+	opts.Stdout = timestampWriter(opts.Stdout, r.OutputTimestamps)
+	opts.Stderr = timestampWriter(opts.Stderr, r.OutputTimestamps)
+	// each stream needs its own stripper, as sequences may span writes
+	strip := func(w io.Writer) io.Writer {
+		if r.OutputStripANSI {
+			return NewANSIStripper(w)
+		}
+		return w
+	}
+	if r.OutputTee != nil {
+		opts.Stdout = teeWriter(opts.Stdout, strip(r.OutputTee))
+		opts.Stderr = teeWriter(opts.Stderr, strip(r.OutputTee))
+	}
+	var capture *tailBuffer
+	if r.CaptureLimit > 0 {
+		capture = newTailBuffer(r.CaptureLimit)
+		opts.Stdout = teeWriter(opts.Stdout, strip(capture))
+		opts.Stderr = teeWriter(opts.Stderr, strip(capture))
+	}
+	var untilMatch *outputMatcher
+	if r.UntilOutput != nil {
+		untilMatch = newOutputMatcher(r.UntilOutput)
+		opts.Stdout, opts.Stderr = untilMatch.tee(opts.Stdout), untilMatch.tee(opts.Stderr)
+	}
+	var failMatch *outputMatcher
+	if r.FailOnOutput != nil {
+		failMatch = newOutputMatcher(r.FailOnOutput)
+		opts.Stdout, opts.Stderr = failMatch.tee(opts.Stdout), failMatch.tee(opts.Stderr)
+	}
+	if idle != nil {
+		activity := idleWriter{idle, r.IdleTimeout}
+		opts.Stdout, opts.Stderr = teeWriter(opts.Stdout, activity), teeWriter(opts.Stderr, activity)
+	}
+	var outputLoggers []*lineWriter
+	logOutput := func(w io.Writer, level slog.Leveler, stream string) io.Writer {
+		// JSON records may carry their own level, so can't be skipped
+		if level == nil || (!r.LogJSONOutput && !r.logEnabled(level.Level())) {
+			return w
+		}
+		lw := newLineWriter(func(line string) {
+			if r.OutputStripANSI {
+				line = string(StripANSI([]byte(line)))
+			}
+			if r.LogJSONOutput {
+				if rec, ok := parseJSONRecord(line); ok {
+					r.logJSONRecord(level.Level(), stream, a.Num, rec)
+					return
+				}
+			}
+			r.log(level.Level(), "Command output", "stream", stream, "attempt", a.Num, "line", line)
+		})
+		outputLoggers = append(outputLoggers, lw)
+		return teeWriter(w, lw)
+	}
+	opts.Stdout = logOutput(opts.Stdout, r.LogStdout, "stdout")
+	opts.Stderr = logOutput(opts.Stderr, r.LogStderr, "stderr")
+	if opts.Orphans != OrphansIgnore {
+		onOrphans := opts.OnOrphans
+		opts.OnOrphans = func(pids []int) {
+			a.Orphans = pids
+			if onOrphans != nil {
+				onOrphans(pids)
+			}
+		}
+	}
+
+	if r.HedgeDelay > 0 {
+		a.Err = r.runHedged(ctx, opts, a.Num)
+	} else {
+		a.Err = r.executor.Run(ctx, opts, r.path, r.args...)
+	}
+	if a.Err != nil && ctx.Err() != nil {
+		cause := r.interruption(ctx)
+		a.Err = fmt.Errorf("%w: %w", cause, a.Err)
+		a.Interrupted = cause != ErrProcessTimeout && cause != ErrIdleTimeout
+	}
+	for _, lw := range outputLoggers {
+		lw.Flush()
+	}
+	for _, lw := range limiters {
+		lw.Flush()
+	}
+	if untilMatch != nil {
+		switch {
+		case untilMatch.Matched() && !a.Interrupted:
+			a.Err = nil
+		case a.Err == nil:
+			a.Err = ErrOutputNotMatched
+		}
+	}
+	if failMatch != nil && failMatch.Matched() {
+		if a.Err != nil {
+			a.Err = fmt.Errorf("%w: %w", ErrFatalOutput, a.Err)
+		} else {
+			a.Err = ErrFatalOutput
+		}
+	}
+	if held != nil && a.Err != nil {
+		switch r.OutputPolicy {
+		case OutputOnFailure:
+			held.Release()
+		case OutputOnFinalFailure:
+			r.finalOutput = held
+		}
+	} else {
+		r.finalOutput = nil
+	}
+	if capture != nil {
+		a.Output, a.OutputSum = capture.Bytes(), capture.Sum()
+		if r.Redactor != nil {
+			a.Output = []byte(r.Redactor.redact(string(a.Output), r.CommandOptions.Env))
+		}
+	}
 }
 
 // func (r *Runner) Stop() error
@@ -147,17 +1231,3 @@ func (r *Runner) executeCommand() error {
 // reason).  For now though, let's keep this out of the API to simplify it and
 // the caller can handle cancellation themselves by providing a context with a
 // cancellation function when creating the Runner.
-
-// Keep the complexity of exposing internal state out of the API for now.  In
-// the future, we may want to expose the state of the Runner via something like
-// this.
-//
-// // RunnerState represents the state of the Runner.
-// type RunnerState int
-
-// const (
-// 	RunnerStateIdle      RunnerState = iota // Runner is active but is not currently executing a command.
-// 	RunnerStateRunning                      // Runner is active and is currently executing a command.
-// 	RunnerStateCompleted                    // Runner has completed its success criteria and is no longer running.
-// 	RunnerStateErrored                      // Runner met an exit/failure condition prior to success (for example, timed out).
-// )
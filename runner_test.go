@@ -1,8 +1,17 @@
 package wut
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/synctest"
 	"time"
@@ -10,7 +19,7 @@ import (
 
 func NewRunnerWithExecutor(ctx context.Context, executor mockExecutor) *Runner {
 	r := NewRunner(ctx, "")
-	r.executor = executor
+	r.SetExecutor(executor)
 	return r
 }
 
@@ -59,6 +68,70 @@ func TestRunner_Run(t *testing.T) {
 		})
 	})
 
+	t.Run("kick skips retry delay", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			r := NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1})
+			r.MaxRuns = 2
+			r.RetryDelay = time.Hour
+
+			// Kick the runner each time it settles into waiting out the retry
+			// delay, so it should never actually wait.
+			go func() {
+				for range r.MaxRuns {
+					synctest.Wait()
+					r.Kick()
+				}
+			}()
+
+			runAssert(t, r, runnerExpectedResults{
+				err:          errMaxRunsCompleted,
+				runs:         2,
+				elapsedTotal: 0,
+			})
+		})
+	})
+
+	t.Run("seeded jitter is reproducible", func(t *testing.T) {
+		// Runners with identically seeded jitter should wait identically.
+		var elapsed []time.Duration
+		for range 2 {
+			synctest.Test(t, func(t *testing.T) {
+				r := NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1})
+				r.MaxRuns = 5
+				r.RetryDelay = 10 * time.Millisecond
+				r.Jitter = 10 * time.Millisecond
+				r.SetRandSource(rand.NewPCG(1, 2))
+
+				start := time.Now()
+				r.Run()
+				elapsed = append(elapsed, time.Since(start))
+			})
+		}
+
+		if elapsed[0] != elapsed[1] {
+			t.Errorf("elapsed: got %v and %v, want equal", elapsed[0], elapsed[1])
+		}
+		if lo, hi := 50*time.Millisecond, 100*time.Millisecond; elapsed[0] < lo || elapsed[0] >= hi {
+			t.Errorf("elapsed: got %v, want in [%v, %v)", elapsed[0], lo, hi)
+		}
+	})
+
+	t.Run("jitter fraction", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			r := NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1})
+			r.MaxRuns = 5
+			r.RetryDelay = 100 * time.Millisecond
+			r.JitterFraction = 0.5
+
+			start := time.Now()
+			r.Run()
+			// five delays of 100ms, each extended by up to 50ms
+			if elapsed, lo, hi := time.Since(start), 500*time.Millisecond, 750*time.Millisecond; elapsed < lo || elapsed >= hi {
+				t.Errorf("elapsed: got %v, want in [%v, %v)", elapsed, lo, hi)
+			}
+		})
+	})
+
 	t.Run("process timeout", func(t *testing.T) {
 		// Run a command that sleeps for 100ms before success, but with a process timeout of 50ms.
 		//
@@ -87,6 +160,57 @@ func TestRunner_Run(t *testing.T) {
 	})
 }
 
+func TestRunner_logAttempt(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "flaky")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"refused", "refused", "refused", "timeout", "refused", ""},
+			exitcode: []int{1, 1, 1, 1, 1, 0},
+		})
+		r.CaptureLimit = 1024
+
+		var buf bytes.Buffer
+		r.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})))
+		if err := r.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var got []string
+		for line := range strings.Lines(buf.String()) {
+			if _, output, ok := strings.Cut(line, "output="); ok {
+				got = append(got, strings.TrimSpace(output))
+			}
+		}
+		want := []string{
+			"refused",
+			`"same as previous (x2)"`,
+			`"same as previous (x3)"`,
+			"timeout",
+			"refused",
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("logged output:\ngot  %q\nwant %q", got, want)
+		}
+	})
+}
+
+func TestRunner_logAttempt_discard(t *testing.T) {
+	r := NewRunner(t.Context(), "flaky")
+	r.CaptureLimit = 1024
+	a := Attempt{Num: 2, Err: exitCodeError(1), Output: []byte("refused")}
+	allocs := testing.AllocsPerRun(100, func() { r.logAttempt(a) })
+	if allocs != 0 {
+		t.Errorf("got %v allocations per attempt with a discard logger, want 0", allocs)
+	}
+}
+
 // runAssert runs the given runner and asserts that it completes with the expected results.
 func runAssert(t *testing.T, r *Runner, want runnerExpectedResults) {
 	t.Helper()
@@ -99,8 +223,8 @@ func runAssert(t *testing.T, r *Runner, want runnerExpectedResults) {
 		t.Errorf("error: got %v, want %v", err, want.err)
 	}
 
-	if r.runsCompleted != want.runs {
-		t.Errorf("runs completed: got %d, want %d", r.runsCompleted, want.runs)
+	if r.RunsCompleted() != want.runs {
+		t.Errorf("runs completed: got %d, want %d", r.RunsCompleted(), want.runs)
 	}
 
 	if elapsed != want.elapsedTotal {
@@ -113,3 +237,725 @@ type runnerExpectedResults struct {
 	runs         uint
 	elapsedTotal time.Duration
 }
+
+func TestRunner_log(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{})
+		r.LogAttrs = []slog.Attr{slog.String("job", "backup")}
+		r.LogGroup = "wut"
+		r.LogKeys = map[string]string{"attempts": "tries"}
+
+		var buf bytes.Buffer
+		r.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})))
+		if err := r.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		lines := slices.Collect(strings.Lines(buf.String()))
+		want := []string{
+			`level=INFO msg="Starting runner" job=backup wut.command="" wut.args=[]` + "\n",
+			`level=INFO msg="Command executed" job=backup wut.error=<nil>` + "\n",
+			`level=INFO msg="Completed successfully" job=backup wut.name="" wut.tries=1` + "\n",
+		}
+		if !slices.Equal(lines, want) {
+			t.Errorf("got log:\n%s\nwant:\n%s", strings.Join(lines, ""), strings.Join(want, ""))
+		}
+	})
+}
+
+func TestRunner_LogStdout(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{output: "hello\nworld"})
+		r.LogStdout = slog.LevelDebug
+		r.LogStderr = slog.LevelWarn
+
+		var buf bytes.Buffer
+		r.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+		if err := r.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var got []string
+		for line := range strings.Lines(buf.String()) {
+			if strings.Contains(line, "Command output") {
+				_, attrs, _ := strings.Cut(line, "level=")
+				got = append(got, strings.TrimSpace(attrs))
+			}
+		}
+		want := []string{
+			`DEBUG msg="Command output" stream=stdout attempt=1 line=hello`,
+			`DEBUG msg="Command output" stream=stdout attempt=1 line=world`,
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestRunner_LogJSONOutput(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{
+			output: `{"time":"2024-01-01T00:00:00Z","level":"warn","msg":"retrying","id":7}` + "\nnot json\n",
+		})
+		r.LogStdout = slog.LevelInfo
+		r.LogJSONOutput = true
+
+		var buf bytes.Buffer
+		r.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+		if err := r.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var got []string
+		for line := range strings.Lines(buf.String()) {
+			if strings.Contains(line, "stream=") {
+				_, attrs, _ := strings.Cut(line, "level=")
+				got = append(got, strings.TrimSpace(attrs))
+			}
+		}
+		want := []string{
+			`WARN msg=retrying stream=stdout attempt=1 id=7`,
+			`INFO msg="Command output" stream=stdout attempt=1 line="not json"`,
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestRunner_OutputPolicy(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "cmd")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"1\n2\n3\n", "4\n5\n6\n", "7\n8\n9\n"},
+			exitcode: []int{1, 0, 1},
+		})
+		r.ContinueOnSuccess = true
+		r.MaxRuns = 3
+		r.OutputPolicy = OutputOnFailure
+		r.OutputTailLines = 2
+
+		var stdout bytes.Buffer
+		r.CommandOptions.Stdout = &stdout
+		r.Run()
+
+		if got, want := stdout.String(), "2\n3\n8\n9\n"; got != want {
+			t.Errorf("got output %q, want %q", got, want)
+		}
+	})
+}
+
+func TestRunner_OutputOnFinalFailure(t *testing.T) {
+	run := func(t *testing.T, exitcodes []int) string {
+		r := NewRunner(t.Context(), "cmd")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"1\n2\n", "3\n4\n", "5\n6\n"},
+			exitcode: exitcodes,
+		})
+		r.MaxRuns = 3
+		r.OutputPolicy = OutputOnFinalFailure
+
+		var stdout bytes.Buffer
+		r.CommandOptions.Stdout = &stdout
+		r.Run()
+		return stdout.String()
+	}
+
+	synctest.Test(t, func(t *testing.T) {
+		if got, want := run(t, []int{1, 1, 1}), "5\n6\n"; got != want {
+			t.Errorf("retries exhausted: got output %q, want %q", got, want)
+		}
+	})
+	synctest.Test(t, func(t *testing.T) {
+		if got := run(t, []int{1, 1, 0}); got != "" {
+			t.Errorf("eventual success: got output %q, want none", got)
+		}
+	})
+}
+
+func TestRunner_OutputPrefix(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "cmd")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"a\nb\n", "c\n"},
+			exitcode: []int{1, 0},
+		})
+		r.OutputPrefix = "[run %d] "
+
+		var stdout bytes.Buffer
+		r.CommandOptions.Stdout = &stdout
+		r.Run()
+
+		if got, want := stdout.String(), "[run 1] a\n[run 1] b\n[run 2] c\n"; got != want {
+			t.Errorf("got output %q, want %q", got, want)
+		}
+	})
+}
+
+// concurrentExecutor writes lines to the stdout and stderr of the command
+// from separate goroutines, as a CmdExecutor does.
+type concurrentExecutor struct{ lines int }
+
+func (ce concurrentExecutor) Run(ctx context.Context, opts CommandOpts, name string, args ...string) error {
+	var wg sync.WaitGroup
+	for _, w := range []io.Writer{opts.Stdout, opts.Stderr} {
+		wg.Go(func() {
+			for range ce.lines {
+				io.WriteString(w, "line\n")
+			}
+		})
+	}
+	wg.Wait()
+	return nil
+}
+
+func TestRunner_OutputPrefixSharedWriter(t *testing.T) {
+	r := NewRunner(t.Context(), "cmd")
+	r.SetExecutor(concurrentExecutor{lines: 100})
+	r.OutputPrefix = "[run %d] "
+
+	var out bytes.Buffer
+	r.CommandOptions.Stdout = &out
+	r.CommandOptions.Stderr = &out
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Repeat("[run 1] line\n", 200)
+	if got := out.String(); got != want {
+		t.Errorf("got output %q, want %q", got, want)
+	}
+}
+
+func TestRunner_OutputTimestampsSharedWriter(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "cmd")
+		r.SetExecutor(concurrentExecutor{lines: 100})
+		r.OutputTimestamps = TimestampsRelative
+
+		var out bytes.Buffer
+		r.CommandOptions.Stdout = &out
+		r.CommandOptions.Stderr = &out
+		if err := r.Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := strings.Repeat("+0.000s line\n", 200)
+		if got := out.String(); got != want {
+			t.Errorf("got output %q, want %q", got, want)
+		}
+	})
+}
+
+func TestRunner_OutputMaxLines(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "cmd")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"1\n2\n3\n4\n5\n", "6\n"},
+			exitcode: []int{1, 0},
+		})
+		r.OutputMaxLines = 2
+		r.OutputPrefix = "%d: "
+
+		var stdout bytes.Buffer
+		r.CommandOptions.Stdout = &stdout
+		r.Run()
+
+		want := "1: 1\n1: ... [6 bytes omitted] ...\n1: 5\n2: 6\n"
+		if got := stdout.String(); got != want {
+			t.Errorf("got output %q, want %q", got, want)
+		}
+	})
+}
+
+func TestRunner_OutputTee(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "cmd")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"1\n2\n", "3\n"},
+			exitcode: []int{1, 0},
+		})
+		r.OutputPolicy = OutputOnFinalFailure
+
+		var stdout, tee bytes.Buffer
+		r.CommandOptions.Stdout = &stdout
+		r.OutputTee = &tee
+		r.Run()
+
+		if got := stdout.String(); got != "" {
+			t.Errorf("got output %q, want none", got)
+		}
+		if got, want := tee.String(), "1\n2\n3\n"; got != want {
+			t.Errorf("got tee %q, want %q", got, want)
+		}
+	})
+}
+
+func TestRunner_OutputStripANSI(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "cmd")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"\x1b[31mred\x1b[0m\n"},
+			exitcode: []int{0},
+		})
+		r.CaptureLimit = 1024
+		r.OutputStripANSI = true
+
+		var stdout, tee bytes.Buffer
+		var output []byte
+		r.CommandOptions.Stdout = &stdout
+		r.OutputTee = &tee
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptEnd {
+				output = e.Attempt.Output
+			}
+		})
+		r.Run()
+
+		if got, want := stdout.String(), "\x1b[31mred\x1b[0m\n"; got != want {
+			t.Errorf("got output %q, want %q", got, want)
+		}
+		if got, want := tee.String(), "red\n"; got != want {
+			t.Errorf("got tee %q, want %q", got, want)
+		}
+		if got, want := string(output), "red\n"; got != want {
+			t.Errorf("got captured output %q, want %q", got, want)
+		}
+	})
+}
+
+// countingClock is a Clock counting the timers it creates.
+type countingClock struct {
+	realClock
+	timers, funcs atomic.Int32
+}
+
+func (c *countingClock) NewTimer(d time.Duration) Timer {
+	c.timers.Add(1)
+	return c.realClock.NewTimer(d)
+}
+
+func (c *countingClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.funcs.Add(1)
+	return c.realClock.AfterFunc(d, f)
+}
+
+func TestRunner_reusesTimer(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		c := &countingClock{}
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1})
+		r.SetClock(c)
+		r.RetryDelay = time.Second
+		r.MaxRuns = 5
+		r.Run()
+		if got := c.timers.Load(); got != 1 {
+			t.Errorf("created %d timers, want 1", got)
+		}
+	})
+}
+
+func TestRunner_RunsCompleted(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{sleep: time.Hour, exitcode: 1})
+		r.RetryDelay = time.Minute
+		r.MaxRuns = 2
+		go r.Run()
+
+		// accessors remain responsive while the command runs
+		time.Sleep(time.Minute)
+		if got := r.RunsCompleted(); got != 0 {
+			t.Errorf("during first attempt: got %d, want 0", got)
+		}
+		time.Sleep(time.Hour + 2*time.Minute)
+		if got := r.RunsCompleted(); got != 1 {
+			t.Errorf("during second attempt: got %d, want 1", got)
+		}
+	})
+}
+
+func TestRunner_Run_snapshot(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{sleep: time.Minute, exitcode: 1})
+		r.RetryDelay = time.Minute
+		r.MaxRuns = 2
+		done := make(chan error)
+		go func() { done <- r.Run() }()
+		synctest.Wait()
+
+		if err := r.Run(); err != errRedundantStartCall {
+			t.Errorf("concurrent Run: got %v, want %v", err, errRedundantStartCall)
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("SetLogger while running: expected panic")
+				}
+			}()
+			r.SetLogger(nil)
+		}()
+
+		// changes to fields take effect from the next Run
+		r.MaxRuns = 3
+		if err := <-done; err != errMaxRunsCompleted {
+			t.Errorf("got %v, want %v", err, errMaxRunsCompleted)
+		}
+		if got := r.RunsCompleted(); got != 2 {
+			t.Errorf("runs completed: got %d, want 2", got)
+		}
+		r.Run()
+		if got := r.RunsCompleted(); got != 3 {
+			t.Errorf("runs completed after second Run: got %d, want 3", got)
+		}
+	})
+}
+
+func TestRunner_Run_notFound(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "wut-no-such-command")
+		r.RetryDelay = time.Hour
+		runAssert(t, r, runnerExpectedResults{err: exec.ErrNotFound, runs: 0})
+	})
+}
+
+// nopExecutor succeeds immediately, writing output to stdout.
+type nopExecutor struct{ output []byte }
+
+func (e nopExecutor) Run(ctx context.Context, opts CommandOpts, name string, args ...string) error {
+	if opts.Stdout != nil {
+		opts.Stdout.Write(e.output)
+	}
+	return nil
+}
+
+// BenchmarkRunner_executeCommand measures the overhead of the Runner for each
+// attempt, such as for the writers capturing its output, excluding the cost
+// of executing the command itself.
+func BenchmarkRunner_executeCommand(b *testing.B) {
+	r := NewRunner(b.Context(), "probe", "-q")
+	r.SetExecutor(nopExecutor{output: []byte("connection refused\n")})
+	r.CaptureLimit = 4096
+	b.ReportAllocs()
+	for b.Loop() {
+		var a Attempt
+		r.executeCommand(&a)
+	}
+}
+
+func TestRunner_Interval(t *testing.T) {
+	tests := []struct {
+		name  string
+		sleep time.Duration
+		want  []time.Duration // start of each attempt relative to the first
+	}{
+		{"short attempts", 300 * time.Millisecond, []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second}},
+		{"overrunning attempts", 1500 * time.Millisecond, []time.Duration{0, 2 * time.Second, 4 * time.Second, 6 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				r := NewRunnerWithExecutor(t.Context(), mockExecutor{sleep: tt.sleep})
+				r.Interval = time.Second
+				r.RetryDelay = time.Hour // ignored
+				r.ContinueOnSuccess = true
+				r.MaxRuns = uint(len(tt.want))
+				var starts []time.Time
+				r.Observe(func(e Event) {
+					if e.Kind == EventAttemptStart {
+						starts = append(starts, e.Attempt.Start)
+					}
+				})
+				r.Run()
+
+				var got []time.Duration
+				for _, s := range starts {
+					got = append(got, s.Sub(starts[0]))
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("attempt starts: got %v, want %v", got, tt.want)
+				}
+			})
+		})
+	}
+}
+
+func TestRunner_CrashLoop(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1})
+		r.RetryDelay = time.Second
+		r.CrashLoopLimit = 3
+		runAssert(t, r, runnerExpectedResults{err: ErrCrashLoop, runs: 3, elapsedTotal: 2 * time.Second})
+
+		// attempts failing only after MinUptime were healthy, so are neither
+		// crashes nor backed off
+		r = NewRunnerWithExecutor(t.Context(), mockExecutor{sleep: 2 * time.Minute, exitcode: 1})
+		r.RetryDelay = time.Second
+		r.Backoff = BackoffExponential
+		r.MinUptime = time.Minute
+		r.CrashLoopLimit = 2
+		r.MaxRuns = 4
+		runAssert(t, r, runnerExpectedResults{err: errMaxRunsCompleted, runs: 4, elapsedTotal: 4*2*time.Minute + 4*time.Second})
+	})
+}
+
+func TestRunner_StartAt(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		// only the first attempt waits for StartAt, the retry is delayed as usual
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1})
+		r.StartAt = time.Now().Add(time.Hour)
+		r.RetryDelay = time.Minute
+		r.MaxRuns = 2
+		runAssert(t, r, runnerExpectedResults{err: errMaxRunsCompleted, runs: 2, elapsedTotal: time.Hour + 2*time.Minute})
+
+		// a StartAt already past does not delay the first attempt
+		r = NewRunnerWithExecutor(t.Context(), mockExecutor{})
+		r.StartAt = time.Now().Add(-time.Hour)
+		runAssert(t, r, runnerExpectedResults{err: nil, runs: 1})
+	})
+}
+
+func TestRunner_Splay(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		// only the first attempt is splayed, following StartAt
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1})
+		r.StartAt = time.Now().Add(time.Hour)
+		r.Splay = 10 * time.Minute
+		r.RetryDelay = time.Second
+		r.MaxRuns = 3
+		var starts []time.Time
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptStart {
+				starts = append(starts, e.Attempt.Start)
+			}
+		})
+		r.Run()
+
+		if len(starts) != 3 {
+			t.Fatalf("got %d attempts, want 3", len(starts))
+		}
+		if lo, hi := r.StartAt, r.StartAt.Add(r.Splay); starts[0].Before(lo) || !starts[0].Before(hi) {
+			t.Errorf("first attempt: got %v, want in [%v, %v)", starts[0], lo, hi)
+		}
+		for i := 1; i < len(starts); i++ {
+			if d := starts[i].Sub(starts[i-1]); d != time.Second {
+				t.Errorf("delay before attempt %d: got %v, want %v", i+1, d, time.Second)
+			}
+		}
+	})
+}
+
+func TestRunner_attemptInterruption(t *testing.T) {
+	errShutdown := errors.New("shutdown")
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) *Runner
+		want  []error
+	}{
+		{
+			name: "process timeout",
+			setup: func(t *testing.T) *Runner {
+				r := NewRunnerWithExecutor(t.Context(), mockExecutor{sleep: time.Hour})
+				r.ProcessTimeout = time.Second
+				return r
+			},
+			want: []error{ErrProcessTimeout, context.DeadlineExceeded},
+		},
+		{
+			name: "idle timeout",
+			setup: func(t *testing.T) *Runner {
+				r := NewRunnerWithExecutor(t.Context(), mockExecutor{sleep: time.Hour})
+				r.IdleTimeout = time.Second
+				return r
+			},
+			want: []error{ErrIdleTimeout, context.Canceled},
+		},
+		{
+			name: "runner deadline",
+			setup: func(t *testing.T) *Runner {
+				ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+				t.Cleanup(cancel)
+				return NewRunnerWithExecutor(ctx, mockExecutor{sleep: time.Hour})
+			},
+			want: []error{ErrRunnerDeadline, context.DeadlineExceeded},
+		},
+		{
+			name: "runner stopped",
+			setup: func(t *testing.T) *Runner {
+				ctx, cancel := context.WithCancelCause(t.Context())
+				time.AfterFunc(time.Second, func() { cancel(errShutdown) })
+				return NewRunnerWithExecutor(ctx, mockExecutor{sleep: time.Hour})
+			},
+			want: []error{ErrRunnerStopped, errShutdown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				r := tt.setup(t)
+				r.MaxRuns = 1
+				var got error
+				r.Observe(func(e Event) {
+					if e.Kind == EventAttemptEnd {
+						got = e.Attempt.Err
+					}
+				})
+				r.Run()
+				for _, want := range tt.want {
+					if !errors.Is(got, want) {
+						t.Errorf("attempt error %q: does not wrap %q", got, want)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestRunner_DrainTimeout(t *testing.T) {
+	tests := []struct {
+		name            string
+		drain           time.Duration
+		wantErr         error
+		wantInterrupted bool
+	}{
+		{"attempt finishes", 5 * time.Second, nil, false},
+		{"attempt interrupted", time.Second, context.Canceled, true},
+		{"no drain", 0, context.Canceled, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				ctx, cancel := context.WithCancel(t.Context())
+				time.AfterFunc(time.Second, cancel)
+				r := NewRunnerWithExecutor(ctx, mockExecutor{sleep: 3 * time.Second})
+				r.DrainTimeout = tt.drain
+				var last Attempt
+				var attempts int
+				r.Observe(func(e Event) {
+					if e.Kind == EventAttemptEnd {
+						last = e.Attempt
+						attempts++
+					}
+				})
+
+				if err := r.Run(); !errors.Is(err, tt.wantErr) {
+					t.Errorf("got error %v, want %v", err, tt.wantErr)
+				}
+				if attempts != 1 {
+					t.Errorf("got %d attempts, want 1", attempts)
+				}
+				if last.Interrupted != tt.wantInterrupted {
+					t.Errorf("interrupted: got %v, want %v", last.Interrupted, tt.wantInterrupted)
+				}
+				if tt.wantInterrupted && !errors.Is(last.Err, ErrRunnerStopped) {
+					t.Errorf("attempt error: got %v, want %v", last.Err, ErrRunnerStopped)
+				}
+			})
+		})
+	}
+}
+
+func TestRunner_DrainTimeout_clock(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		time.AfterFunc(time.Second, cancel)
+		c := &countingClock{}
+		r := NewRunnerWithExecutor(ctx, mockExecutor{sleep: 3 * time.Second})
+		r.SetClock(c)
+		r.DrainTimeout = time.Second
+		if err := r.Run(); !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v, want %v", err, context.Canceled)
+		}
+		if got := c.funcs.Load(); got != 1 {
+			t.Errorf("scheduled %d funcs on the clock, want 1", got)
+		}
+	})
+}
+
+// chattyExecutor is an Executor writing a line of output every interval, for
+// the given duration.
+type chattyExecutor struct {
+	interval, duration time.Duration
+}
+
+func (ce chattyExecutor) Run(ctx context.Context, opts CommandOpts, name string, args ...string) error {
+	ticker := time.NewTicker(ce.interval)
+	defer ticker.Stop()
+	done := time.After(ce.duration)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+			return nil
+		case <-ticker.C:
+			opts.Stdout.Write([]byte("working\n"))
+		}
+	}
+}
+
+func TestRunner_IdleTimeout(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		// output restarts the idle timeout, so the attempt runs to completion
+		r := NewRunner(t.Context(), "chatty")
+		r.SetExecutor(chattyExecutor{interval: time.Second, duration: 10 * time.Second})
+		r.IdleTimeout = 2 * time.Second
+		runAssert(t, r, runnerExpectedResults{err: nil, runs: 1, elapsedTotal: 10 * time.Second})
+	})
+}
+
+func TestRunner_Verify(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		errPending := errors.New("pending")
+		var calls int
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{})
+		r.Verify = func(ctx context.Context) error {
+			if calls++; calls < 3 {
+				return errPending
+			}
+			return nil
+		}
+		var errs []error
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptEnd {
+				errs = append(errs, e.Attempt.Err)
+			}
+		})
+
+		runAssert(t, r, runnerExpectedResults{err: nil, runs: 3})
+		if len(errs) != 3 || !errors.Is(errs[0], ErrVerifyFailed) || !errors.Is(errs[1], errPending) || errs[2] != nil {
+			t.Errorf("attempt errors: got %v, want two wrapping %v, then <nil>", errs, ErrVerifyFailed)
+		}
+	})
+}
+
+func TestRunner_UntilFailure(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "flaky")
+		r.SetExecutor(&scriptedExecutor{outputs: []string{"", "", "", ""}, exitcode: []int{0, 0, 2, 0}})
+		r.CommandOptions.Stdout = io.Discard
+		r.UntilFailure = true
+		runAssert(t, r, runnerExpectedResults{err: ErrAttemptFailed, runs: 3})
+
+		r = NewRunnerWithExecutor(t.Context(), mockExecutor{})
+		r.UntilFailure = true
+		r.MaxRuns = 5
+		runAssert(t, r, runnerExpectedResults{err: nil, runs: 5})
+	})
+}
+
+func TestRunner_ConsecutiveSuccesses(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "healthcheck")
+		r.SetExecutor(&scriptedExecutor{outputs: make([]string, 7), exitcode: []int{0, 0, 1, 0, 0, 0, 1}})
+		r.CommandOptions.Stdout = io.Discard
+		r.RetryDelay = time.Second
+		r.ConsecutiveSuccesses = 3
+		runAssert(t, r, runnerExpectedResults{err: nil, runs: 6, elapsedTotal: 5 * time.Second})
+	})
+}
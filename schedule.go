@@ -0,0 +1,74 @@
+package wut
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Schedule determines the times at which [Runner.RunSchedule] runs the
+// Runner, such as a [Cron] expression.
+type Schedule interface {
+	// Next returns the first scheduled time after t, or the zero time if
+	// there are no further scheduled times.
+	Next(t time.Time) time.Time
+}
+
+// RunSchedule runs the Runner at each time given by the schedule, as if by
+// calling Run, until its context is done or the schedule has no further times.
+//
+// Each scheduled run applies the retry, timeout, and stop conditions of the
+// Runner afresh, so for example MaxRuns limits the attempts of each run rather
+// than in total, and a Lock is held only for the duration of each run. A failed
+// run is logged and does not stop the schedule. Runs never overlap: if a run
+// is still in progress at a scheduled time, that time is skipped.
+//
+// RunSchedule returns nil once the schedule is exhausted, or otherwise the
+// cause of the cancellation of the context of the Runner.
+func (r *Runner) RunSchedule(s Schedule) error {
+	timer := r.clock.NewTimer(0)
+	stopTimer(timer)
+	defer timer.Stop()
+	return r.runEach(func() (bool, error) {
+		now := r.clock.Now()
+		next := s.Next(now)
+		if next.IsZero() {
+			r.log(slog.LevelInfo, "Schedule exhausted")
+			return false, nil
+		}
+		r.log(slog.LevelInfo, "Waiting for next scheduled run", "at", next)
+		timer.Reset(next.Sub(now))
+		select {
+		case <-r.baseCtx.Done():
+			return false, context.Cause(r.baseCtx)
+		case <-timer.C():
+			return true, nil
+		}
+	})
+}
+
+// runEach runs the Runner as if by Run each time wait returns true, until it
+// returns false or an error, or the context of the Runner is done. A failed
+// run is logged rather than returned.
+func (r *Runner) runEach(wait func() (bool, error)) error {
+	if !r.shared.running.CompareAndSwap(false, true) {
+		return errRedundantStartCall
+	}
+	defer r.shared.running.Store(false)
+
+	for {
+		if ok, err := wait(); !ok || err != nil {
+			return err
+		}
+
+		r.shared.runsCompleted.Store(0)
+		snap := *r
+		err := snap.run()
+		if r.baseCtx.Err() != nil {
+			return context.Cause(r.baseCtx)
+		}
+		if err != nil {
+			r.log(slog.LevelWarn, "Run failed", "error", err)
+		}
+	}
+}
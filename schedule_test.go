@@ -0,0 +1,80 @@
+package wut
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+// timesSchedule is a Schedule of a fixed list of times.
+type timesSchedule []time.Time
+
+func (ts timesSchedule) Next(t time.Time) time.Time {
+	for _, next := range ts {
+		if next.After(t) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func TestRunner_RunSchedule(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		start := time.Now()
+		sched := timesSchedule{
+			start.Add(time.Minute),
+			start.Add(90 * time.Second), // missed while the previous run retries
+			start.Add(4 * time.Minute),
+			start.Add(7 * time.Minute),
+		}
+
+		// each run fails once then succeeds, so MaxRuns applies per run
+		r := NewRunner(t.Context(), "flaky")
+		r.SetExecutor(&scriptedExecutor{outputs: make([]string, 6), exitcode: []int{1, 0, 1, 0, 1, 0}})
+		r.CommandOptions.Stdout = io.Discard
+		r.RetryDelay = time.Minute
+		r.MaxRuns = 2
+		var starts []time.Duration
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptStart {
+				starts = append(starts, e.Attempt.Start.Sub(start))
+			}
+		})
+
+		if err := r.RunSchedule(sched); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []time.Duration{1 * time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 7 * time.Minute, 8 * time.Minute}
+		if !slices.Equal(starts, want) {
+			t.Errorf("attempt starts: got %v, want %v", starts, want)
+		}
+	})
+}
+
+func TestRunner_RunScheduleCancel(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		errStop := errors.New("stop")
+		ctx, cancel := context.WithCancelCause(t.Context())
+		time.AfterFunc(90*time.Second, func() { cancel(errStop) })
+
+		sched, err := ParseCron("* * * * *")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := NewRunnerWithExecutor(ctx, mockExecutor{})
+		start := time.Now()
+		if err := r.RunSchedule(sched); !errors.Is(err, errStop) {
+			t.Errorf("got error %v, want %v", err, errStop)
+		}
+		if elapsed := time.Since(start); elapsed != 90*time.Second {
+			t.Errorf("elapsed: got %v, want %v", elapsed, 90*time.Second)
+		}
+		if got := r.RunsCompleted(); got != 1 {
+			t.Errorf("runs completed: got %d, want 1", got)
+		}
+	})
+}
@@ -0,0 +1,12 @@
+module github.com/mroth/wut/sshexec
+
+go 1.25.0
+
+require (
+	github.com/mroth/wut v0.0.0
+	golang.org/x/crypto v0.54.0
+)
+
+require golang.org/x/sys v0.47.0 // indirect
+
+replace github.com/mroth/wut => ..
@@ -0,0 +1,130 @@
+// Package sshexec provides a [wut.Executor] that runs commands on a remote host over SSH.
+//
+// It is a module of its own, github.com/mroth/wut/sshexec, keeping
+// golang.org/x/crypto out of the dependencies of wut itself.
+package sshexec
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/mroth/wut"
+	"golang.org/x/crypto/ssh"
+)
+
+// Executor runs commands on a remote host over SSH.
+//
+// A new connection is established for every run, so transient connection
+// failures are treated like any other failed attempt and retried by the Runner.
+//
+// The Stdin, Stdout, and Stderr streams of [wut.CommandOpts] are attached to the
+// remote session. Env entries are requested via the SSH "env" mechanism, which
+// most servers restrict (see AcceptEnv in sshd_config); a rejected variable
+// fails the run. Dir is honored by changing directory in the remote shell prior
+// to running the command. Cancel and WaitDelay are not applicable: when the
+// context is done, the remote process is signalled and the connection closed.
+type Executor struct {
+	Addr   string            // remote address in host or host:port form, port 22 is used if omitted
+	Config *ssh.ClientConfig // client configuration including user, authentication and host key verification
+}
+
+// verify Executor implements the wut.Executor interface
+var _ wut.Executor = Executor{}
+
+func (e Executor) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	addr := e.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	// Until the handshake completes, closing the underlying conn is the only
+	// way to abort it on cancellation.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, e.Config)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	for _, kv := range opts.Env {
+		k, v, _ := strings.Cut(kv, "=")
+		if err := session.Setenv(k, v); err != nil {
+			return fmt.Errorf("sshexec: setting env %s: %w", k, err)
+		}
+	}
+	session.Stdin = opts.Stdin
+	session.Stdout = opts.Stdout
+	session.Stderr = opts.Stderr
+
+	if err := session.Start(command(opts.Dir, name, args...)); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- session.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		_ = session.Signal(ssh.SIGKILL) // best effort, not all servers support signals
+		client.Close()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// command builds the remote shell command line for the given command.
+func command(dir, name string, args ...string) string {
+	var b strings.Builder
+	if dir != "" {
+		b.WriteString("cd " + quote(dir) + " && ")
+	}
+	b.WriteString(quote(name))
+	for _, arg := range args {
+		b.WriteString(" " + quote(arg))
+	}
+	return b.String()
+}
+
+// quote quotes s for safe interpretation as a single word by a POSIX shell.
+func quote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,@%+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// PrivateKeyFile returns an [ssh.AuthMethod] using the unencrypted PEM encoded
+// private key read from the named file.
+func PrivateKeyFile(path string) (ssh.AuthMethod, error) {
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("sshexec: parsing private key %s: %w", path, err)
+	}
+	return ssh.PublicKeys(signer), nil
+}
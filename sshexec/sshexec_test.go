@@ -0,0 +1,153 @@
+package sshexec
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mroth/wut"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"ls", "ls"},
+		{"/usr/bin/env", "/usr/bin/env"},
+		{"", "''"},
+		{"hello world", "'hello world'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+	}
+	for _, tt := range tests {
+		if got := quote(tt.in); got != tt.want {
+			t.Errorf("quote(%q): got %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	got := command("/tmp/my dir", "echo", "a b", "c")
+	want := `cd '/tmp/my dir' && echo 'a b' c`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestExecutor_Run(t *testing.T) {
+	addr := startTestServer(t)
+	e := Executor{
+		Addr: addr,
+		Config: &ssh.ClientConfig{
+			User:            "tester",
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := e.Run(t.Context(), wut.CommandOpts{Stdout: &stdout}, "echo", "hello world")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, want := stdout.String(), "echo 'hello world'"; got != want {
+			t.Errorf("stdout: got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("exit status", func(t *testing.T) {
+		err := e.Run(t.Context(), wut.CommandOpts{}, "fail")
+		var exitErr *ssh.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitStatus() != 3 {
+			t.Errorf("got %v, want exit status 3", err)
+		}
+	})
+
+	t.Run("context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		err := e.Run(ctx, wut.CommandOpts{}, "hang")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}
+
+// startTestServer starts an SSH server on a local port, returning its address.
+//
+// The server accepts any client and responds to exec requests by writing the
+// received command line to stdout. Commands starting with "fail" exit with
+// status 3, and commands starting with "hang" never exit.
+func startTestServer(t *testing.T) string {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestConn(conn, config)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveTestConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newCh := range chans {
+		ch, requests, err := newCh.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer ch.Close()
+			for req := range requests {
+				if req.Type != "exec" {
+					req.Reply(false, nil)
+					continue
+				}
+				req.Reply(true, nil)
+
+				cmd := string(req.Payload[4:]) // skip uint32 length prefix
+				if strings.HasPrefix(cmd, "hang") {
+					continue
+				}
+				ch.Write([]byte(cmd))
+				status := uint32(0)
+				if strings.HasPrefix(cmd, "fail") {
+					status = 3
+				}
+				ch.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, status))
+				return
+			}
+		}()
+	}
+}
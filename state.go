@@ -0,0 +1,59 @@
+package wut
+
+// RunnerState represents the state of a Runner.
+type RunnerState int
+
+const (
+	RunnerStateIdle      RunnerState = iota // Runner is not currently executing a command, such as before it has started or while waiting out a retry delay.
+	RunnerStateRunning                      // Runner is currently executing a command.
+	RunnerStateCompleted                    // Runner has completed its success criteria and is no longer running.
+	RunnerStateErrored                      // Runner met an exit/failure condition prior to success (for example, timed out).
+)
+
+func (s RunnerState) String() string {
+	switch s {
+	case RunnerStateIdle:
+		return "idle"
+	case RunnerStateRunning:
+		return "running"
+	case RunnerStateCompleted:
+		return "completed"
+	case RunnerStateErrored:
+		return "errored"
+	default:
+		return "unknown"
+	}
+}
+
+// State returns the current state of the Runner. It is safe to call from any
+// goroutine, including while the Runner is running.
+func (r *Runner) State() RunnerState {
+	return RunnerState(r.shared.state.Load())
+}
+
+// stateAfter returns the state of a Runner once it has emitted e.
+func stateAfter(e Event) RunnerState {
+	switch e.Kind {
+	case EventAttemptStart:
+		return RunnerStateRunning
+	case EventRunEnd:
+		if e.Err != nil {
+			return RunnerStateErrored
+		}
+		return RunnerStateCompleted
+	default:
+		return RunnerStateIdle
+	}
+}
+
+// MarshalText implements [encoding.TextMarshaler], encoding the state as its
+// String, such as for JSON.
+func (s RunnerState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler], decoding a state
+// encoded by MarshalText.
+func (s *RunnerState) UnmarshalText(text []byte) error {
+	return unmarshalEnum(s, text, RunnerStateErrored)
+}
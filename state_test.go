@@ -0,0 +1,62 @@
+package wut
+
+import (
+	"testing"
+	"testing/synctest"
+)
+
+func TestRunner_State(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1})
+		r.MaxRuns = 2
+		if got := r.State(); got != RunnerStateIdle {
+			t.Errorf("before run: got %v, want %v", got, RunnerStateIdle)
+		}
+
+		var states []RunnerState
+		r.Observe(func(e Event) {
+			if e.Kind == EventAttemptStart || e.Kind == EventAttemptEnd {
+				states = append(states, r.State())
+			}
+		})
+		r.Run()
+
+		want := []RunnerState{RunnerStateRunning, RunnerStateIdle, RunnerStateRunning, RunnerStateIdle}
+		if len(states) != len(want) {
+			t.Fatalf("got states %v, want %v", states, want)
+		}
+		for i := range want {
+			if states[i] != want[i] {
+				t.Errorf("state %d: got %v, want %v", i, states[i], want[i])
+			}
+		}
+		if got := r.State(); got != RunnerStateErrored {
+			t.Errorf("after run: got %v, want %v", got, RunnerStateErrored)
+		}
+	})
+
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{})
+		r.Run()
+		if got := r.State(); got != RunnerStateCompleted {
+			t.Errorf("after success: got %v, want %v", got, RunnerStateCompleted)
+		}
+	})
+}
+
+func TestRunnerState_Text(t *testing.T) {
+	for state := RunnerStateIdle; state <= RunnerStateErrored; state++ {
+		text, err := state.MarshalText()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got RunnerState
+		if err := got.UnmarshalText(text); err != nil || got != state {
+			t.Errorf("%q: got %v, %v, want %v", text, got, err, state)
+		}
+	}
+	var s RunnerState
+	if err := s.UnmarshalText([]byte("bogus")); err == nil {
+		t.Errorf("expected error for unknown state")
+	}
+}
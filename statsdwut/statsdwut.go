@@ -0,0 +1,69 @@
+// Package statsdwut sends metrics about the attempts made by a [wut.Runner] to
+// a StatsD server over UDP, with optional DogStatsD-style tags for Datadog and
+// compatible agents.
+package statsdwut
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// Sink is a [wut.Metrics] adapter sending the following StatsD metrics:
+//
+//   - wut.attempts.success, wut.attempts.failure: counters of attempts at
+//     executing the command, by outcome
+//   - wut.attempt.duration: a timer of attempt durations in milliseconds
+//
+// Metrics are sent on a best-effort basis, with any errors ignored.
+type Sink struct {
+	Prefix string   // prefix for all metric names, such as "myapp."
+	Tags   []string // tags in "key:value" form added to all metrics, requiring a DogStatsD compatible server
+
+	conn net.Conn
+}
+
+// verify Sink implements the wut.Metrics interface
+var _ wut.Metrics = (*Sink)(nil)
+
+// NewSink returns a Sink sending metrics to the StatsD server at addr, such as
+// "localhost:8125". Provide it to a Runner with [wut.Runner.SetMetrics].
+func NewSink(addr string) (*Sink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Sink{conn: conn}, nil
+}
+
+// IncAttempt implements [wut.Metrics].
+func (s *Sink) IncAttempt(result wut.AttemptResult) {
+	s.send("wut.attempts."+string(result), "1", "c")
+}
+
+// ObserveDuration implements [wut.Metrics].
+func (s *Sink) ObserveDuration(d time.Duration) {
+	ms := strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', -1, 64)
+	s.send("wut.attempt.duration", ms, "ms")
+}
+
+// SetState implements [wut.Metrics]. The state of the Runner is not sent.
+func (s *Sink) SetState(wut.RunnerState) {}
+
+// send writes a single metric in the StatsD line protocol.
+func (s *Sink) send(name, value, typ string) {
+	line := fmt.Sprintf("%s%s:%s|%s", s.Prefix, name, value, typ)
+	if len(s.Tags) > 0 {
+		line += "|#" + strings.Join(s.Tags, ",")
+	}
+	s.conn.Write([]byte(line))
+}
+
+// Close closes the connection to the StatsD server.
+func (s *Sink) Close() error {
+	return s.conn.Close()
+}
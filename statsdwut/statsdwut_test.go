@@ -0,0 +1,64 @@
+package statsdwut
+
+import (
+	"log/slog"
+	"net"
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/mroth/wut"
+	"github.com/mroth/wut/wuttest"
+)
+
+func TestSink(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	sink, err := NewSink(server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	sink.Prefix = "app."
+	sink.Tags = []string{"env:test", "job:wut"}
+
+	// run within a bubble so that the attempt durations are exact
+	synctest.Test(t, func(t *testing.T) {
+		r := wut.NewRunner(t.Context(), "cmd")
+		r.SetLogger(slog.New(slog.DiscardHandler))
+		r.SetExecutor(wuttest.NewExecutor(
+			wuttest.Outcome{ExitCode: 1},
+			wuttest.Outcome{Sleep: 1500 * time.Microsecond},
+		))
+		r.SetMetrics(sink)
+		if err := r.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	var got []string
+	buf := make([]byte, 512)
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(got) < 4 {
+		n, _, err := server.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("after %q: %v", got, err)
+		}
+		got = append(got, string(buf[:n]))
+	}
+
+	want := []string{
+		"app.wut.attempts.failure:1|c|#env:test,job:wut",
+		"app.wut.attempt.duration:0|ms|#env:test,job:wut",
+		"app.wut.attempts.success:1|c|#env:test,job:wut",
+		"app.wut.attempt.duration:1.5|ms|#env:test,job:wut",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
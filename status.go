@@ -0,0 +1,96 @@
+package wut
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status is a snapshot of the progress of a Runner, as reported by a
+// StatusTracker.
+type Status struct {
+	State               RunnerState `json:"state"`
+	Paused              bool        `json:"paused"` // see Runner.Pause
+	RunsCompleted       uint        `json:"runs_completed"`
+	ConsecutiveFailures uint        `json:"consecutive_failures"`
+	LastSuccess         time.Time   `json:"last_success,omitzero"` // end of the last successful attempt, if any
+	LastError           string      `json:"last_error,omitempty"`  // error of the last attempt, if it failed
+}
+
+// Healthy reports whether the Runner is healthy, meaning that it has not
+// stopped with an error, and that its last attempt, if any, succeeded.
+func (s Status) Healthy() bool {
+	return s.State != RunnerStateErrored && s.ConsecutiveFailures == 0
+}
+
+// StatusTracker tracks the Status of a Runner, so that it can be checked on
+// from other goroutines, such as over HTTP by load balancers and humans via
+// its Handler. It is safe for concurrent use.
+type StatusTracker struct {
+	r      *Runner
+	mu     sync.Mutex
+	status Status
+}
+
+// TrackStatus registers an observer with r tracking its Status. It must not
+// be called while the Runner is running.
+func TrackStatus(r *Runner) *StatusTracker {
+	t := &StatusTracker{r: r}
+	r.Observe(t.record)
+	return t
+}
+
+func (t *StatusTracker) record(e Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.State = stateAfter(e)
+	switch e.Kind {
+	case EventRunStart:
+		t.status.ConsecutiveFailures, t.status.LastError = 0, ""
+	case EventAttemptEnd:
+		t.status.RunsCompleted++
+		if e.Attempt.Err != nil {
+			t.status.ConsecutiveFailures++
+			t.status.LastError = e.Attempt.Err.Error()
+		} else {
+			t.status.ConsecutiveFailures, t.status.LastError = 0, ""
+			t.status.LastSuccess = e.Time
+		}
+	case EventRunEnd:
+		if e.Err != nil {
+			t.status.LastError = e.Err.Error()
+		}
+	}
+}
+
+// Status returns the current Status of the Runner.
+func (t *StatusTracker) Status() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.status
+	s.Paused = t.r.Paused()
+	return s
+}
+
+// Handler returns an http.Handler serving the following endpoints:
+//
+//   - /healthz: responds 200 OK if the Runner is healthy, or otherwise 503
+//     Service Unavailable, see [Status.Healthy]
+//   - /status: responds with the current Status as JSON
+func (t *StatusTracker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, req *http.Request) {
+		if !t.Status().Healthy() {
+			http.Error(w, "unhealthy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(t.Status())
+	})
+	return mux
+}
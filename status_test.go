@@ -0,0 +1,64 @@
+package wut
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestStatusTracker(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "svc")
+		r.SetExecutor(&scriptedExecutor{
+			outputs:  []string{"", "", ""},
+			exitcode: []int{0, 1, 1},
+		})
+		r.CommandOptions.Stdout = io.Discard
+		r.ContinueOnSuccess = true
+		r.MaxRuns = 3
+		r.RetryDelay = time.Second
+		tracker := TrackStatus(r)
+		h := tracker.Handler()
+
+		get := func(path string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			return rec
+		}
+
+		start := time.Now()
+		go r.Run()
+		synctest.Wait() // first attempt succeeded, waiting for the retry delay
+		if rec := get("/healthz"); rec.Code != http.StatusOK {
+			t.Errorf("/healthz after success: got %d, want %d", rec.Code, http.StatusOK)
+		}
+
+		time.Sleep(time.Second)
+		synctest.Wait() // second attempt failed
+		if rec := get("/healthz"); rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("/healthz after failure: got %d, want %d", rec.Code, http.StatusServiceUnavailable)
+		}
+
+		rec := get("/status")
+		var got map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON %q: %v", rec.Body, err)
+		}
+		want := map[string]any{
+			"state":                "idle",
+			"runs_completed":       2.0,
+			"consecutive_failures": 1.0,
+			"last_success":         start.UTC().Format(time.RFC3339),
+			"last_error":           "exit status 1",
+		}
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("/status %s: got %v, want %v", k, got[k], v)
+			}
+		}
+	})
+}
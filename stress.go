@@ -0,0 +1,139 @@
+package wut
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errStressComplete is the cause used to stop stress workers once the stress
+// duration has elapsed.
+var errStressComplete = errors.New("wut: stress duration elapsed")
+
+// StressReport summarizes the outcomes of a stress run.
+type StressReport struct {
+	Workers int           // number of concurrent workers
+	Elapsed time.Duration // total time elapsed
+
+	// Results summarizes the durations of the successful runs and the number
+	// of failed runs, across all workers.
+	Results BenchmarkResult
+
+	// Signatures groups the failed runs by their failure signature, most
+	// frequent first. Attempt numbers count runs across all workers in order
+	// of completion.
+	Signatures []FailureSignature
+}
+
+// Runs returns the total number of runs completed across all workers.
+func (sr StressReport) Runs() uint {
+	return uint(len(sr.Results.Durations)) + sr.Results.Failures
+}
+
+// String returns a human readable summary of the report.
+func (sr StressReport) String() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "workers: %d, elapsed: %v, throughput: %.2f runs/s\n",
+		sr.Workers, sr.Elapsed, float64(sr.Runs())/sr.Elapsed.Seconds())
+	s.WriteString(sr.Results.String())
+	for _, sig := range sr.Signatures {
+		fmt.Fprintf(&s, "%6dx %s\n", sig.Count, sig.Signature)
+	}
+	return s.String()
+}
+
+// Stress executes workers concurrent copies of the command repeatedly, each
+// regardless of outcome, until duration has elapsed, and reports on the
+// aggregated outcomes.
+//
+// Each worker behaves like the Runner would with ContinueOnSuccess set,
+// applying its configured RetryDelay, ProcessTimeout, and MaxRuns. Runs still
+// in progress once the duration has elapsed are cancelled and not counted.
+// As with [Runner.Flakes], output is captured to determine failure signatures,
+// using a default CaptureLimit for the workers if unset, and the Runner itself
+// is left unchanged. Any Stdout and Stderr writers configured in
+// CommandOptions receive the output of all workers through a [Multiplexer],
+// with each line prefixed by the number of its worker, colored if the writer
+// is a terminal and the NO_COLOR environment variable is unset.
+//
+// If the Runner is stopped early via its context, the report so far is
+// returned along with the reason for stopping.
+func (r *Runner) Stress(workers int, duration time.Duration) (StressReport, error) {
+	if !r.shared.running.CompareAndSwap(false, true) {
+		return StressReport{}, errRedundantStartCall
+	}
+	defer r.shared.running.Store(false)
+	report := StressReport{Workers: workers}
+
+	ctx, cancel := context.WithTimeoutCause(r.baseCtx, duration, errStressComplete)
+	defer cancel()
+
+	var (
+		mu   sync.Mutex // guards report and sigs
+		sigs signatureSet
+		wg   sync.WaitGroup
+	)
+	var stdoutMux, stderrMux *Multiplexer
+	if w := r.CommandOptions.Stdout; w != nil {
+		stdoutMux = NewMultiplexer(w)
+		stdoutMux.Color = isColorTerminal(w)
+	}
+	if w := r.CommandOptions.Stderr; w != nil && w == r.CommandOptions.Stdout {
+		stderrMux = stdoutMux
+	} else if w != nil {
+		stderrMux = NewMultiplexer(w)
+		stderrMux.Color = isColorTerminal(w)
+	}
+	start := r.clock.Now()
+	for i := range workers {
+		w := r.derive(ctx)
+		w.ContinueOnSuccess = true
+		w.retryRegardless()
+		if w.CaptureLimit <= 0 {
+			w.CaptureLimit = defaultFlakeCaptureLimit
+		}
+		var muxWriters []*MuxWriter
+		name := fmt.Sprintf("worker %d", i+1)
+		if stdoutMux != nil {
+			mw := stdoutMux.Writer(name)
+			w.CommandOptions.Stdout = mw
+			muxWriters = append(muxWriters, mw)
+		}
+		if stderrMux != nil {
+			mw := stderrMux.Writer(name)
+			w.CommandOptions.Stderr = mw
+			muxWriters = append(muxWriters, mw)
+		}
+		w.Observe(func(e Event) {
+			if e.Kind != EventAttemptEnd {
+				return
+			}
+			for _, mw := range muxWriters {
+				mw.Flush()
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if e.Attempt.Err == nil {
+				report.Results.Durations = append(report.Results.Durations, e.Attempt.Duration)
+			} else {
+				report.Results.Failures++
+				sigs.add(e.Attempt, report.Runs())
+			}
+		})
+		wg.Go(func() { w.Run() })
+	}
+	wg.Wait()
+
+	report.Elapsed = r.clock.Now().Sub(start)
+	report.Signatures = sigs.sorted()
+	if err := context.Cause(ctx); !errors.Is(err, errStressComplete) {
+		return report, err
+	}
+	return report, nil
+}
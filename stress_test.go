@@ -0,0 +1,148 @@
+package wut
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+// alternatingExecutor is an Executor that takes a fixed time per run, failing
+// every other run across all concurrent callers.
+type alternatingExecutor struct {
+	sleep time.Duration
+	runs  atomic.Int64
+}
+
+func (ae *alternatingExecutor) Run(ctx context.Context, opts CommandOpts, name string, args ...string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(ae.sleep):
+	}
+	if ae.runs.Add(1)%2 == 0 {
+		opts.Stdout.Write([]byte("boom\n"))
+		return exitCodeError(1)
+	}
+	return nil
+}
+
+func TestRunner_Stress(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunner(t.Context(), "svc")
+		r.SetExecutor(&alternatingExecutor{sleep: 10 * time.Millisecond})
+		r.RetryDelay = 0
+
+		// 4 workers each completing a run every 10ms for 105ms
+		report, err := r.Stress(4, 105*time.Millisecond)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := report.Runs(); got != 40 {
+			t.Errorf("runs: got %d, want 40", got)
+		}
+		if got := report.Results.Failures; got != 20 {
+			t.Errorf("failures: got %d, want 20", got)
+		}
+		if len(report.Signatures) != 1 || report.Signatures[0].Signature != "exit code 1: boom" {
+			t.Errorf("signatures: got %+v", report.Signatures)
+		}
+		if report.Elapsed != 105*time.Millisecond {
+			t.Errorf("elapsed: got %v, want %v", report.Elapsed, 105*time.Millisecond)
+		}
+		if r.CaptureLimit != 0 {
+			t.Errorf("CaptureLimit: got %d, want unchanged", r.CaptureLimit)
+		}
+	})
+
+	t.Run("seeded jitter", func(t *testing.T) {
+		// the workers draw their delays from sources seeded by the Runner
+		starts := func() []time.Duration {
+			var got []time.Duration
+			synctest.Test(t, func(t *testing.T) {
+				var mu sync.Mutex
+				start := time.Now()
+				r := NewRunner(t.Context(), "svc")
+				r.SetExecutor(executorFunc(func() {
+					mu.Lock()
+					defer mu.Unlock()
+					got = append(got, time.Since(start))
+				}))
+				r.RetryDelay = 0
+				r.Jitter = 10 * time.Millisecond
+				r.SetRandSource(rand.NewPCG(1, 2))
+				if _, err := r.Stress(2, 50*time.Millisecond); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			})
+			slices.Sort(got)
+			return got
+		}
+		if a, b := starts(), starts(); !slices.Equal(a, b) {
+			t.Errorf("attempt starts differ between runs: %v and %v", a, b)
+		}
+	})
+
+	t.Run("already running", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			r := NewRunner(t.Context(), "svc")
+			r.SetExecutor(&alternatingExecutor{sleep: 10 * time.Millisecond})
+			go r.Run()
+			synctest.Wait()
+			if _, err := r.Stress(2, time.Second); !errors.Is(err, errRedundantStartCall) {
+				t.Errorf("got %v, want %v", err, errRedundantStartCall)
+			}
+		})
+	})
+
+	t.Run("stopped early", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+			defer cancel()
+			r := NewRunner(ctx, "svc")
+			r.SetExecutor(&alternatingExecutor{sleep: 10 * time.Millisecond})
+
+			if _, err := r.Stress(2, time.Hour); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+			}
+		})
+	})
+
+	t.Run("output", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			r := NewRunner(t.Context(), "svc")
+			r.SetExecutor(&alternatingExecutor{sleep: 10 * time.Millisecond})
+			r.RetryDelay = 0
+			var out strings.Builder // writes are serialized by the Multiplexer
+			r.CommandOptions.Stdout = &out
+
+			if _, err := r.Stress(2, 25*time.Millisecond); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("got output %q, want 2 lines", out.String())
+			}
+			for _, line := range lines {
+				if !strings.HasPrefix(line, "worker ") || !strings.HasSuffix(line, " | boom") {
+					t.Errorf("got line %q, want prefixed by worker", line)
+				}
+			}
+		})
+	})
+}
+
+// executorFunc is an Executor calling the function for each run, which
+// succeeds immediately.
+type executorFunc func()
+
+func (fn executorFunc) Run(ctx context.Context, opts CommandOpts, name string, args ...string) error {
+	fn()
+	return nil
+}
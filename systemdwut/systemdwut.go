@@ -0,0 +1,103 @@
+// Package systemdwut reports the progress of a [wut.Runner] to systemd via the
+// sd_notify protocol, so that units of Type=notify wrapping it report
+// meaningful state, as shown by systemctl status, and keeps the systemd
+// watchdog alive while the command is healthy.
+package systemdwut
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/mroth/wut"
+)
+
+// Notify registers an observer with r which notifies systemd of its progress,
+// if the NOTIFY_SOCKET environment variable is set, reporting whether it is.
+//
+// READY=1 is sent following the first successful attempt, and STATUS is
+// updated with the number of the current attempt and the error of the last
+// failed one. STOPPING=1 is sent once the Runner stops. Notifications are sent
+// on a best-effort basis, with any errors ignored once connected.
+//
+// Notify must not be called while the Runner is running.
+func Notify(r *wut.Runner) (bool, error) {
+	conn, err := dial()
+	if conn == nil {
+		return false, err
+	}
+	n := &notifier{conn: conn}
+	r.Observe(n.observe)
+	return true, nil
+}
+
+// dial connects to the socket in NOTIFY_SOCKET, returning nil if it is unset.
+func dial() (*net.UnixConn, error) {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(addr, "@") {
+		addr = "\x00" + addr[1:] // abstract socket
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("systemdwut: %w", err)
+	}
+	return conn, nil
+}
+
+// notifier sends notifications for the events of a Runner. As observers are
+// called synchronously from the goroutine executing Run, it requires no
+// locking.
+type notifier struct {
+	conn    *net.UnixConn
+	ready   bool
+	lastErr error // error of the last failed attempt
+}
+
+func (n *notifier) observe(e wut.Event) {
+	switch e.Kind {
+	case wut.EventAttemptStart:
+		status := fmt.Sprintf("Running attempt %d", e.Attempt.Num)
+		if n.lastErr != nil {
+			status += fmt.Sprintf(" (last error: %v)", n.lastErr)
+		}
+		n.send("STATUS=" + status)
+
+	case wut.EventAttemptEnd:
+		a := e.Attempt
+		if a.Err != nil {
+			n.lastErr = a.Err
+			n.send(fmt.Sprintf("STATUS=Attempt %d failed: %v", a.Num, a.Err))
+			return
+		}
+		n.lastErr = nil
+		status := fmt.Sprintf("STATUS=Attempt %d succeeded", a.Num)
+		if !n.ready {
+			n.ready = true
+			n.send("READY=1", status)
+			return
+		}
+		n.send(status)
+
+	case wut.EventDelay:
+		if n.lastErr != nil {
+			n.send(fmt.Sprintf("STATUS=Retrying in %v (last error: %v)", e.Delay, n.lastErr))
+		}
+
+	case wut.EventRunEnd:
+		status := "STATUS=Completed successfully"
+		if e.Err != nil {
+			status = fmt.Sprintf("STATUS=Stopped: %v", e.Err)
+		}
+		n.send("STOPPING=1", status)
+		n.conn.Close()
+	}
+}
+
+// send sends a notification of the given newline separated assignments.
+func (n *notifier) send(assignments ...string) {
+	n.conn.Write([]byte(strings.Join(assignments, "\n")))
+}
@@ -0,0 +1,66 @@
+package systemdwut
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mroth/wut"
+	"github.com/mroth/wut/wuttest"
+)
+
+func TestNotify(t *testing.T) {
+	// socket paths are limited in length, so avoid the long t.TempDir
+	dir, err := os.MkdirTemp("", "sd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	r := wut.NewRunner(t.Context(), "cmd")
+	r.SetExecutor(wuttest.FailTimes(1))
+	r.RetryDelay = 0
+	if ok, err := Notify(r); !ok || err != nil {
+		t.Fatalf("got %v, %v, want enabled", ok, err)
+	}
+	if err := r.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	buf := make([]byte, 1024)
+	for range 5 {
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(buf[:n]))
+	}
+	want := []string{
+		"STATUS=Running attempt 1",
+		"STATUS=Attempt 1 failed: exit status 1",
+		"STATUS=Running attempt 2 (last error: exit status 1)",
+		"READY=1\nSTATUS=Attempt 2 succeeded",
+		"STOPPING=1\nSTATUS=Completed successfully",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got notifications %q, want %q", got, want)
+	}
+}
+
+func TestNotify_disabled(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	r := wut.NewRunner(t.Context(), "cmd")
+	if ok, err := Notify(r); ok || err != nil {
+		t.Errorf("got %v, %v, want disabled", ok, err)
+	}
+}
@@ -0,0 +1,98 @@
+package systemdwut
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// Watchdog registers an observer with r which keeps the systemd watchdog of
+// the service alive, if the WATCHDOG_USEC environment variable is set for this
+// process, as it is by WatchdogSec= in the unit, reporting whether it is.
+//
+// WATCHDOG=1 is sent at half the watchdog interval for as long as the command
+// is healthy, until ctx is done or the Runner stops. Once maxFailures
+// consecutive attempts have failed, pings stop until an attempt succeeds, so
+// that systemd restarts the unit if the command stays broken. Combine it with
+// a [wut.Runner.ProcessTimeout], so that a wedged command results in failed
+// attempts rather than a hang.
+//
+// Watchdog must not be called while the Runner is running.
+func Watchdog(ctx context.Context, r *wut.Runner, maxFailures int) (bool, error) {
+	interval, ok := watchdogInterval()
+	if !ok {
+		return false, nil
+	}
+	conn, err := dial()
+	if conn == nil {
+		return false, err
+	}
+	wd := &watchdog{maxFailures: int64(maxFailures)}
+	r.Observe(wd.observe)
+	go wd.ping(ctx, conn, interval/2)
+	return true, nil
+}
+
+// watchdogInterval returns the watchdog interval configured for this process.
+func watchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false // intended for another process
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// watchdog tracks the health of a Runner. Its fields are accessed atomically,
+// as they are read from the pinging goroutine.
+type watchdog struct {
+	maxFailures int64
+	failures    atomic.Int64 // consecutive failed attempts
+	stopped     atomic.Bool  // whether the Runner has stopped
+}
+
+func (wd *watchdog) observe(e wut.Event) {
+	switch e.Kind {
+	case wut.EventAttemptEnd:
+		if e.Attempt.Err != nil {
+			wd.failures.Add(1)
+		} else {
+			wd.failures.Store(0)
+		}
+	case wut.EventRunEnd:
+		wd.stopped.Store(true)
+	}
+}
+
+// healthy reports whether the watchdog should be kept alive.
+func (wd *watchdog) healthy() bool {
+	return wd.maxFailures <= 0 || wd.failures.Load() < wd.maxFailures
+}
+
+// ping sends WATCHDOG=1 every interval while healthy, until ctx is done or
+// the Runner stops.
+func (wd *watchdog) ping(ctx context.Context, conn *net.UnixConn, interval time.Duration) {
+	defer conn.Close()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if wd.stopped.Load() {
+			return
+		}
+		if wd.healthy() {
+			conn.Write([]byte("WATCHDOG=1"))
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package systemdwut
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mroth/wut"
+	"github.com/mroth/wut/wuttest"
+)
+
+func TestWatchdog(t *testing.T) {
+	dir, err := os.MkdirTemp("", "sd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+	t.Setenv("WATCHDOG_USEC", "20000")
+	t.Setenv("WATCHDOG_PID", "")
+
+	// pings are sent while a healthy attempt is running
+	r := wut.NewRunner(t.Context(), "cmd")
+	r.SetExecutor(wuttest.NewExecutor(wuttest.Outcome{Sleep: 100 * time.Millisecond}))
+	if ok, err := Watchdog(t.Context(), r, 3); !ok || err != nil {
+		t.Fatalf("got %v, %v, want enabled", ok, err)
+	}
+	go r.Run()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("expected a ping: %v", err)
+	}
+	if got := string(buf[:n]); got != "WATCHDOG=1" {
+		t.Errorf("got %q, want WATCHDOG=1", got)
+	}
+}
+
+func TestWatchdog_healthy(t *testing.T) {
+	failed := wut.Event{Kind: wut.EventAttemptEnd, Attempt: wut.Attempt{Err: errors.New("exit status 1")}}
+	succeeded := wut.Event{Kind: wut.EventAttemptEnd}
+
+	wd := &watchdog{maxFailures: 2}
+	for i, tt := range []struct {
+		e    wut.Event
+		want bool
+	}{
+		{failed, true},
+		{failed, false},
+		{failed, false},
+		{succeeded, true},
+	} {
+		wd.observe(tt.e)
+		if got := wd.healthy(); got != tt.want {
+			t.Errorf("after event %d: got healthy %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestWatchdog_disabled(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "20000")
+	t.Setenv("WATCHDOG_PID", "1") // another process
+	r := wut.NewRunner(t.Context(), "cmd")
+	if ok, err := Watchdog(t.Context(), r, 3); ok || err != nil {
+		t.Errorf("got %v, %v, want disabled", ok, err)
+	}
+}
@@ -0,0 +1,47 @@
+package wut
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Trace records a timeline of the Events emitted by a Runner, for inspecting
+// and debugging its behavior after the fact.
+//
+// To enable tracing, register the Record method of a Trace as an observer:
+//
+//	var trace wut.Trace
+//	runner.Observe(trace.Record)
+//
+// The zero value is ready to use. Trace is safe for concurrent use.
+type Trace struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Record appends an event to the trace.
+func (t *Trace) Record(e Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, e)
+}
+
+// Events returns all events recorded so far, in order.
+func (t *Trace) Events() []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.events)
+}
+
+// String formats the trace as a timeline, one event per line, with each event
+// annotated by its time offset from the first recorded event.
+func (t *Trace) String() string {
+	events := t.Events()
+	var b strings.Builder
+	for _, e := range events {
+		fmt.Fprintf(&b, "+%-10v %v\n", e.Time.Sub(events[0].Time), e)
+	}
+	return b.String()
+}
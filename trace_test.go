@@ -0,0 +1,47 @@
+package wut
+
+import (
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestTrace(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewRunnerWithExecutor(t.Context(), mockExecutor{exitcode: 1, sleep: time.Millisecond})
+		r.MaxRuns = 2
+		r.RetryDelay = time.Second
+
+		var trace Trace
+		r.Observe(trace.Record)
+		r.Run()
+
+		var kinds []EventKind
+		for _, e := range trace.Events() {
+			kinds = append(kinds, e.Kind)
+		}
+		want := []EventKind{
+			EventRunStart,
+			EventAttemptStart, EventAttemptEnd, EventDelay,
+			EventAttemptStart, EventAttemptEnd, EventDelay,
+			EventRunEnd,
+		}
+		if !slices.Equal(kinds, want) {
+			t.Errorf("event kinds:\ngot  %v\nwant %v", kinds, want)
+		}
+
+		wantTimeline := `+0s         run start
++0s         attempt 1 start
++1ms        attempt 1 failed after 1ms: mock command failure with exit code 1
++1ms        delay 1s
++1.001s     attempt 2 start
++1.002s     attempt 2 failed after 1ms: mock command failure with exit code 1
++1.002s     delay 1s
++2.002s     run end: wut: maximum number of runs completed
+`
+		if got := trace.String(); got != wantTimeline {
+			t.Errorf("timeline:\ngot\n%s\nwant\n%s", got, wantTimeline)
+		}
+	})
+}
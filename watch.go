@@ -0,0 +1,114 @@
+package wut
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// watchPollInterval is the interval at which [Runner.Watch] polls the watched
+// paths for changes.
+const watchPollInterval = 250 * time.Millisecond
+
+// Watch runs the Runner as if by calling Run, then again each time any of the
+// files in paths, or within the directories in paths, change, until its
+// context is done.
+//
+// Changes are detected by polling the modification times and sizes of the
+// files, ignoring hidden files and directories such as .git within the
+// watched directories. Once a change is detected, Watch waits until no further
+// changes are seen for the debounce duration before starting a run, so that a
+// burst of changes, such as saving several files at once, triggers a single
+// run. Changes made while a run is in progress, including by the command
+// itself, do not trigger another run.
+//
+// As with [Runner.RunSchedule], each run applies the retry, timeout, and stop
+// conditions of the Runner afresh, and a failed run is logged without stopping
+// Watch. Watch returns the cause of the cancellation of the context of the
+// Runner.
+func (r *Runner) Watch(paths []string, debounce time.Duration) error {
+	timer := r.clock.NewTimer(0)
+	stopTimer(timer)
+	defer timer.Stop()
+	sleep := func(d time.Duration) error {
+		timer.Reset(d)
+		select {
+		case <-r.baseCtx.Done():
+			return context.Cause(r.baseCtx)
+		case <-timer.C():
+			return nil
+		}
+	}
+
+	first := true
+	return r.runEach(func() (bool, error) {
+		if first {
+			first = false
+			return true, nil
+		}
+		r.log(slog.LevelInfo, "Waiting for changes", "paths", paths)
+		prev := statPaths(paths)
+		for {
+			if err := sleep(watchPollInterval); err != nil {
+				return false, err
+			}
+			if cur := statPaths(paths); !maps.Equal(cur, prev) {
+				prev = cur
+				break
+			}
+		}
+		for debounce > 0 {
+			if err := sleep(debounce); err != nil {
+				return false, err
+			}
+			cur := statPaths(paths)
+			if maps.Equal(cur, prev) {
+				break
+			}
+			prev = cur
+		}
+		r.log(slog.LevelInfo, "Change detected, running")
+		return true, nil
+	})
+}
+
+// fileStamp identifies the version of a file, for detecting changes to it.
+type fileStamp struct {
+	modTime int64 // in nanoseconds since the Unix epoch
+	size    int64
+	mode    fs.FileMode
+}
+
+// statPaths returns the stamps of the files in paths, and of those within the
+// directories in paths, keyed by their path. Paths which cannot be
+// read are omitted, so that their creation is seen as a change.
+func statPaths(paths []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	for _, root := range paths {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil // any changes within are seen in the files themselves
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			stamps[path] = fileStamp{info.ModTime().UnixNano(), info.Size(), info.Mode()}
+			return nil
+		})
+	}
+	return stamps
+}
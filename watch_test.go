@@ -0,0 +1,61 @@
+package wut
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestRunner_Watch(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	synctest.Test(t, func(t *testing.T) {
+		errStop := errors.New("stop")
+		ctx, cancel := context.WithCancelCause(t.Context())
+		start := time.Now()
+		r := NewRunnerWithExecutor(ctx, mockExecutor{})
+		var runs []time.Duration
+		r.Observe(func(e Event) {
+			if e.Kind == EventRunStart {
+				runs = append(runs, time.Since(start))
+			}
+		})
+
+		go func() {
+			// a burst of changes triggers a single run once they settle
+			time.Sleep(1100 * time.Millisecond)
+			for i := range 3 {
+				os.WriteFile(file, []byte("package main\n"+strings.Repeat("\n", i+1)), 0o644)
+				time.Sleep(300 * time.Millisecond)
+			}
+			// as does creating a file, but not a hidden one
+			time.Sleep(3 * time.Second)
+			os.WriteFile(filepath.Join(dir, ".main.go.swp"), nil, 0o644)
+			time.Sleep(3 * time.Second)
+			os.WriteFile(filepath.Join(dir, "main_test.go"), nil, 0o644)
+			time.Sleep(3 * time.Second)
+			cancel(errStop)
+		}()
+
+		if err := r.Watch([]string{dir}, 500*time.Millisecond); !errors.Is(err, errStop) {
+			t.Errorf("got error %v, want %v", err, errStop)
+		}
+		if len(runs) != 3 || runs[0] != 0 {
+			t.Fatalf("runs started at %v, want 3 starting immediately", runs)
+		}
+		// the burst from 1.1s to 1.7s is first seen by the poll at 1.25s, then
+		// still changing when debounced at 1.75s, and settled by 2.25s
+		if want := 2250 * time.Millisecond; runs[1] != want {
+			t.Errorf("second run started at %v, want %v", runs[1], want)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package wuttest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// Clock is a fake [wut.Clock] whose time only moves when advanced manually,
+// allowing a Runner's retry delays to be driven deterministically.
+//
+// Clock is safe for concurrent use.
+type Clock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond // broadcast whenever the set of active timers changes
+	now    time.Time
+	timers []*timer // active timers
+}
+
+// verify Clock implements the wut.Clock interface
+var _ wut.Clock = (*Clock)(nil)
+
+// NewClock returns a Clock with its current time set to now.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer creates a Timer that fires once the clock has been advanced by d.
+// A Timer with a non-positive duration fires immediately.
+func (c *Clock) NewTimer(d time.Duration) wut.Timer {
+	t := &timer{clock: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// AfterFunc creates a Timer that calls f in its own goroutine once the clock
+// has been advanced by d. Its C method returns a channel that is never sent to.
+func (c *Clock) AfterFunc(d time.Duration, f func()) wut.Timer {
+	t := &timer{clock: c, ch: make(chan time.Time, 1), f: f}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing any timers that become due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.fireDue()
+}
+
+// BlockUntilTimers blocks until at least n timers are active, that is, created
+// or reset and not yet fired or stopped. It can be used to wait until a Runner
+// is waiting out a retry delay before advancing the clock.
+func (c *Clock) BlockUntilTimers(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// fireDue fires and deactivates all timers whose deadline has been reached.
+// The caller must hold c.mu.
+func (c *Clock) fireDue() {
+	active := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			active = append(active, t)
+			continue
+		}
+		if t.f != nil {
+			go t.f()
+			continue
+		}
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+	}
+	clear(c.timers[len(active):])
+	c.timers = active
+	c.cond.Broadcast()
+}
+
+// remove deactivates t, reporting whether it was active.
+// The caller must hold c.mu.
+func (c *Clock) remove(t *timer) bool {
+	for i, at := range c.timers {
+		if at == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// timer is a wut.Timer created by a fake Clock.
+type timer struct {
+	clock    *Clock
+	ch       chan time.Time
+	deadline time.Time
+	f        func() // called instead of sending on ch, if set
+}
+
+func (t *timer) C() <-chan time.Time { return t.ch }
+
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
+
+func (t *timer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wasActive := c.remove(t)
+	select { // as with time.Timer, no stale values are received after Reset
+	case <-t.ch:
+	default:
+	}
+	t.deadline = c.now.Add(d)
+	c.timers = append(c.timers, t)
+	c.fireDue()
+	return wasActive
+}
@@ -0,0 +1,117 @@
+package wuttest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewClock(start)
+
+	timer := c.NewTimer(time.Minute)
+	c.Advance(30 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	c.Advance(30 * time.Second)
+	select {
+	case got := <-timer.C():
+		if want := start.Add(time.Minute); !got.Equal(want) {
+			t.Errorf("fired at %v, want %v", got, want)
+		}
+	default:
+		t.Fatal("timer did not fire")
+	}
+	if timer.Stop() {
+		t.Error("Stop of fired timer: got true, want false")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("Reset of inactive timer: got true, want false")
+	}
+	if !timer.Stop() {
+		t.Error("Stop of active timer: got false, want true")
+	}
+	c.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+}
+
+func TestClock_AfterFunc(t *testing.T) {
+	c := NewClock(time.Now())
+	called := make(chan struct{})
+	c.AfterFunc(time.Minute, func() { close(called) })
+	c.Advance(30 * time.Second)
+	select {
+	case <-called:
+		t.Fatal("func called early")
+	default:
+	}
+
+	c.Advance(30 * time.Second)
+	<-called // blocks until called
+
+	stopped := c.AfterFunc(time.Minute, func() { t.Error("stopped func called") })
+	if !stopped.Stop() {
+		t.Error("Stop of active timer: got false, want true")
+	}
+	c.Advance(time.Hour)
+}
+
+func TestClock_Runner(t *testing.T) {
+	c := NewClock(time.Now())
+	e := FailTimes(2)
+	r := wut.NewRunner(t.Context(), "flaky")
+	r.SetExecutor(e)
+	r.SetClock(c)
+	r.RetryDelay = time.Hour
+
+	done := make(chan error)
+	go func() { done <- r.Run() }()
+
+	for range 2 {
+		c.BlockUntilTimers(1)
+		c.Advance(time.Hour)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := e.Runs(); got != 3 {
+		t.Errorf("runs: got %d, want 3", got)
+	}
+}
+
+func TestClock_IdleTimeout(t *testing.T) {
+	c := NewClock(time.Now())
+	r := wut.NewRunner(t.Context(), "hang")
+	r.SetExecutor(NewExecutor(Outcome{Sleep: time.Hour}))
+	r.SetClock(c)
+	r.IdleTimeout = time.Minute
+	r.MaxRuns = 1
+	var last wut.Attempt
+	r.Observe(func(e wut.Event) {
+		if e.Kind == wut.EventAttemptEnd {
+			last = e.Attempt
+		}
+	})
+
+	done := make(chan error)
+	go func() { done <- r.Run() }()
+
+	c.BlockUntilTimers(1)
+	c.Advance(time.Minute)
+	<-done
+	if !errors.Is(last.Err, wut.ErrIdleTimeout) {
+		t.Errorf("attempt error: got %v, want %v", last.Err, wut.ErrIdleTimeout)
+	}
+}
@@ -0,0 +1,125 @@
+// Package wuttest provides utilities for testing code that embeds a [wut.Runner].
+package wuttest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+// Outcome describes the scripted result of a single run of an Executor.
+type Outcome struct {
+	Sleep    time.Duration // 1. First, sleep for the specified duration (simulating processing time)
+	Stdout   string        // 2. Then, write output to the standard output stream
+	Stderr   string        //    and the standard error stream
+	ExitCode int           // 3. Finally, exit with the specified exit code
+}
+
+// ExitError is the error returned by an Executor for a run scripted with a
+// non-zero exit code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the scripted exit code, mirroring [os/exec.ExitError].
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// Call records the details of a single run of an Executor.
+type Call struct {
+	Name string
+	Args []string
+	Opts wut.CommandOpts
+}
+
+// Executor is a fake [wut.Executor] whose outcomes are scripted per run, so
+// that a Runner can be exercised without spawning real processes.
+//
+// Each run consumes the next Outcome in order. Once all outcomes have been
+// consumed, the final Outcome is repeated for all subsequent runs. If no
+// outcomes are scripted, every run succeeds immediately.
+//
+// If the context is done while sleeping, the run returns the context error.
+// Executor is safe for concurrent use.
+type Executor struct {
+	mu       sync.Mutex
+	outcomes []Outcome
+	calls    []Call
+}
+
+// verify Executor implements the wut.Executor interface
+var _ wut.Executor = (*Executor)(nil)
+
+// NewExecutor returns an Executor scripted with the given outcomes.
+func NewExecutor(outcomes ...Outcome) *Executor {
+	return &Executor{outcomes: outcomes}
+}
+
+// FailTimes returns an Executor that fails with exit code 1 for the first n
+// runs, and succeeds thereafter.
+func FailTimes(n int) *Executor {
+	outcomes := make([]Outcome, n, n+1)
+	for i := range outcomes {
+		outcomes[i].ExitCode = 1
+	}
+	return NewExecutor(append(outcomes, Outcome{})...)
+}
+
+func (e *Executor) Run(ctx context.Context, opts wut.CommandOpts, name string, args ...string) error {
+	e.mu.Lock()
+	var o Outcome
+	if n := len(e.outcomes); n > 0 {
+		o = e.outcomes[min(len(e.calls), n-1)]
+	}
+	e.calls = append(e.calls, Call{Name: name, Args: slices.Clone(args), Opts: opts})
+	e.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(o.Sleep):
+	}
+
+	if err := write(opts.Stdout, o.Stdout); err != nil {
+		return err
+	}
+	if err := write(opts.Stderr, o.Stderr); err != nil {
+		return err
+	}
+	if o.ExitCode != 0 {
+		return &ExitError{Code: o.ExitCode}
+	}
+	return nil
+}
+
+func write(w io.Writer, s string) error {
+	if w == nil || s == "" {
+		return nil
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// Calls returns the details of all runs performed so far, in order.
+func (e *Executor) Calls() []Call {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.calls)
+}
+
+// Runs returns the number of runs performed so far.
+func (e *Executor) Runs() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.calls)
+}
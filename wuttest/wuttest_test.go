@@ -0,0 +1,82 @@
+package wuttest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/mroth/wut"
+)
+
+func TestExecutor(t *testing.T) {
+	t.Run("scripted outcomes", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			e := NewExecutor(
+				Outcome{Sleep: time.Second, Stderr: "not yet\n", ExitCode: 2},
+				Outcome{Stdout: "ready\n"},
+			)
+
+			var stdout, stderr bytes.Buffer
+			opts := wut.CommandOpts{Stdout: &stdout, Stderr: &stderr}
+
+			start := time.Now()
+			err := e.Run(t.Context(), opts, "check", "--all")
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) || exitErr.Code != 2 {
+				t.Errorf("run 1: got %v, want exit status 2", err)
+			}
+			if elapsed := time.Since(start); elapsed != time.Second {
+				t.Errorf("run 1: elapsed: got %v, want %v", elapsed, time.Second)
+			}
+
+			for i := range 2 { // final outcome is repeated
+				if err := e.Run(t.Context(), opts, "check"); err != nil {
+					t.Errorf("run %d: unexpected error: %v", i+2, err)
+				}
+			}
+
+			if got, want := stdout.String(), "ready\nready\n"; got != want {
+				t.Errorf("stdout: got %q, want %q", got, want)
+			}
+			if got, want := stderr.String(), "not yet\n"; got != want {
+				t.Errorf("stderr: got %q, want %q", got, want)
+			}
+			if got := e.Runs(); got != 3 {
+				t.Errorf("runs: got %d, want 3", got)
+			}
+			if call := e.Calls()[0]; call.Name != "check" || len(call.Args) != 1 || call.Args[0] != "--all" {
+				t.Errorf("call 1: got %+v", call)
+			}
+		})
+	})
+
+	t.Run("context cancellation", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			e := NewExecutor(Outcome{Sleep: time.Hour})
+			ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+			defer cancel()
+			if err := e.Run(ctx, wut.CommandOpts{}, "slow"); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+			}
+		})
+	})
+
+	t.Run("with runner", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			e := FailTimes(3)
+			r := wut.NewRunner(t.Context(), "flaky")
+			r.SetExecutor(e)
+			r.RetryDelay = time.Second
+
+			if err := r.Run(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if got := e.Runs(); got != 4 {
+				t.Errorf("runs: got %d, want 4", got)
+			}
+		})
+	})
+}